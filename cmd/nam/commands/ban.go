@@ -0,0 +1,264 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/api"
+	"github.com/nodeaccessmanager/nam/internal/config"
+	"github.com/nodeaccessmanager/nam/internal/enforcer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	banPort      int
+	banAllPorts  bool
+	banDuration  string
+	banPermanent bool
+	banReason    string
+
+	unbanPort int
+
+	bansPort int
+	bansJSON bool
+)
+
+var banCmd = &cobra.Command{
+	Use:   "ban <ip|cidr>",
+	Short: "手动封禁 IP 或网段",
+	Long: `通过运行中的守护进程断开目标的连接并下发封禁，封禁以 MANUAL 策略记录并持久化。
+未指定 --duration 时使用端口规则的 ban_duration（规则未配置封禁时长时必须显式指定）。
+永久封禁需使用 --permanent 或 --duration 0。`,
+	Example: `  nam ban 203.0.113.7 --port 443 --duration 1h --reason abuse
+  nam ban 198.51.100.0/24 --all-ports --permanent`,
+	Args: cobra.ExactArgs(1),
+	Run:  runBan,
+}
+
+var unbanCmd = &cobra.Command{
+	Use:   "unban <ip|cidr>",
+	Short: "解除手动或自动封禁",
+	Long:  `通过运行中的守护进程解除封禁。未指定 --port 时解除该目标在所有端口上的封禁。`,
+	Args:  cobra.ExactArgs(1),
+	Run:   runUnban,
+}
+
+var bansCmd = &cobra.Command{
+	Use:   "bans",
+	Short: "列出活跃封禁",
+	Args:  cobra.NoArgs,
+	Run:   runBans,
+}
+
+func runBan(cmd *cobra.Command, args []string) {
+	target, err := enforcer.NormalizeIP(args[0])
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if banAllPorts == (banPort != 0) {
+		fmt.Println("❌ 请指定 --port 或 --all-ports（二选一）")
+		os.Exit(1)
+	}
+	if banPermanent && banDuration != "" {
+		fmt.Println("❌ --permanent 与 --duration 不能同时使用")
+		os.Exit(1)
+	}
+
+	// 先确认守护进程在运行，再解析时长
+	client := newAPIClient()
+	status, err := client.Status()
+	if err != nil {
+		exitAPIError("封禁", err)
+	}
+
+	ports := []int{banPort}
+	if banAllPorts {
+		ports = ports[:0]
+		for _, port := range status.Ports {
+			ports = append(ports, port.Port)
+		}
+		if len(ports) == 0 {
+			fmt.Println("⚠️  守护进程没有监控任何端口")
+			os.Exit(1)
+		}
+	}
+
+	durations, err := resolveBanDurations(ports)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, port := range ports {
+		req := api.BanRequest{IP: target, Port: port, Duration: durations[port], Reason: banReason}
+		if err := client.Ban(req); err != nil {
+			if errors.Is(err, api.ErrDaemonNotRunning) {
+				exitAPIError("封禁", err)
+			}
+			fmt.Printf("❌ 端口 %d 封禁失败: %v\n", port, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ 已封禁 %s（端口 %d，时长 %s）\n", target, port, formatBanDuration(req.Duration))
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// resolveBanDurations 各端口的封禁时长（秒）：--permanent / --duration 优先，否则取配置文件中端口规则的 ban_duration
+// 配置中的 0 表示"不封禁"而非永久，此时拒绝执行，永久封禁只能显式指定
+func resolveBanDurations(ports []int) (map[int]int, error) {
+	durations := make(map[int]int, len(ports))
+	if banPermanent || banDuration != "" {
+		seconds := 0
+		if !banPermanent {
+			var err error
+			if seconds, err = config.ParseDuration(banDuration); err != nil {
+				return nil, err
+			}
+		}
+		for _, port := range ports {
+			durations[port] = seconds
+		}
+		return durations, nil
+	}
+
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return nil, fmt.Errorf("未指定 --duration 且无法读取配置: %w", err)
+	}
+	for _, port := range ports {
+		durations[port] = cfg.Global.BanDuration
+		if rule := cfg.GetRuleByPort(port); rule != nil {
+			durations[port] = rule.GetEffectiveBanDuration(cfg.Global.BanDuration)
+		}
+		if durations[port] == 0 {
+			return nil, fmt.Errorf("端口 %d 未配置 ban_duration，请通过 --duration 指定时长，或使用 --permanent 永久封禁", port)
+		}
+	}
+	return durations, nil
+}
+
+func runUnban(cmd *cobra.Command, args []string) {
+	target, err := enforcer.NormalizeIP(args[0])
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	client := newAPIClient()
+
+	ports := []int{unbanPort}
+	if unbanPort == 0 {
+		bans, err := client.Bans()
+		if err != nil {
+			exitAPIError("解封", err)
+		}
+		ports = ports[:0]
+		for _, ban := range bans {
+			if ban.IP == target {
+				ports = append(ports, ban.Port)
+			}
+		}
+		if len(ports) == 0 {
+			fmt.Printf("⚠️  %s 当前没有活跃封禁\n", target)
+			os.Exit(1)
+		}
+		sort.Ints(ports)
+	}
+
+	failed := 0
+	for _, port := range ports {
+		if err := client.Unban(api.UnbanRequest{IP: target, Port: port}); err != nil {
+			if errors.Is(err, api.ErrDaemonNotRunning) {
+				exitAPIError("解封", err)
+			}
+			fmt.Printf("❌ 端口 %d 解封失败: %v\n", port, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ 已解封 %s（端口 %d）\n", target, port)
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func runBans(cmd *cobra.Command, args []string) {
+	bans, err := newAPIClient().Bans()
+	if err != nil {
+		exitAPIError("获取封禁列表", err)
+	}
+
+	filtered := make([]enforcer.BanRecord, 0, len(bans))
+	for _, ban := range bans {
+		if bansPort == 0 || ban.Port == bansPort {
+			filtered = append(filtered, ban)
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		if filtered[i].Port != filtered[j].Port {
+			return filtered[i].Port < filtered[j].Port
+		}
+		return filtered[i].BannedAt.Before(filtered[j].BannedAt)
+	})
+
+	if bansJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(filtered); err != nil {
+			fmt.Printf("❌ 输出 JSON 失败: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(filtered) == 0 {
+		fmt.Println("✅ 当前没有活跃封禁")
+		return
+	}
+
+	now := time.Now()
+	fmt.Printf("%-39s %-6s %-10s %-10s %-20s %s\n", "IP 地址", "端口", "策略", "剩余", "封禁时间", "原因")
+	for _, ban := range filtered {
+		remaining := "永久"
+		if ban.Duration > 0 {
+			remaining = max(ban.ExpireAt.Sub(now), 0).Round(time.Second).String()
+		}
+		fmt.Printf("%-39s %-6d %-10s %-10s %-20s %s\n",
+			ban.IP, ban.Port, ban.Strategy, remaining,
+			ban.BannedAt.Local().Format("2006-01-02 15:04:05"), ban.Reason)
+	}
+	fmt.Printf("\n共 %d 条封禁\n", len(filtered))
+}
+
+// formatBanDuration 封禁时长（0 表示永久）
+func formatBanDuration(seconds int) string {
+	if seconds == 0 {
+		return "永久"
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
+
+func init() {
+	banCmd.Flags().IntVarP(&banPort, "port", "p", 0, "封禁的端口")
+	banCmd.Flags().BoolVar(&banAllPorts, "all-ports", false, "在守护进程监控的所有端口上封禁")
+	banCmd.Flags().StringVar(&banDuration, "duration", "", "封禁时长，秒数或 10m、1h 等写法，0 表示永久（默认取规则 ban_duration）")
+	banCmd.Flags().BoolVar(&banPermanent, "permanent", false, "永久封禁")
+	banCmd.Flags().StringVar(&banReason, "reason", "manual", "封禁原因")
+	unbanCmd.Flags().IntVarP(&unbanPort, "port", "p", 0, "解封的端口（默认解除所有端口上的封禁）")
+	bansCmd.Flags().IntVarP(&bansPort, "port", "p", 0, "只列出指定端口的封禁")
+	bansCmd.Flags().BoolVar(&bansJSON, "json", false, "以 JSON 格式输出")
+	rootCmd.AddCommand(banCmd)
+	rootCmd.AddCommand(unbanCmd)
+	rootCmd.AddCommand(bansCmd)
+}
@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/nodeaccessmanager/nam/internal/config"
+	"github.com/nodeaccessmanager/nam/internal/core"
+	"github.com/nodeaccessmanager/nam/internal/enforcer"
+	"github.com/nodeaccessmanager/nam/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanupDryRun      bool
+	cleanupForce       bool
+	cleanupRemoveChain bool
+)
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "清理 NAM 创建的防火墙规则",
+	Long: `清空 NAM 创建的全部封禁（iptables NAM-INPUT 链、ipset 集合、nftables inet nam 表），
+并清空数据库中的活跃封禁记录，避免下次启动时被重新下发。
+
+使用 --dry-run 仅列出将被删除的规则；
+使用 --remove-chain 同时删除 NAM-INPUT 链、ipset 集合与 inet nam 表（卸载时使用）。`,
+	Run: runCleanup,
+}
+
+func runCleanup(cmd *cobra.Command, args []string) {
+	cleanupPidFile := pidFile
+	if cleanupPidFile == "" {
+		cleanupPidFile = core.DefaultPIDFile
+	}
+
+	// 守护进程运行中清理会使其封禁状态与内核不一致
+	if running, pid, _ := core.CheckDaemonStatus(cleanupPidFile); running && !cleanupDryRun && !cleanupForce {
+		fmt.Printf("❌ NAM 正在运行 (PID: %d)\n", pid)
+		fmt.Println("   请先停止服务（nam stop --flush-bans），或使用 --force 强制清理")
+		os.Exit(1)
+	}
+
+	if err := flushBans(cleanupDryRun); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	if cleanupRemoveChain && !cleanupDryRun {
+		if err := teardownBackends(); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// flushBans 清理全部 NAM 防火墙规则及持久化的活跃封禁
+func flushBans(dryRun bool) error {
+	if dryRun {
+		fmt.Println("🔍 以下 NAM 规则将被删除 (dry-run):")
+	} else {
+		fmt.Println("🧹 清理 NAM 防火墙规则...")
+	}
+
+	// 遍历全部可用后端（切换过 firewall_backend 时旧后端的规则也一并清理）
+	total := 0
+	var errs []error
+	for _, backend := range enforcer.AllBanBackends() {
+		if backend.Probe() != nil {
+			continue
+		}
+
+		entries, err := backend.Flush(dryRun)
+		for _, entry := range entries {
+			fmt.Printf("   %s %s: %s  (%d pkts, %d bytes)\n",
+				entry.Binary, entry.Chain, entry.Rule, entry.Packets, entry.Bytes)
+		}
+		total += len(entries)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", backend.Name(), err))
+		}
+	}
+
+	if total == 0 {
+		fmt.Println("   （无 NAM 规则）")
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("清理规则失败: %w", err)
+	}
+
+	if dryRun {
+		fmt.Printf("共 %d 条规则\n", total)
+		return nil
+	}
+
+	// 清空活跃封禁记录，避免下次启动对账时重新下发
+	if err := clearActiveBans(); err != nil {
+		fmt.Printf("⚠️  清空活跃封禁记录失败: %v\n", err)
+	}
+
+	fmt.Printf("✅ 已删除 %d 条规则\n", total)
+	return nil
+}
+
+// teardownBackends 删除全部后端中 NAM 专用的链/表
+func teardownBackends() error {
+	var errs []error
+	for _, backend := range enforcer.AllBanBackends() {
+		if backend.Probe() != nil {
+			continue
+		}
+		if err := backend.Teardown(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", backend.Name(), err))
+			continue
+		}
+		fmt.Printf("✅ 已删除 %s 的 NAM 链/表\n", backend.Name())
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("删除 NAM 链/表失败: %w", err)
+	}
+	return nil
+}
+
+// clearActiveBans 清空数据库中的活跃封禁
+func clearActiveBans() error {
+	dbPath := config.DefaultConfig().Global.DatabasePath
+	if cfg, err := config.Load(cfgFile); err == nil && cfg.Global.DatabasePath != "" {
+		dbPath = cfg.Global.DatabasePath
+	}
+
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	db, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.ClearActiveBans()
+}
+
+func init() {
+	cleanupCmd.Flags().BoolVar(&cleanupDryRun, "dry-run", false, "仅列出将被删除的规则")
+	cleanupCmd.Flags().BoolVar(&cleanupForce, "force", false, "守护进程运行时仍强制清理")
+	cleanupCmd.Flags().BoolVar(&cleanupRemoveChain, "remove-chain", false, "同时删除 NAM-INPUT 链、ipset 集合与 inet nam 表")
+	rootCmd.AddCommand(cleanupCmd)
+}
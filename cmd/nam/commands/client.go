@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/nodeaccessmanager/nam/internal/api"
+	"github.com/nodeaccessmanager/nam/internal/config"
+)
+
+// apiSocketPath 控制接口套接字路径: --socket 参数 > 配置文件 api_socket > 默认路径
+func apiSocketPath() string {
+	if socketPath != "" {
+		return socketPath
+	}
+	if cfg, err := config.Load(cfgFile); err == nil {
+		return cfg.GetAPISocket()
+	}
+	return config.DefaultAPISocket
+}
+
+// newAPIClient 创建连接到守护进程控制接口的客户端
+func newAPIClient() *api.Client {
+	return api.NewClient(apiSocketPath())
+}
+
+// exitAPIError 输出控制接口请求失败的原因并退出（守护进程未运行时给出启动提示）
+func exitAPIError(action string, err error) {
+	if errors.Is(err, api.ErrDaemonNotRunning) {
+		fmt.Printf("❌ NAM 守护进程未运行，无法%s\n", action)
+		fmt.Println("   启动服务: nam start")
+	} else {
+		fmt.Printf("❌ %s失败: %v\n", action, err)
+	}
+	os.Exit(1)
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -45,7 +46,16 @@ var configValidateCmd = &cobra.Command{
 
 		cfg, err := config.Load(cfgFile)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "❌ 配置验证失败: %v\n", err)
+			var verrs config.ValidationErrors
+			if !errors.As(err, &verrs) {
+				fmt.Fprintf(os.Stderr, "❌ 配置验证失败: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Fprintf(os.Stderr, "❌ 配置验证失败，共 %d 个错误:\n", len(verrs))
+			for _, e := range verrs {
+				fmt.Fprintf(os.Stderr, "   - %s\n", e)
+			}
 			os.Exit(1)
 		}
 
@@ -1,9 +1,11 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/nodeaccessmanager/nam/internal/api"
 	"github.com/nodeaccessmanager/nam/internal/core"
 	"github.com/spf13/cobra"
 )
@@ -11,17 +13,31 @@ import (
 var reloadCmd = &cobra.Command{
 	Use:   "reload",
 	Short: "重载配置文件",
-	Long:  `向运行中的 NAM 守护进程发送 SIGHUP 信号，重新加载配置文件`,
-	Run:   runReload,
+	Long: `通知运行中的 NAM 守护进程重新加载配置文件并返回变更结果。
+控制接口不可用时回退为发送 SIGHUP 信号（无法获知重载结果）。`,
+	Run: runReload,
 }
 
 func runReload(cmd *cobra.Command, args []string) {
+	fmt.Println("🔄 重载配置...")
+
+	summary, err := newAPIClient().Reload()
+	if err == nil {
+		fmt.Println("✅ 配置重载完成")
+		fmt.Printf("   %s\n", summary)
+		return
+	}
+	if !errors.Is(err, api.ErrDaemonNotRunning) {
+		fmt.Printf("❌ 重载失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 控制接口不可用：回退到 SIGHUP
 	reloadPidFile := pidFile
 	if reloadPidFile == "" {
 		reloadPidFile = core.DefaultPIDFile
 	}
 
-	// 检查是否在运行
 	running, pid, err := core.CheckDaemonStatus(reloadPidFile)
 	if err != nil {
 		fmt.Printf("❌ %v\n", err)
@@ -33,7 +49,7 @@ func runReload(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	fmt.Printf("🔄 重载配置 (PID: %d)...\n", pid)
+	fmt.Printf("⚠️  控制接口不可用，发送 SIGHUP 信号 (PID: %d)...\n", pid)
 
 	// 发送 SIGHUP 信号
 	if err := core.ReloadDaemon(reloadPidFile); err != nil {
@@ -17,6 +17,8 @@ var (
 	cfgFile string
 	// 调试模式
 	debug bool
+	// 控制接口套接字路径（为空时取配置文件中的 api_socket）
+	socketPath string
 )
 
 // rootCmd 根命令
@@ -58,6 +60,7 @@ func init() {
 	// 全局参数
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "/etc/nam/config.yaml", "配置文件路径")
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "d", false, "启用调试模式")
+	rootCmd.PersistentFlags().StringVar(&socketPath, "socket", "", "控制接口套接字路径（默认取配置文件 api_socket）")
 
 	// 添加子命令
 	rootCmd.AddCommand(versionCmd)
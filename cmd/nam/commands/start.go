@@ -6,6 +6,8 @@ import (
 	"os/exec"
 	"syscall"
 
+	"github.com/nodeaccessmanager/nam/internal/api"
+	"github.com/nodeaccessmanager/nam/internal/config"
 	"github.com/nodeaccessmanager/nam/internal/core"
 	"github.com/nodeaccessmanager/nam/pkg/utils"
 	"github.com/spf13/cobra"
@@ -92,6 +94,7 @@ func startForeground() {
 	if err != nil {
 		logger.Fatalf("创建应用失败: %v", err)
 	}
+	app.SetDebug(debug)
 
 	// 写入 PID 文件
 	if err := core.WritePIDFile(pidFile); err != nil {
@@ -104,24 +107,31 @@ func startForeground() {
 		logger.Fatalf("启动应用失败: %v", err)
 	}
 
+	// 启动控制接口（status / reload / tui 等命令通过它访问守护进程）
+	server := api.NewServer(app, app.APISocket())
+	if err := server.Start(); err != nil {
+		logger.Errorf("启动控制接口失败: %v", err)
+	} else {
+		app.OnShutdown(func() {
+			if err := server.Close(); err != nil {
+				logger.Errorf("关闭控制接口失败: %v", err)
+			}
+		})
+	}
+
 	// 阻塞等待
 	select {}
 }
 
-// initLogger 初始化日志系统
+// initLogger 按配置文件初始化日志系统
+// 配置无法加载时使用默认设置，随后创建应用失败的原因仍会写入默认日志文件
 func initLogger() error {
-	logLevel := "info"
-	if debug {
-		logLevel = "debug"
+	opts := core.DefaultLogOptions(debug)
+	if cfg, err := config.Load(cfgFile); err == nil {
+		opts = core.LogOptions(cfg, debug)
 	}
 
-	return utils.InitLogger(
-		"/var/log/nam/nam.log",
-		logLevel,
-		100, // maxSize MB
-		7,   // maxBackups
-		30,  // maxAge days
-	)
+	return utils.ConfigureLogger(opts)
 }
 
 func init() {
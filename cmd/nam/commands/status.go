@@ -1,9 +1,12 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/nodeaccessmanager/nam/internal/api"
 	"github.com/nodeaccessmanager/nam/internal/core"
 	"github.com/spf13/cobra"
 )
@@ -23,27 +26,56 @@ func runStatus(cmd *cobra.Command, args []string) {
 	fmt.Println("📊 NAM 状态信息")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
-	// 检查运行状态
-	running, pid, err := core.CheckDaemonStatus(statusPidFile)
-	if err != nil {
-		fmt.Printf("状态: ❌ 未运行\n")
-		fmt.Printf("详情: %v\n", err)
-		os.Exit(1)
+	// 优先通过控制接口获取运行时信息
+	client := newAPIClient()
+	status, err := client.Status()
+	if err == nil {
+		printStatus(status)
+		fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+		fmt.Println("💡 提示: 使用 'nam tui' 查看实时会话与封禁")
+		return
 	}
 
-	if !running {
+	// 控制接口不可用时回退到 PID 文件
+	running, pid, pidErr := core.CheckDaemonStatus(statusPidFile)
+	if pidErr != nil || !running {
 		fmt.Println("状态: ❌ 未运行")
+		if pidErr != nil {
+			fmt.Printf("详情: %v\n", pidErr)
+		}
 		os.Exit(1)
 	}
 
 	fmt.Printf("状态: ✅ 运行中\n")
 	fmt.Printf("PID:  %d\n", pid)
 	fmt.Printf("配置: %s\n", cfgFile)
-
-	// TODO: 可以通过 Unix Socket 或 HTTP API 获取更详细的运行时信息
-	// 例如：监控端口列表、当前连接数、封禁列表等
-	// 这将在 Phase 8 (API/管理接口) 中实现
+	if errors.Is(err, api.ErrDaemonNotRunning) {
+		fmt.Printf("⚠️  控制接口 %s 不可用，无法获取运行时信息\n", client.SocketPath())
+	} else {
+		fmt.Printf("⚠️  %v\n", err)
+	}
 
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	fmt.Println("💡 提示: 查看详细日志: tail -f /var/log/nam/nam.log")
 }
+
+// printStatus 输出守护进程返回的运行状态
+func printStatus(status *core.Status) {
+	fmt.Printf("状态: ✅ 运行中\n")
+	fmt.Printf("PID:  %d\n", status.PID)
+	fmt.Printf("配置: %s\n", status.ConfigPath)
+	fmt.Printf("运行: %s（启动于 %s）\n",
+		status.Uptime.Round(time.Second), status.StartTime.Format("2006-01-02 15:04:05"))
+	fmt.Printf("防火墙后端: %s\n", status.Backend)
+	fmt.Printf("活跃封禁: %d\n", status.TotalBans)
+
+	fmt.Printf("\n%-8s %-10s %-16s %s\n", "端口", "协议", "标签", "在线/上限")
+	for _, port := range status.Ports {
+		mark := "✅"
+		if port.CurrentIPs > port.MaxIPs {
+			mark = "⚠️ "
+		}
+		fmt.Printf("%-8d %-10s %-16s %d/%d %s\n",
+			port.Port, port.Protocol, port.Tag, port.CurrentIPs, port.MaxIPs, mark)
+	}
+}
@@ -9,9 +9,12 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var stopFlushBans bool
+
 var stopCmd = &cobra.Command{
 	Use:   "stop",
 	Short: "停止守护进程",
+	Long:  `停止 NAM 守护进程。默认保留已下发的封禁规则，使用 --flush-bans 同时清理全部 NAM 规则`,
 	Run:   runStop,
 }
 
@@ -64,4 +67,16 @@ func runStop(cmd *cobra.Command, args []string) {
 	core.RemovePIDFile(stopPidFile)
 
 	fmt.Println("✅ NAM 已停止")
+
+	// 可选：清理全部封禁
+	if stopFlushBans {
+		if err := flushBans(false); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func init() {
+	stopCmd.Flags().BoolVar(&stopFlushBans, "flush-bans", false, "停止后清理全部 NAM 防火墙规则")
 }
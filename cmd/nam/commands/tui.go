@@ -1,22 +1,27 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nodeaccessmanager/nam/internal/api"
 	"github.com/nodeaccessmanager/nam/internal/config"
-	"github.com/nodeaccessmanager/nam/internal/core"
+	"github.com/nodeaccessmanager/nam/internal/geoip"
 	"github.com/nodeaccessmanager/nam/internal/tui"
 	"github.com/nodeaccessmanager/nam/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
+var tuiInspect bool
+
 var tuiCmd = &cobra.Command{
 	Use:   "tui",
 	Short: "启动 TUI 监控界面",
-	Long:  `启动基于终端的交互式监控界面，实时查看端口状态和封禁信息`,
-	Run:   runTUI,
+	Long: `启动基于终端的交互式监控界面，通过控制接口实时查看端口状态、会话、封禁和事件。
+守护进程未运行时（或指定 --inspect）进入检查模式，只读查看数据库中的封禁与历史记录。`,
+	Run: runTUI,
 }
 
 func runTUI(cmd *cobra.Command, args []string) {
@@ -34,32 +39,22 @@ func runTUI(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// 检查守护进程是否运行
-	tuiPidFile := pidFile
-	if tuiPidFile == "" {
-		tuiPidFile = core.DefaultPIDFile
-	}
-
-	running, pid, _ := core.CheckDaemonStatus(tuiPidFile)
-	if !running {
-		fmt.Println("❌ NAM 守护进程未运行")
-		fmt.Println("   请先启动服务: nam start")
-		os.Exit(1)
-	}
+	source := connectTUISource(cfg)
+	defer source.Close()
 
-	fmt.Printf("🔗 连接到 NAM 守护进程 (PID: %d)\n", pid)
-	fmt.Println("⏳ 加载监控界面...")
+	// 创建 TUI 模型
+	model := tui.NewModel(source, cfg)
 
-	// 创建应用实例（只读模式，用于获取状态）
-	app, err := core.NewApp(cfgFile)
-	if err != nil {
-		fmt.Printf("❌ 创建应用实例失败: %v\n", err)
-		os.Exit(1)
+	// GeoIP 数据为可选项，加载失败时不显示国家
+	if cfg.Global.GeoIPFile != "" {
+		db, err := geoip.Open(cfg.Global.GeoIPFile)
+		if err != nil {
+			fmt.Printf("⚠️  %v（不显示来源国家）\n", err)
+		} else {
+			model.SetGeoIP(db)
+		}
 	}
 
-	// 创建 TUI 模型
-	model := tui.NewModel(app, cfg)
-
 	// 启动 TUI
 	p := tea.NewProgram(
 		model,
@@ -73,6 +68,33 @@ func runTUI(cmd *cobra.Command, args []string) {
 	}
 }
 
+// connectTUISource 优先连接运行中的守护进程，未运行时回退到检查模式
+func connectTUISource(cfg *config.Config) tui.DataSource {
+	if !tuiInspect {
+		client := newAPIClient()
+		status, err := client.Status()
+		if err == nil {
+			fmt.Printf("🔗 连接到 NAM 守护进程 (PID: %d)\n", status.PID)
+			fmt.Println("⏳ 加载监控界面...")
+			return tui.NewLiveSource(client)
+		}
+		if !errors.Is(err, api.ErrDaemonNotRunning) {
+			fmt.Printf("❌ 连接守护进程失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("⚠️  NAM 守护进程未运行，进入检查模式（只读数据库）")
+		fmt.Println("   启动服务: nam start")
+	}
+
+	source, err := tui.NewInspectSource(cfg, cfgFile)
+	if err != nil {
+		fmt.Printf("❌ 打开数据库失败: %v\n", err)
+		os.Exit(1)
+	}
+	return source
+}
+
 func init() {
+	tuiCmd.Flags().BoolVar(&tuiInspect, "inspect", false, "不连接守护进程，只读查看数据库")
 	rootCmd.AddCommand(tuiCmd)
 }
@@ -26,7 +26,7 @@ cat > /etc/nam/config.yaml <<EOF
 global:
   check_interval: 1
   ban_duration: 60
-  white_list_cidr:
+  whitelist:
     - "127.0.0.1/32"
 rules:
   - port: 10000
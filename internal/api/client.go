@@ -0,0 +1,187 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/core"
+	"github.com/nodeaccessmanager/nam/internal/enforcer"
+	"github.com/nodeaccessmanager/nam/internal/monitor"
+)
+
+// ErrDaemonNotRunning 控制接口套接字不存在或无人监听
+var ErrDaemonNotRunning = errors.New("NAM 守护进程未运行（控制接口不可用）")
+
+// clientTimeout 单次请求超时（热重载需要重建监控，给足时间）
+const clientTimeout = 30 * time.Second
+
+// Client 控制接口客户端
+type Client struct {
+	socketPath string
+	httpClient *http.Client
+}
+
+// NewClient 创建连接到指定套接字的客户端
+func NewClient(socketPath string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+
+	return &Client{
+		socketPath: socketPath,
+		httpClient: &http.Client{Transport: transport, Timeout: clientTimeout},
+	}
+}
+
+// SocketPath 套接字路径
+func (c *Client) SocketPath() string {
+	return c.socketPath
+}
+
+// Status 获取运行状态
+func (c *Client) Status() (*core.Status, error) {
+	var status core.Status
+	if err := c.do(http.MethodGet, pathStatus, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Sessions 获取端口的活跃会话
+func (c *Client) Sessions(port int) ([]*monitor.Session, error) {
+	var sessions []*monitor.Session
+	path := pathSessions + "?" + url.Values{"port": {strconv.Itoa(port)}}.Encode()
+	if err := c.do(http.MethodGet, path, nil, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Bans 获取活跃封禁
+func (c *Client) Bans() ([]enforcer.BanRecord, error) {
+	var bans []enforcer.BanRecord
+	if err := c.do(http.MethodGet, pathBans, nil, &bans); err != nil {
+		return nil, err
+	}
+	return bans, nil
+}
+
+// Events 获取序号大于 after 的最近事件（after 为 0 时返回守护进程保留的全部事件）
+func (c *Client) Events(after uint64) ([]core.EventEntry, error) {
+	var events []core.EventEntry
+	path := pathEvents + "?" + url.Values{"after": {strconv.FormatUint(after, 10)}}.Encode()
+	if err := c.do(http.MethodGet, path, nil, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// History 获取端口最近的趋势采样点
+func (c *Client) History(port int) ([]core.HistoryPoint, error) {
+	var points []core.HistoryPoint
+	path := pathHistory + "?" + url.Values{"port": {strconv.Itoa(port)}}.Encode()
+	if err := c.do(http.MethodGet, path, nil, &points); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// Ban 手动封禁
+func (c *Client) Ban(req BanRequest) error {
+	return c.do(http.MethodPost, pathBans, req, nil)
+}
+
+// Unban 手动解封
+func (c *Client) Unban(req UnbanRequest) error {
+	return c.do(http.MethodPost, pathUnban, req, nil)
+}
+
+// Kick 断开连接（不封禁）
+func (c *Client) Kick(req KickRequest) error {
+	return c.do(http.MethodPost, pathKick, req, nil)
+}
+
+// Allows 获取临时白名单
+func (c *Client) Allows() ([]enforcer.AllowRecord, error) {
+	var allows []enforcer.AllowRecord
+	if err := c.do(http.MethodGet, pathAllows, nil, &allows); err != nil {
+		return nil, err
+	}
+	return allows, nil
+}
+
+// Allow 加入临时白名单（已被封禁时同时解封）
+func (c *Client) Allow(req AllowRequest) error {
+	return c.do(http.MethodPost, pathAllows, req, nil)
+}
+
+// Reload 热重载配置并返回变更摘要
+func (c *Client) Reload() (*monitor.ReconfigureSummary, error) {
+	var summary monitor.ReconfigureSummary
+	if err := c.do(http.MethodPost, pathReload, nil, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// do 发送请求并解析响应；服务端返回的错误信息原样返回
+func (c *Client) do(method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("编码请求失败: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	// 主机名仅用于构造 URL，实际通过 Unix 套接字连接
+	req, err := http.NewRequest(method, "http://nam"+path, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, syscall.ENOENT), errors.Is(err, syscall.ECONNREFUSED):
+			return ErrDaemonNotRunning
+		case errors.Is(err, syscall.EACCES):
+			return fmt.Errorf("访问控制接口 %s 需要 root 权限", c.socketPath)
+		}
+		return fmt.Errorf("请求控制接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var errResp errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || errResp.Error == "" {
+			return fmt.Errorf("控制接口返回 HTTP %d", resp.StatusCode)
+		}
+		return errors.New(errResp.Error)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
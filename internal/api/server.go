@@ -0,0 +1,306 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/core"
+	"github.com/nodeaccessmanager/nam/pkg/utils"
+)
+
+// shutdownTimeout 关闭时等待进行中请求的时间
+const shutdownTimeout = 5 * time.Second
+
+// Server 控制接口服务端
+type Server struct {
+	app        *core.App
+	socketPath string
+	listener   net.Listener
+	httpServer *http.Server
+}
+
+// NewServer 创建控制接口服务端
+func NewServer(app *core.App, socketPath string) *Server {
+	s := &Server{
+		app:        app,
+		socketPath: socketPath,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+pathStatus, s.handleStatus)
+	mux.HandleFunc("GET "+pathSessions, s.handleSessions)
+	mux.HandleFunc("GET "+pathBans, s.handleBans)
+	mux.HandleFunc("POST "+pathBans, s.handleBan)
+	mux.HandleFunc("POST "+pathUnban, s.handleUnban)
+	mux.HandleFunc("POST "+pathReload, s.handleReload)
+	mux.HandleFunc("GET "+pathEvents, s.handleEvents)
+	mux.HandleFunc("GET "+pathHistory, s.handleHistory)
+	mux.HandleFunc("POST "+pathKick, s.handleKick)
+	mux.HandleFunc("GET "+pathAllows, s.handleAllows)
+	mux.HandleFunc("POST "+pathAllows, s.handleAllow)
+
+	s.httpServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Start 监听 Unix 套接字并开始服务
+// 套接字创建即为 0600，目录须属于当前用户且不可被他人写入；
+// 连接时再按 SO_PEERCRED 校验对端 uid，只接受 root 与守护进程自身的用户
+func (s *Server) Start() error {
+	dir := filepath.Dir(s.socketPath)
+	_, statErr := os.Stat(dir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("创建套接字目录失败: %w", err)
+	}
+	if err := checkSocketDir(dir, os.IsNotExist(statErr)); err != nil {
+		return err
+	}
+
+	if err := removeStaleSocket(s.socketPath); err != nil {
+		return err
+	}
+
+	listener, err := listenUnix(s.socketPath)
+	if err != nil {
+		return fmt.Errorf("监听控制接口失败: %w", err)
+	}
+	s.listener = &peerListener{Listener: listener, uid: uint32(os.Geteuid())}
+
+	go func() {
+		if err := s.httpServer.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			utils.GetLogger().Errorf("控制接口异常退出: %v", err)
+		}
+	}()
+
+	utils.GetLogger().Infof("控制接口已启动: %s", s.socketPath)
+	return nil
+}
+
+// Close 停止服务并删除套接字文件
+func (s *Server) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := s.httpServer.Shutdown(ctx)
+
+	// net.UnixListener 关闭时会删除套接字文件，这里兜底
+	os.Remove(s.socketPath)
+	return err
+}
+
+// peerListener 只接受 root 与指定 uid 的客户端连接，其余连接直接关闭
+type peerListener struct {
+	net.Listener
+	uid uint32
+}
+
+// Accept 等待下一个通过身份校验的连接
+func (l *peerListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		uid, err := peerUID(conn)
+		if err == nil && (uid == 0 || uid == l.uid) {
+			return conn, nil
+		}
+		conn.Close()
+
+		if err != nil {
+			utils.GetLogger().Warnf("读取控制接口客户端身份失败，已拒绝连接: %v", err)
+		} else {
+			utils.GetLogger().Warnf("已拒绝 uid %d 的控制接口连接", uid)
+		}
+	}
+}
+
+// removeStaleSocket 删除上次异常退出遗留的套接字；仍有进程在监听时拒绝启动
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("检查套接字失败: %w", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s 已存在且不是套接字", path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("控制接口 %s 已被其他进程占用", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("删除遗留套接字失败: %w", err)
+	}
+	return nil
+}
+
+// handleStatus GET /v1/status
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.app.GetStatus())
+}
+
+// handleSessions GET /v1/sessions?port=N
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	port, err := strconv.Atoi(r.URL.Query().Get("port"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("缺少或无效的 port 参数"))
+		return
+	}
+
+	sessions, err := s.app.GetSessions(port)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+// handleBans GET /v1/bans
+func (s *Server) handleBans(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.app.GetActiveBans())
+}
+
+// handleEvents GET /v1/events?after=N，返回序号大于 N 的最近事件
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	var after uint64
+	if value := r.URL.Query().Get("after"); value != "" {
+		var err error
+		if after, err = strconv.ParseUint(value, 10, 64); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("无效的 after 参数"))
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, s.app.GetEvents(after))
+}
+
+// handleHistory GET /v1/history?port=N，返回端口最近的趋势采样点
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	port, err := strconv.Atoi(r.URL.Query().Get("port"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("缺少或无效的 port 参数"))
+		return
+	}
+
+	points, err := s.app.GetHistory(port)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, points)
+}
+
+// handleBan POST /v1/bans
+func (s *Server) handleBan(w http.ResponseWriter, r *http.Request) {
+	var req BanRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.app.ManualBan(req.IP, req.Port, req.Duration, req.Reason); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleUnban POST /v1/unban
+func (s *Server) handleUnban(w http.ResponseWriter, r *http.Request) {
+	var req UnbanRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.app.ManualUnban(req.IP, req.Port); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleKick POST /v1/kick
+func (s *Server) handleKick(w http.ResponseWriter, r *http.Request) {
+	var req KickRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.app.Kick(req.IP, req.Port); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAllows GET /v1/allows
+func (s *Server) handleAllows(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.app.GetTempAllows())
+}
+
+// handleAllow POST /v1/allows
+func (s *Server) handleAllow(w http.ResponseWriter, r *http.Request) {
+	var req AllowRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.app.TempAllow(req.IP, req.Port, req.Duration, req.Reason); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleReload POST /v1/reload，返回变更摘要；配置无效时返回 422 与错误详情
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	summary, err := s.app.Reload()
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// decodeJSON 解析请求体（拒绝未知字段）
+func decodeJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("请求格式错误: %w", err)
+	}
+	return nil
+}
+
+// writeJSON 写入 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 写入错误响应
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,74 @@
+//go:build linux
+
+package api
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// listenUnix 在 umask 0177 下创建套接字，文件从创建起即为 0600，不存在可被连接的宽松权限窗口
+// umask 是进程级设置，只在 Listen 期间临时收紧
+func listenUnix(path string) (net.Listener, error) {
+	old := syscall.Umask(0177)
+	listener, err := net.Listen("unix", path)
+	syscall.Umask(old)
+	return listener, err
+}
+
+// checkSocketDir 验证套接字目录属于当前用户且不可被其他用户写入（否则套接字可能被替换）
+// 由本进程创建的目录收紧为 0700
+func checkSocketDir(dir string, created bool) error {
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("检查套接字目录失败: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("套接字目录 %s 不是目录", dir)
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("无法读取套接字目录 %s 的属主", dir)
+	}
+	if int(stat.Uid) != os.Geteuid() {
+		return fmt.Errorf("套接字目录 %s 不属于当前用户（uid %d）", dir, stat.Uid)
+	}
+
+	if created && info.Mode().Perm() != 0700 {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return fmt.Errorf("设置套接字目录权限失败: %w", err)
+		}
+		return nil
+	}
+	if info.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("套接字目录 %s 权限过宽（%o），其他用户可写", dir, info.Mode().Perm())
+	}
+	return nil
+}
+
+// peerUID 通过 SO_PEERCRED 读取客户端进程的 uid
+func peerUID(conn net.Conn) (uint32, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return 0, fmt.Errorf("不是 Unix 套接字连接")
+	}
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return 0, err
+	}
+	if credErr != nil {
+		return 0, fmt.Errorf("读取 SO_PEERCRED 失败: %w", credErr)
+	}
+	return cred.Uid, nil
+}
@@ -0,0 +1,31 @@
+//go:build !linux
+
+package api
+
+import (
+	"net"
+	"os"
+)
+
+// listenUnix 创建套接字后设置为 0600
+func listenUnix(path string) (net.Listener, error) {
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// checkSocketDir 非 Linux 平台只依赖 MkdirAll 的 0700 权限
+func checkSocketDir(dir string, created bool) error {
+	return nil
+}
+
+// peerUID 非 Linux 平台无法读取对端身份，视为当前用户（访问由套接字文件权限控制）
+func peerUID(conn net.Conn) (uint32, error) {
+	return uint32(os.Geteuid()), nil
+}
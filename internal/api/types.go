@@ -0,0 +1,48 @@
+// Package api 守护进程的本地控制接口：Unix 套接字上的 HTTP/JSON 服务端与客户端
+package api
+
+// 接口路径
+const (
+	pathStatus   = "/v1/status"
+	pathSessions = "/v1/sessions"
+	pathBans     = "/v1/bans"
+	pathUnban    = "/v1/unban"
+	pathReload   = "/v1/reload"
+	pathEvents   = "/v1/events"
+	pathKick     = "/v1/kick"
+	pathAllows   = "/v1/allows"
+	pathHistory  = "/v1/history"
+)
+
+// BanRequest 手动封禁请求
+type BanRequest struct {
+	IP       string `json:"ip"` // IP 或 CIDR
+	Port     int    `json:"port"`
+	Duration int    `json:"duration"` // 秒，0 表示永久
+	Reason   string `json:"reason,omitempty"`
+}
+
+// UnbanRequest 手动解封请求
+type UnbanRequest struct {
+	IP   string `json:"ip"`
+	Port int    `json:"port"`
+}
+
+// KickRequest 断开连接请求（不封禁）
+type KickRequest struct {
+	IP   string `json:"ip"`
+	Port int    `json:"port"`
+}
+
+// AllowRequest 临时白名单请求
+type AllowRequest struct {
+	IP       string `json:"ip"` // IP 或 CIDR
+	Port     int    `json:"port"`
+	Duration int    `json:"duration"` // 秒，必须大于 0
+	Reason   string `json:"reason,omitempty"`
+}
+
+// errorResponse 错误响应
+type errorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,75 @@
+// Package clock 提供可替换的时间源
+// 生产环境使用 Real()，测试中使用 NewFake() 手动推进时间，
+// 无需真实等待即可验证封禁到期、FIFO/LIFO 排序与检查周期。
+package clock
+
+import "time"
+
+// Clock 时间源
+type Clock interface {
+	// Now 当前时间
+	Now() time.Time
+	// AfterFunc 在 d 之后于独立的 goroutine 中（Fake 为 Advance 的调用方）执行 f
+	AfterFunc(d time.Duration, f func()) Timer
+	// NewTicker 创建周期为 d 的 Ticker
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer 由 AfterFunc 创建的定时器
+type Timer interface {
+	// Stop 取消定时器，返回定时器是否在触发前被取消
+	Stop() bool
+}
+
+// Ticker 周期触发器
+type Ticker interface {
+	// C 触发通道
+	C() <-chan time.Time
+	// Reset 修改周期
+	Reset(d time.Duration)
+	// Stop 停止触发
+	Stop()
+}
+
+// realClock 基于 time 包的时间源
+type realClock struct{}
+
+// Real 返回系统时间源
+func Real() Clock {
+	return realClock{}
+}
+
+// Now 当前时间
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// AfterFunc 见 time.AfterFunc
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// NewTicker 见 time.NewTicker
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return &realTicker{ticker: time.NewTicker(d)}
+}
+
+// realTicker 包装 time.Ticker
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+// C 触发通道
+func (t *realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+// Reset 修改周期
+func (t *realTicker) Reset(d time.Duration) {
+	t.ticker.Reset(d)
+}
+
+// Stop 停止触发
+func (t *realTicker) Stop() {
+	t.ticker.Stop()
+}
@@ -0,0 +1,169 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake 手动推进的时间源
+// 定时器回调在 Advance 的调用方 goroutine 中按触发时间顺序同步执行；
+// Ticker 与 time.Ticker 一致，接收方未及时读取时丢弃多余的触发。
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFake 创建从 start 开始的手动时间源
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+// Now 当前（虚拟）时间
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// AfterFunc 在虚拟时间推进 d 之后执行 fn
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTimer{clock: f, when: f.now.Add(d), fn: fn}
+	f.timers = append(f.timers, t)
+	return t
+}
+
+// NewTicker 创建虚拟时间的 Ticker
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: 非正数的 Ticker 周期")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := &fakeTimer{clock: f, when: f.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	f.timers = append(f.timers, t)
+	return fakeTicker{t}
+}
+
+// Advance 推进虚拟时间，依次触发期间到期的定时器与 Ticker
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	target := f.now.Add(d)
+	f.mu.Unlock()
+
+	for {
+		f.mu.Lock()
+		next := f.nextDue(target)
+		if next == nil {
+			f.now = target
+			f.mu.Unlock()
+			return
+		}
+
+		f.now = next.when
+		fn, ch, now := next.fn, next.ch, f.now
+		if next.period > 0 {
+			next.when = next.when.Add(next.period)
+		} else {
+			f.remove(next)
+		}
+		f.mu.Unlock()
+
+		if ch != nil {
+			select {
+			case ch <- now:
+			default:
+			}
+		}
+		if fn != nil {
+			fn()
+		}
+	}
+}
+
+// Set 将虚拟时间推进到 t（早于当前时间时不做任何事）
+func (f *Fake) Set(t time.Time) {
+	if d := t.Sub(f.Now()); d > 0 {
+		f.Advance(d)
+	}
+}
+
+// Pending 当前未触发的定时器与 Ticker 数量（用于等待被测 goroutine 完成注册）
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// nextDue 返回最早在 target 之前到期的定时器（调用方需持有锁）
+func (f *Fake) nextDue(target time.Time) *fakeTimer {
+	var next *fakeTimer
+	for _, t := range f.timers {
+		if t.when.After(target) {
+			continue
+		}
+		if next == nil || t.when.Before(next.when) {
+			next = t
+		}
+	}
+	return next
+}
+
+// remove 移除定时器，返回其是否仍在等待（调用方需持有锁）
+func (f *Fake) remove(target *fakeTimer) bool {
+	for i, t := range f.timers {
+		if t == target {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// fakeTimer 虚拟时间的定时器 / Ticker
+type fakeTimer struct {
+	clock  *Fake
+	when   time.Time
+	period time.Duration // > 0 表示 Ticker
+	fn     func()
+	ch     chan time.Time
+}
+
+// Stop 取消定时器
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t)
+}
+
+// C Ticker 的触发通道
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+// Reset 修改 Ticker 周期，从当前虚拟时间重新计时
+func (t *fakeTimer) Reset(d time.Duration) {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	// 已停止的 Ticker 也会重新启用，与 time.Ticker 一致
+	t.period = d
+	t.when = t.clock.now.Add(d)
+	t.clock.remove(t)
+	t.clock.timers = append(t.clock.timers, t)
+}
+
+// fakeTicker Ticker 视图（Stop 无返回值）
+type fakeTicker struct {
+	*fakeTimer
+}
+
+// Stop 停止 Ticker
+func (t fakeTicker) Stop() {
+	t.fakeTimer.Stop()
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldError 配置中的单个错误，Line 为 0 表示无法定位到源文件（Column 为 0 表示只知道行号）
+type FieldError struct {
+	Line    int
+	Column  int
+	Path    string // 字段路径，如 rules[1].strategy
+	Message string
+}
+
+// Error 实现 error 接口
+func (e FieldError) Error() string {
+	var b strings.Builder
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		fmt.Fprintf(&b, "第 %d 行第 %d 列 ", e.Line, e.Column)
+	case e.Line > 0:
+		fmt.Fprintf(&b, "第 %d 行 ", e.Line)
+	}
+	if e.Path != "" {
+		fmt.Fprintf(&b, "%s: ", e.Path)
+	}
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// ValidationErrors 配置中的全部错误（按位置排序）
+type ValidationErrors []FieldError
+
+// Error 实现 error 接口
+func (v ValidationErrors) Error() string {
+	if len(v) == 1 {
+		return v[0].Error()
+	}
+
+	messages := make([]string, len(v))
+	for i, e := range v {
+		messages[i] = e.Error()
+	}
+	return fmt.Sprintf("共 %d 个错误: %s", len(v), strings.Join(messages, "; "))
+}
+
+// add 追加一条错误
+func (v *ValidationErrors) add(path, format string, args ...any) {
+	*v = append(*v, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// err 没有错误时返回 nil（避免返回非 nil 的空切片接口值）
+func (v ValidationErrors) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+// sort 按源文件位置排序，无法定位的错误保持原顺序排在最后
+func (v ValidationErrors) sort() {
+	sort.SliceStable(v, func(i, j int) bool {
+		a, b := v[i], v[j]
+		if (a.Line == 0) != (b.Line == 0) {
+			return b.Line == 0
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
@@ -1,9 +1,14 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -19,18 +24,17 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("读取配置文件失败: %w", err)
 	}
 
-	// YAML 解析
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("配置文件格式错误: %w", err)
-	}
-
-	// 验证配置
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("配置验证失败: %w", err)
+	// 严格解析并验证（未知字段、类型错误与语义错误一并报告）
+	config, err := decodeStrict(data)
+	if err != nil {
+		var verrs ValidationErrors
+		if errors.As(err, &verrs) {
+			return nil, fmt.Errorf("配置验证失败: %w", err)
+		}
+		return nil, err
 	}
 
-	return &config, nil
+	return config, nil
 }
 
 // Save 保存配置到文件
@@ -54,26 +58,53 @@ func Save(config *Config, path string) error {
 	return nil
 }
 
-// Validate 验证配置的合法性
+// Validate 验证配置的合法性，返回 ValidationErrors（包含全部错误而非第一个）
 func (c *Config) Validate() error {
+	var errs ValidationErrors
+
 	// 检查必需字段
 	if c.Global.CheckInterval <= 0 {
-		return fmt.Errorf("check_interval 必须大于 0")
-	}
-
-	if c.Global.CheckInterval > 3600 {
-		return fmt.Errorf("check_interval 不应超过 3600 秒")
+		errs.add("global.check_interval", "check_interval 必须大于 0")
+	} else if c.Global.CheckInterval > 3600 {
+		errs.add("global.check_interval", "check_interval 不应超过 3600 秒")
 	}
 
 	if c.Global.BanDuration < 0 {
-		return fmt.Errorf("ban_duration 不能为负数")
+		errs.add("global.ban_duration", "ban_duration 不能为负数")
 	}
 
 	// 验证策略
 	if c.Global.Strategy != StrategyFIFO && c.Global.Strategy != StrategyLIFO {
-		return fmt.Errorf("不支持的策略: %s（仅支持 FIFO 或 LIFO）", c.Global.Strategy)
+		errs.add("global.strategy", "不支持的策略: %s（仅支持 FIFO 或 LIFO）", c.Global.Strategy)
 	}
 
+	// 验证采集模式（留空等同于 per_port）
+	switch c.Global.CollectMode {
+	case "", CollectModePerPort, CollectModeBatch:
+	default:
+		errs.add("global.collect_mode", "不支持的采集模式: %s（仅支持 per_port 或 batch）", c.Global.CollectMode)
+	}
+
+	// 验证连接数据源（留空等同于 auto）
+	switch c.Global.ConnectionSource {
+	case "", ConnectionSourceAuto, ConnectionSourceNetlink, ConnectionSourceProc, ConnectionSourceSS:
+	default:
+		errs.add("global.connection_source", "不支持的连接数据源: %s（仅支持 auto、netlink、proc 或 ss）", c.Global.ConnectionSource)
+	}
+
+	// 验证防火墙后端（留空等同于 auto）
+	switch c.Global.FirewallBackend {
+	case "", FirewallBackendAuto, FirewallBackendIPTables, FirewallBackendIPSet, FirewallBackendNFTables:
+	default:
+		errs.add("global.firewall_backend", "不支持的防火墙后端: %s（仅支持 auto、iptables、ipset 或 nftables）", c.Global.FirewallBackend)
+	}
+
+	// 验证全局名单
+	validateCIDRs(&errs, "global.whitelist", "全局白名单", c.Global.Whitelist)
+	validateCIDRs(&errs, "global.blacklist", "全局黑名单", c.Global.Blacklist)
+	validateListFiles(&errs, "global.whitelist_files", c.Global.WhitelistFiles)
+	validateListFiles(&errs, "global.blacklist_files", c.Global.BlacklistFiles)
+
 	// 验证日志级别
 	validLevels := map[string]bool{
 		"debug": true,
@@ -82,62 +113,194 @@ func (c *Config) Validate() error {
 		"error": true,
 	}
 	if !validLevels[c.Global.LogLevel] {
-		return fmt.Errorf("不支持的日志级别: %s", c.Global.LogLevel)
+		errs.add("global.log_level", "不支持的日志级别: %s", c.Global.LogLevel)
+	}
+
+	// 验证日志输出与格式
+	for i, output := range c.Global.LogOutputs {
+		path := fmt.Sprintf("global.log_outputs[%d]", i)
+		switch output {
+		case LogOutputFile:
+			if c.Global.LogFile == "" {
+				errs.add(path, "日志输出 file 需要配置 log_file")
+			}
+		case LogOutputStdout, LogOutputSyslog, LogOutputJournald:
+		default:
+			errs.add(path, "不支持的日志输出: %s（仅支持 file、stdout、syslog 或 journald）", output)
+		}
+	}
+	switch c.Global.LogFormat {
+	case "", LogFormatText, LogFormatJSON:
+	default:
+		errs.add("global.log_format", "不支持的日志格式: %s（仅支持 text 或 json）", c.Global.LogFormat)
+	}
+
+	// 验证通知设置
+	c.Global.Notification.validate(&errs)
+
+	// 验证控制接口套接字路径
+	if c.Global.APISocket != "" && !filepath.IsAbs(c.Global.APISocket) {
+		errs.add("global.api_socket", "api_socket 必须使用绝对路径: %q", c.Global.APISocket)
+	}
+
+	// 验证 GeoIP 数据文件路径
+	if c.Global.GeoIPFile != "" && !filepath.IsAbs(c.Global.GeoIPFile) {
+		errs.add("global.geoip_file", "geoip_file 必须使用绝对路径: %q", c.Global.GeoIPFile)
 	}
 
 	// 检查端口规则
 	if len(c.Rules) == 0 {
-		return fmt.Errorf("至少需要配置一个端口规则")
+		errs.add("rules", "至少需要配置一个端口规则")
 	}
 
 	// 检查端口唯一性
 	ports := make(map[int]bool)
-	for _, rule := range c.Rules {
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		path := fmt.Sprintf("rules[%d]", i)
+
 		if err := rule.Validate(); err != nil {
-			return fmt.Errorf("端口 %d 的规则无效: %w", rule.Port, err)
+			for _, e := range err.(ValidationErrors) {
+				e.Path = path + "." + e.Path
+				e.Message = fmt.Sprintf("端口 %d 的规则无效: %s", rule.Port, e.Message)
+				errs = append(errs, e)
+			}
 		}
 
 		if ports[rule.Port] {
-			return fmt.Errorf("端口 %d 重复配置", rule.Port)
+			errs.add(path+".port", "端口 %d 重复配置", rule.Port)
 		}
 		ports[rule.Port] = true
 	}
 
-	return nil
+	return errs.err()
 }
 
-// Validate 验证规则的合法性
+// Validate 验证规则的合法性，返回 ValidationErrors（字段路径相对于规则）
 func (r *Rule) Validate() error {
+	var errs ValidationErrors
+
 	// 验证端口范围
 	if r.Port < 1 || r.Port > 65535 {
-		return fmt.Errorf("端口号必须在 1-65535 之间")
+		errs.add("port", "端口号必须在 1-65535 之间")
 	}
 
 	// 验证最大 IP 数
 	if r.MaxIPs <= 0 {
-		return fmt.Errorf("max_ips 必须大于 0")
+		errs.add("max_ips", "max_ips 必须大于 0")
 	}
 
 	// 验证策略（如果设置）
 	if r.Strategy != "" && r.Strategy != StrategyFIFO && r.Strategy != StrategyLIFO {
-		return fmt.Errorf("不支持的策略: %s", r.Strategy)
+		errs.add("strategy", "不支持的策略: %s", r.Strategy)
 	}
 
-	// 验证白名单 CIDR 格式
-	for _, cidr := range r.Whitelist {
-		if err := validateCIDR(cidr); err != nil {
-			return fmt.Errorf("白名单中的 CIDR 无效 (%s): %w", cidr, err)
+	// 验证地址聚合前缀长度
+	if r.IPv4PrefixLen < 0 || r.IPv4PrefixLen > 32 {
+		errs.add("ipv4_prefix_len", "ipv4_prefix_len 必须在 0-32 之间")
+	}
+	if r.IPv6PrefixLen < 0 || r.IPv6PrefixLen > 128 {
+		errs.add("ipv6_prefix_len", "ipv6_prefix_len 必须在 0-128 之间")
+	}
+
+	// 验证名单 CIDR 格式与名单文件路径
+	validateCIDRs(&errs, "whitelist", "白名单", r.Whitelist)
+	validateCIDRs(&errs, "blacklist", "黑名单", r.Blacklist)
+	validateListFiles(&errs, "whitelist_files", r.WhitelistFiles)
+	validateListFiles(&errs, "blacklist_files", r.BlacklistFiles)
+
+	return errs.err()
+}
+
+// validate 验证通知设置（未启用时不检查）
+func (n *NotificationConfig) validate(errs *ValidationErrors) {
+	if !n.Enabled {
+		return
+	}
+
+	const path = "global.notification"
+	if u, err := url.Parse(n.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.add(path+".webhook_url", "启用通知时需要有效的 http(s) webhook_url")
+	}
+
+	switch n.Type {
+	case "", NotifyTypeGeneric, NotifyTypeDiscord:
+	case NotifyTypeTelegram:
+		if n.TelegramChatID == "" {
+			errs.add(path+".telegram_chat_id", "telegram 通知需要配置 telegram_chat_id")
+		}
+	default:
+		errs.add(path+".type", "不支持的通知类型: %s（仅支持 generic、telegram 或 discord）", n.Type)
+	}
+
+	validEvents := map[string]bool{
+		NotifyEventOverlimit: true,
+		NotifyEventBan:       true,
+		NotifyEventUnban:     true,
+		NotifyEventBlacklist: true,
+		NotifyEventKick:      true,
+		NotifyEventAllow:     true,
+		NotifyEventReload:    true,
+		NotifyEventStart:     true,
+		NotifyEventStop:      true,
+	}
+	for i, event := range n.Events {
+		if !validEvents[event] {
+			errs.add(fmt.Sprintf("%s.events[%d]", path, i), "不支持的通知事件: %s", event)
 		}
 	}
 
-	// 验证黑名单 CIDR 格式
-	for _, cidr := range r.Blacklist {
+	if n.RateLimit < 0 {
+		errs.add(path+".rate_limit", "rate_limit 不能为负数")
+	}
+	if n.QueueSize < 0 {
+		errs.add(path+".queue_size", "queue_size 不能为负数")
+	}
+	if n.MaxRetries < 0 {
+		errs.add(path+".max_retries", "max_retries 不能为负数")
+	}
+}
+
+// validateCIDRs 验证名单中的每个条目
+func validateCIDRs(errs *ValidationErrors, path, name string, list []string) {
+	for i, cidr := range list {
 		if err := validateCIDR(cidr); err != nil {
-			return fmt.Errorf("黑名单中的 CIDR 无效 (%s): %w", cidr, err)
+			errs.add(fmt.Sprintf("%s[%d]", path, i), "%s中的 CIDR 无效 (%s): %v", name, cidr, err)
 		}
 	}
+}
 
-	return nil
+// validateListFiles 验证名单文件路径（必须为绝对路径，文件不存在时只在运行时告警，
+// 以便订阅脚本稍后生成）
+func validateListFiles(errs *ValidationErrors, path string, files []string) {
+	for i, file := range files {
+		if !filepath.IsAbs(file) {
+			errs.add(fmt.Sprintf("%s[%d]", path, i), "名单文件必须使用绝对路径: %q", file)
+		}
+	}
+}
+
+// ListFiles 返回配置中引用的全部名单文件（去重）
+func (c *Config) ListFiles() []string {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(paths []string) {
+		for _, path := range paths {
+			path = filepath.Clean(path)
+			if !seen[path] {
+				seen[path] = true
+				files = append(files, path)
+			}
+		}
+	}
+
+	add(c.Global.WhitelistFiles)
+	add(c.Global.BlacklistFiles)
+	for _, rule := range c.Rules {
+		add(rule.WhitelistFiles)
+		add(rule.BlacklistFiles)
+	}
+	return files
 }
 
 // validateCIDR 验证 CIDR 格式或单个 IP
@@ -166,6 +329,45 @@ func (c *Config) GetRuleByPort(port int) *Rule {
 	return nil
 }
 
+// ParseDuration 解析命令行或界面输入的时长（秒）：纯数字为秒，也支持 10m、1h30m 等写法
+// 只有字面量 0 表示 0 秒（封禁时即永久），不足 1 秒的时长返回错误，避免被舍入为 0
+func ParseDuration(s string) (int, error) {
+	if s == "" {
+		return 0, fmt.Errorf("请输入时长")
+	}
+	if seconds, err := strconv.Atoi(s); err == nil {
+		if seconds < 0 {
+			return 0, fmt.Errorf("时长不能为负数")
+		}
+		return seconds, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("无效的时长: %s（示例: 600、10m、1h）", s)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("时长不能为负数")
+	}
+	if d < time.Second {
+		return 0, fmt.Errorf("时长不能小于 1 秒: %s（永久请使用 0）", s)
+	}
+	return int(d.Round(time.Second) / time.Second), nil
+}
+
+// GetAPISocket 控制接口套接字路径（未配置时使用默认路径）
+func (c *Config) GetAPISocket() string {
+	if c.Global.APISocket != "" {
+		return c.Global.APISocket
+	}
+	return DefaultAPISocket
+}
+
+// IsBatchCollect 是否使用单次批量采集模式
+func (c *Config) IsBatchCollect() bool {
+	return c.Global.CollectMode == CollectModeBatch
+}
+
 // GetEffectiveStrategy 获取规则的有效策略（考虑全局默认值）
 func (r *Rule) GetEffectiveStrategy(global Strategy) Strategy {
 	if r.Strategy != "" {
@@ -0,0 +1,40 @@
+package config
+
+import "testing"
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{input: "600", want: 600},
+		{input: "10m", want: 600},
+		{input: "1h30m", want: 5400},
+		{input: "1.5s", want: 2},
+		{input: "0", want: 0},
+		{input: "400ms", wantErr: true},
+		{input: "0s", wantErr: true},
+		{input: "-1s", wantErr: true},
+		{input: "-5", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "forever", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDuration(%q) = %d, want error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDuration(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
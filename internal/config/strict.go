@@ -0,0 +1,261 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// position 字段在源文件中的位置
+type position struct {
+	line, column int
+}
+
+// decodeStrict 严格解析配置：拒绝未知字段、规范化枚举值，并收集全部错误（带行列号）
+// YAML 语法错误无法继续解析，直接返回
+func decodeStrict(data []byte) (*Config, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("配置文件格式错误: %w", err)
+	}
+
+	var errs ValidationErrors
+	positions := make(map[string]position)
+	checkKeys(&root, reflect.TypeOf(Config{}), "", positions, &errs)
+
+	// 类型错误（如把字符串写进整数字段）逐条记录，其余字段照常解析
+	var config Config
+	if len(root.Content) > 0 {
+		if err := root.Decode(&config); err != nil {
+			var typeErr *yaml.TypeError
+			if !errors.As(err, &typeErr) {
+				return nil, fmt.Errorf("配置文件格式错误: %w", err)
+			}
+			for _, msg := range typeErr.Errors {
+				errs = append(errs, typeError(msg))
+			}
+		}
+	}
+
+	config.normalize()
+
+	// 语义验证：按字段路径补上行列号；已有类型错误的行不再重复报告
+	typeErrLines := make(map[int]bool)
+	for _, e := range errs {
+		typeErrLines[e.Line] = true
+	}
+	if err := config.Validate(); err != nil {
+		for _, e := range err.(ValidationErrors) {
+			if pos, ok := locate(positions, e.Path); ok {
+				e.Line, e.Column = pos.line, pos.column
+			}
+			if e.Line > 0 && typeErrLines[e.Line] {
+				continue
+			}
+			errs = append(errs, e)
+		}
+	}
+
+	if len(errs) > 0 {
+		errs.sort()
+		return nil, errs
+	}
+	return &config, nil
+}
+
+// checkKeys 对照结构体的 yaml 标签检查未知字段，并记录每个字段值的位置
+func checkKeys(node *yaml.Node, t reflect.Type, path string, positions map[string]position, errs *ValidationErrors) {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			checkKeys(child, t, path, positions, errs)
+		}
+
+	case yaml.MappingNode:
+		if t.Kind() == reflect.Map {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				childPath := joinPath(path, key.Value)
+				positions[childPath] = position{value.Line, value.Column}
+				checkKeys(value, t.Elem(), childPath, positions, errs)
+			}
+			return
+		}
+		if t.Kind() != reflect.Struct {
+			return
+		}
+
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			// 合并键（<<: *anchor）按同一结构体检查
+			if key.Value == "<<" {
+				checkKeys(value, t, path, positions, errs)
+				continue
+			}
+
+			childPath := joinPath(path, key.Value)
+			field, ok := fields[key.Value]
+			if !ok {
+				*errs = append(*errs, FieldError{
+					Line:    key.Line,
+					Column:  key.Column,
+					Path:    childPath,
+					Message: unknownFieldMessage(key.Value, fields),
+				})
+				continue
+			}
+
+			positions[childPath] = position{value.Line, value.Column}
+			checkKeys(value, field.Type, childPath, positions, errs)
+		}
+
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for i, child := range node.Content {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			positions[childPath] = position{child.Line, child.Column}
+			checkKeys(child, t.Elem(), childPath, positions, errs)
+		}
+	}
+}
+
+// yamlFields 结构体的 yaml 字段名 -> 字段
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("yaml")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			for k, v := range yamlFields(field.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// unknownFieldMessage 未知字段提示，拼写相近时给出建议
+func unknownFieldMessage(key string, fields map[string]reflect.StructField) string {
+	best, bestDist := "", 0
+	for name := range fields {
+		d := editDistance(normalizeKey(key), normalizeKey(name))
+		if best == "" || d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+
+	if best != "" && bestDist <= len(best)/3 {
+		return fmt.Sprintf("未知字段 %q（是否为 %q？）", key, best)
+	}
+	return fmt.Sprintf("未知字段 %q", key)
+}
+
+// normalizeKey 比较拼写时忽略大小写、下划线与连字符（white_list → whitelist）
+func normalizeKey(key string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+}
+
+// editDistance Levenshtein 编辑距离
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// typeError 将 yaml.v3 的类型错误（"line N: ..."）转换为带行号的错误
+func typeError(msg string) FieldError {
+	var line int
+	if _, err := fmt.Sscanf(msg, "line %d:", &line); err == nil {
+		if _, rest, ok := strings.Cut(msg, ": "); ok {
+			return FieldError{Line: line, Message: rest}
+		}
+	}
+	return FieldError{Message: msg}
+}
+
+// locate 查找字段路径的位置；路径不存在时（如缺少的必填字段）回退到最近的上级
+func locate(positions map[string]position, path string) (position, bool) {
+	for path != "" {
+		if pos, ok := positions[path]; ok {
+			return pos, true
+		}
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return position{}, false
+}
+
+// joinPath 拼接字段路径
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// normalize 规范化枚举值的大小写: 策略大写，其余枚举小写
+func (c *Config) normalize() {
+	c.Global.Strategy = Strategy(strings.ToUpper(strings.TrimSpace(string(c.Global.Strategy))))
+	c.Global.CollectMode = strings.ToLower(strings.TrimSpace(c.Global.CollectMode))
+	c.Global.ConnectionSource = strings.ToLower(strings.TrimSpace(c.Global.ConnectionSource))
+	c.Global.FirewallBackend = strings.ToLower(strings.TrimSpace(c.Global.FirewallBackend))
+	c.Global.LogLevel = strings.ToLower(strings.TrimSpace(c.Global.LogLevel))
+	c.Global.LogFormat = strings.ToLower(strings.TrimSpace(c.Global.LogFormat))
+	for i, output := range c.Global.LogOutputs {
+		c.Global.LogOutputs[i] = strings.ToLower(strings.TrimSpace(output))
+	}
+
+	c.Global.Notification.Type = strings.ToLower(strings.TrimSpace(c.Global.Notification.Type))
+	for i, event := range c.Global.Notification.Events {
+		c.Global.Notification.Events[i] = strings.ToLower(strings.TrimSpace(event))
+	}
+
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		rule.Strategy = Strategy(strings.ToUpper(strings.TrimSpace(string(rule.Strategy))))
+	}
+}
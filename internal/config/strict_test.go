@@ -0,0 +1,183 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeStrictNormalize(t *testing.T) {
+	yaml := `
+global:
+  check_interval: 5
+  strategy: fifo
+  collect_mode: BATCH
+  firewall_backend: " NFTables "
+  log_level: INFO
+  log_outputs: [Stdout, JOURNALD]
+  notification:
+    events: [BAN, Overlimit]
+rules:
+  - port: 443
+    max_ips: 3
+    strategy: Lifo
+  - port: 8443
+    max_ips: 3
+`
+	cfg, err := decodeStrict([]byte(yaml))
+	if err != nil {
+		t.Fatalf("decodeStrict() error = %v", err)
+	}
+
+	tests := []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{field: "global.strategy", got: string(cfg.Global.Strategy), want: "FIFO"},
+		{field: "global.collect_mode", got: cfg.Global.CollectMode, want: "batch"},
+		{field: "global.firewall_backend", got: cfg.Global.FirewallBackend, want: "nftables"},
+		{field: "global.log_level", got: cfg.Global.LogLevel, want: "info"},
+		{field: "global.log_outputs", got: strings.Join(cfg.Global.LogOutputs, ","), want: "stdout,journald"},
+		{field: "global.notification.events", got: strings.Join(cfg.Global.Notification.Events, ","), want: "ban,overlimit"},
+		{field: "rules[0].strategy", got: string(cfg.Rules[0].Strategy), want: "LIFO"},
+		{field: "rules[1].strategy", got: string(cfg.Rules[1].Strategy), want: ""},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, tt.got, tt.want)
+		}
+	}
+}
+
+func TestDecodeStrictErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []FieldError // Message 只比较子串
+	}{
+		{
+			name: "未知字段报告键的行列号并给出拼写建议",
+			yaml: `
+global:
+  check_interval: 5
+  strategy: FIFO
+  log_level: info
+rules:
+  - port: 443
+    max_ips: 3
+    white_list: [192.0.2.1]
+`,
+			want: []FieldError{
+				{Line: 9, Column: 5, Path: "rules[0].white_list", Message: `未知字段 "white_list"（是否为 "whitelist"？）`},
+			},
+		},
+		{
+			name: "拼写差异过大时不给建议",
+			yaml: `
+global:
+  check_interval: 5
+  strategy: FIFO
+  log_level: info
+  frobnicate: true
+rules:
+  - port: 443
+    max_ips: 3
+`,
+			want: []FieldError{
+				{Line: 6, Column: 3, Path: "global.frobnicate", Message: `未知字段 "frobnicate"`},
+			},
+		},
+		{
+			name: "语义错误定位到字段值",
+			yaml: `
+global:
+  check_interval: 5
+  strategy: random
+  log_level: info
+rules:
+  - port: 443
+    max_ips: 3
+`,
+			want: []FieldError{
+				{Line: 4, Column: 13, Path: "global.strategy", Message: "不支持的策略: RANDOM"},
+			},
+		},
+		{
+			name: "多个错误一并收集并按位置排序",
+			yaml: `
+global:
+  check_interval: 0
+  strategy: FIFO
+  log_level: info
+  colect_mode: batch
+rules:
+  - port: 443
+    max_ips: three
+  - port: 443
+    max_ips: 3
+    strategy: newest
+`,
+			want: []FieldError{
+				{Line: 3, Column: 19, Path: "global.check_interval", Message: "check_interval 必须大于 0"},
+				{Line: 6, Column: 3, Path: "global.colect_mode", Message: `是否为 "collect_mode"`},
+				{Line: 9, Message: "cannot unmarshal !!str `three` into int"},
+				{Line: 10, Column: 11, Path: "rules[1].port", Message: "端口 443 重复配置"},
+				{Line: 12, Column: 15, Path: "rules[1].strategy", Message: "不支持的策略: NEWEST"},
+			},
+		},
+		{
+			name: "缺少的字段回退到上级位置，无法定位的排在最后",
+			yaml: `
+global:
+  check_interval: 5
+  strategy: FIFO
+  log_level: info
+rule:
+  - port: 443
+`,
+			want: []FieldError{
+				{Line: 6, Column: 1, Path: "rule", Message: `是否为 "rules"`},
+				{Path: "rules", Message: "至少需要配置一个端口规则"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := decodeStrict([]byte(tt.yaml))
+			if err == nil {
+				t.Fatalf("decodeStrict() = %+v, want error", cfg)
+			}
+
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("decodeStrict() error = %v (%T), want ValidationErrors", err, err)
+			}
+			if len(errs) != len(tt.want) {
+				t.Fatalf("got %d errors, want %d:\n%v", len(errs), len(tt.want), err)
+			}
+			for i, want := range tt.want {
+				got := errs[i]
+				if got.Line != want.Line || got.Column != want.Column || got.Path != want.Path {
+					t.Errorf("errs[%d] = %d:%d %s, want %d:%d %s", i, got.Line, got.Column, got.Path, want.Line, want.Column, want.Path)
+				}
+				if !strings.Contains(got.Message, want.Message) {
+					t.Errorf("errs[%d].Message = %q, want substring %q", i, got.Message, want.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeStrictSyntaxError(t *testing.T) {
+	_, err := decodeStrict([]byte("global:\n  check_interval: [5\n"))
+	if err == nil {
+		t.Fatal("decodeStrict() error = nil, want syntax error")
+	}
+
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		t.Errorf("语法错误不应作为 ValidationErrors 返回: %v", err)
+	}
+}
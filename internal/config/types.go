@@ -15,18 +15,42 @@ type GlobalConfig struct {
 	CheckInterval int      `yaml:"check_interval"` // 检查周期（秒）
 	BanDuration   int      `yaml:"ban_duration"`   // 默认封禁时长（秒），0 表示不封禁
 	Strategy      Strategy `yaml:"strategy"`       // 默认策略: FIFO / LIFO
+	CollectMode   string   `yaml:"collect_mode"`   // 采集模式: per_port / batch
+
+	// 连接数据源: auto / netlink / proc / ss
+	ConnectionSource string `yaml:"connection_source"`
+
+	// 防火墙后端: auto / iptables / ipset / nftables
+	FirewallBackend string `yaml:"firewall_backend"`
+
+	// 全局名单（IP 或 CIDR），作用于所有端口规则
+	// 优先级: 规则白名单 > 规则黑名单 > 全局白名单 > 全局黑名单
+	Whitelist []string `yaml:"whitelist,omitempty"`
+	Blacklist []string `yaml:"blacklist,omitempty"`
+
+	// 外部名单文件（每行一个 IP 或 CIDR），文件变更后自动重新加载
+	WhitelistFiles []string `yaml:"whitelist_files,omitempty"`
+	BlacklistFiles []string `yaml:"blacklist_files,omitempty"`
 
 	// 日志设置
-	LogLevel      string `yaml:"log_level"`       // debug / info / warn / error
-	LogFile       string `yaml:"log_file"`        // 日志文件路径
-	LogMaxSize    int    `yaml:"log_max_size"`    // 日志文件最大大小（MB）
-	LogMaxBackups int    `yaml:"log_max_backups"` // 保留备份数
-	LogMaxAge     int    `yaml:"log_max_age"`     // 保留天数
+	LogLevel      string   `yaml:"log_level"`             // debug / info / warn / error
+	LogFile       string   `yaml:"log_file"`              // 日志文件路径
+	LogMaxSize    int      `yaml:"log_max_size"`          // 日志文件最大大小（MB）
+	LogMaxBackups int      `yaml:"log_max_backups"`       // 保留备份数
+	LogMaxAge     int      `yaml:"log_max_age"`           // 保留天数
+	LogOutputs    []string `yaml:"log_outputs,omitempty"` // file / stdout / syslog / journald，可多选，默认 file
+	LogFormat     string   `yaml:"log_format,omitempty"`  // text / json，默认 text
 
 	// 数据库设置
 	DatabasePath string `yaml:"database_path"` // SQLite 数据库路径
 	HistoryDays  int    `yaml:"history_days"`  // 历史数据保留天数
 
+	// 控制接口（Unix 套接字，仅 root 可访问），status / reload / tui 等命令通过它访问守护进程
+	APISocket string `yaml:"api_socket,omitempty"` // 默认 /run/nam/nam.sock
+
+	// GeoIP 数据文件（CSV: 起始IP,结束IP,国家代码 或 CIDR,国家代码），用于 TUI 显示来源国家，可选
+	GeoIPFile string `yaml:"geoip_file,omitempty"`
+
 	// 通知设置（可选）
 	Notification NotificationConfig `yaml:"notification,omitempty"`
 }
@@ -35,9 +59,35 @@ type GlobalConfig struct {
 type NotificationConfig struct {
 	Enabled    bool     `yaml:"enabled"`
 	WebhookURL string   `yaml:"webhook_url"` // Telegram/Discord Webhook
-	Events     []string `yaml:"events"`      // 触发通知的事件
+	Events     []string `yaml:"events"`      // 触发通知的事件，留空表示全部
+
+	Type           string `yaml:"type,omitempty"`             // generic / telegram / discord，留空按 URL 识别
+	TelegramChatID string `yaml:"telegram_chat_id,omitempty"` // Telegram Bot API 的 chat_id
+	RateLimit      int    `yaml:"rate_limit,omitempty"`       // 每类事件每分钟最多发送条数（默认 10）
+	QueueSize      int    `yaml:"queue_size,omitempty"`       // 待发送队列长度，满时丢弃（默认 100）
+	MaxRetries     int    `yaml:"max_retries,omitempty"`      // 发送失败的重试次数（默认 3）
 }
 
+// 通知类型
+const (
+	NotifyTypeGeneric  = "generic"  // 通用 JSON
+	NotifyTypeTelegram = "telegram" // Telegram Bot API sendMessage
+	NotifyTypeDiscord  = "discord"  // Discord Webhook
+)
+
+// 通知事件
+const (
+	NotifyEventOverlimit = "overlimit" // 端口超限
+	NotifyEventBan       = "ban"       // 封禁
+	NotifyEventUnban     = "unban"     // 解封
+	NotifyEventBlacklist = "blacklist" // 断开黑名单连接
+	NotifyEventKick      = "kick"      // 手动断开连接
+	NotifyEventAllow     = "allow"     // 加入临时白名单
+	NotifyEventReload    = "reload"    // 配置热重载
+	NotifyEventStart     = "start"     // 守护进程启动
+	NotifyEventStop      = "stop"      // 守护进程停止
+)
+
 // Rule 端口规则
 type Rule struct {
 	Port        int      `yaml:"port"`
@@ -48,6 +98,14 @@ type Rule struct {
 	BanDuration int      `yaml:"ban_duration,omitempty"` // 可覆盖全局时长
 	Whitelist   []string `yaml:"whitelist,omitempty"`    // 白名单（IP 或 CIDR）
 	Blacklist   []string `yaml:"blacklist,omitempty"`    // 黑名单
+
+	// 外部名单文件，与 whitelist / blacklist 合并生效
+	WhitelistFiles []string `yaml:"whitelist_files,omitempty"`
+	BlacklistFiles []string `yaml:"blacklist_files,omitempty"`
+
+	// 地址聚合：同一前缀内的地址计为一个会话，按前缀驱逐与封禁（0 表示按单个 IP）
+	IPv4PrefixLen int `yaml:"ipv4_prefix_len,omitempty"` // 如 24
+	IPv6PrefixLen int `yaml:"ipv6_prefix_len,omitempty"` // 如 64（隐私扩展地址）
 }
 
 // Strategy 驱逐策略
@@ -58,6 +116,45 @@ const (
 	StrategyLIFO Strategy = "LIFO" // 后进先出（拒绝新入）
 )
 
+// 采集模式
+const (
+	CollectModePerPort = "per_port" // 每个端口独立协程、独立采集
+	CollectModeBatch   = "batch"    // 每个周期采集一次，按端口分发
+)
+
+// 连接数据源
+const (
+	ConnectionSourceAuto    = "auto"    // 启动时自动探测
+	ConnectionSourceNetlink = "netlink" // NETLINK_SOCK_DIAG (inet_diag)
+	ConnectionSourceProc    = "proc"    // /proc/net/tcp + /proc/net/tcp6
+	ConnectionSourceSS      = "ss"      // ss 命令
+)
+
+// 防火墙后端
+const (
+	FirewallBackendAuto     = "auto"     // 启动时自动探测
+	FirewallBackendIPTables = "iptables" // iptables NAM-INPUT 链，每个封禁一条规则
+	FirewallBackendIPSet    = "ipset"    // ipset 集合 + 单条 iptables 规则（内核超时）
+	FirewallBackendNFTables = "nftables" // nftables inet nam 表（内核超时）
+)
+
+// DefaultAPISocket 默认控制接口套接字路径
+const DefaultAPISocket = "/run/nam/nam.sock"
+
+// 日志输出
+const (
+	LogOutputFile     = "file"     // log_file 指定的滚动日志文件
+	LogOutputStdout   = "stdout"   // 标准输出
+	LogOutputSyslog   = "syslog"   // 本地 syslog
+	LogOutputJournald = "journald" // systemd-journald 原生协议
+)
+
+// 日志格式
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
 // Discovery 自动发现历史记录
 type Discovery struct {
 	LastScanAt        time.Time         `yaml:"last_scan_at"`
@@ -75,16 +172,22 @@ type DetectedProcess struct {
 func DefaultConfig() *Config {
 	return &Config{
 		Global: GlobalConfig{
-			CheckInterval: 5,
-			BanDuration:   60,
-			Strategy:      StrategyFIFO,
-			LogLevel:      "info",
-			LogFile:       "/var/log/nam.log",
-			LogMaxSize:    100,
-			LogMaxBackups: 5,
-			LogMaxAge:     30,
-			DatabasePath:  "/var/lib/nam/nam.db",
-			HistoryDays:   30,
+			CheckInterval:    5,
+			BanDuration:      60,
+			Strategy:         StrategyFIFO,
+			CollectMode:      CollectModePerPort,
+			ConnectionSource: ConnectionSourceAuto,
+			FirewallBackend:  FirewallBackendAuto,
+			LogLevel:         "info",
+			LogFile:          "/var/log/nam/nam.log",
+			LogMaxSize:       100,
+			LogMaxBackups:    5,
+			LogMaxAge:        30,
+			LogOutputs:       []string{LogOutputFile},
+			LogFormat:        LogFormatText,
+			DatabasePath:     "/var/lib/nam/nam.db",
+			APISocket:        DefaultAPISocket,
+			HistoryDays:      30,
 			Notification: NotificationConfig{
 				Enabled: false,
 				Events:  []string{"ban", "overlimit"},
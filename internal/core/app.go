@@ -5,13 +5,19 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"reflect"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/nodeaccessmanager/nam/internal/clock"
 	"github.com/nodeaccessmanager/nam/internal/config"
 	"github.com/nodeaccessmanager/nam/internal/enforcer"
+	"github.com/nodeaccessmanager/nam/internal/iplist"
 	"github.com/nodeaccessmanager/nam/internal/monitor"
+	"github.com/nodeaccessmanager/nam/internal/notify"
 	"github.com/nodeaccessmanager/nam/internal/storage"
 	"github.com/nodeaccessmanager/nam/pkg/utils"
 )
@@ -23,6 +29,12 @@ type App struct {
 	enforcer    *enforcer.Enforcer
 	db          *storage.Database
 	ruleMap     map[int]*config.Rule // port -> rule
+	listWatcher *iplist.Watcher      // 名单文件监听（不可用时为 nil）
+
+	// notifier 通知器（未启用时为 nil），执行事件回调可能发生在持有 mu 的热重载期间，因此不经 mu 访问
+	notifier atomic.Pointer[notify.Notifier]
+	events   *eventLog // 最近事件，供控制接口客户端轮询
+	history  *history  // 各端口在线 IP 与封禁趋势，供 TUI 绘制图表
 
 	ctx        context.Context
 	cancel     context.CancelFunc
@@ -31,10 +43,19 @@ type App struct {
 	isRunning  bool
 	startTime  time.Time
 	configPath string
+	clock      clock.Clock
+	debug      bool // 命令行 --debug，热重载日志配置时保持 debug 级别
+
+	shutdownHooks []func()
 }
 
 // NewApp 创建应用实例
 func NewApp(configPath string) (*App, error) {
+	return NewAppWithClock(configPath, clock.Real())
+}
+
+// NewAppWithClock 使用指定时间源创建应用实例（监控周期、封禁到期与统计均使用该时间源）
+func NewAppWithClock(configPath string, clk clock.Clock) (*App, error) {
 	logger := utils.GetLogger()
 
 	// 1. 加载配置
@@ -53,11 +74,26 @@ func NewApp(configPath string) (*App, error) {
 		return nil, fmt.Errorf("初始化数据库失败: %w", err)
 	}
 
-	// 3. 创建 Enforcer
-	enf := enforcer.NewEnforcer(cfg)
+	// 3. 创建 Enforcer（活跃封禁持久化到数据库）
+	// 防火墙后端不可用时降级为仅断开连接，监控与驱逐仍然运行
+	backend, err := enforcer.NewBanBackend(cfg.Global.FirewallBackend)
+	if err != nil {
+		utils.GetLogger().Errorf("初始化防火墙后端失败，降级为仅断开连接模式: %v", err)
+		backend = enforcer.NewNullBackend(err)
+	}
+	enf := enforcer.NewEnforcer(cfg, backend)
+	enf.SetClock(clk)
+	enf.SetBanStore(db)
+	enf.SetAllowStore(db)
 
 	// 4. 创建 Monitor Coordinator
-	coord := monitor.NewCoordinator(cfg)
+	source, err := monitor.NewConnectionSource(cfg.Global.ConnectionSource)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化连接数据源失败: %w", err)
+	}
+	coord := monitor.NewCoordinator(cfg, source)
+	coord.SetClock(clk)
 
 	// 5. 构建 port -> rule 映射
 	ruleMap := make(map[int]*config.Rule)
@@ -76,15 +112,28 @@ func NewApp(configPath string) (*App, error) {
 		ctx:         ctx,
 		cancel:      cancel,
 		configPath:  configPath,
+		clock:       clk,
+		events:      newEventLog(eventLogSize),
+		history:     newHistory(historySize),
 	}
 
-	// 6. 设置超限回调
+	// 6. 设置超限回调与事件通知
 	coord.SetOverlimitCallback(app.handleOverlimit)
+	coord.SetUpdateCallback(app.handleUpdate)
+	app.setNotifier(notify.NewNotifier(cfg.Global.Notification))
+	enf.SetEventCallback(app.handleEnforcerEvent)
 
 	logger.Info("应用实例创建成功")
 	return app, nil
 }
 
+// SetDebug 设置命令行调试模式（热重载日志配置时保持 debug 级别）
+func (a *App) SetDebug(debug bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.debug = debug
+}
+
 // Start 启动应用
 func (a *App) Start() error {
 	a.mu.Lock()
@@ -93,12 +142,36 @@ func (a *App) Start() error {
 		return fmt.Errorf("应用已在运行")
 	}
 	a.isRunning = true
-	a.startTime = time.Now()
+	a.startTime = a.clock.Now()
 	a.mu.Unlock()
 
 	logger := utils.GetLogger()
 	logger.Info("========== NAM 启动 ==========")
 
+	// 0. 准备防火墙链，并对账持久化封禁与内核规则（恢复定时解封、清除过期与孤儿规则）
+	if err := a.enforcer.Setup(); err != nil {
+		logger.Errorf("初始化防火墙失败: %v", err)
+	} else if _, err := a.enforcer.Reconcile(a.db); err != nil {
+		logger.Errorf("封禁对账失败: %v", err)
+	}
+
+	// 恢复未到期的临时白名单
+	if restored, err := a.enforcer.RestoreTempAllows(); err != nil {
+		logger.Errorf("恢复临时白名单失败: %v", err)
+	} else if restored > 0 {
+		logger.Infof("恢复临时白名单 %d 条", restored)
+	}
+
+	// 黑名单整体下发到防火墙的黑名单集合/链（不产生封禁记录）
+	if err := a.enforcer.SyncBlacklists(); err != nil {
+		logger.Errorf("%v", err)
+	}
+
+	// 监听名单文件，变更后无需整体重载即可生效
+	a.mu.Lock()
+	a.syncListWatcher(a.config.ListFiles())
+	a.mu.Unlock()
+
 	// 1. 启动监控协调器（会自动初始化所有配置中的端口）
 	if err := a.coordinator.Start(); err != nil {
 		return fmt.Errorf("启动监控失败: %w", err)
@@ -112,9 +185,21 @@ func (a *App) Start() error {
 	a.wg.Add(1)
 	go a.cleanupWorker()
 
-	// 4. 设置信号处理
+	// 4. 启动趋势采样协程
+	a.wg.Add(1)
+	go a.historyWorker()
+
+	// 5. 设置信号处理
 	a.setupSignalHandler()
 
+	a.mu.RLock()
+	portCount := len(a.config.Rules)
+	a.mu.RUnlock()
+	a.notify(notify.Event{
+		Type:    notify.EventStart,
+		Message: fmt.Sprintf("NAM 已启动，监控 %d 个端口（防火墙后端: %s）", portCount, a.enforcer.BackendName()),
+	})
+
 	logger.Info("NAM 启动完成，开始监控...")
 	return nil
 }
@@ -142,8 +227,22 @@ func (a *App) Shutdown() {
 	logger := utils.GetLogger()
 	logger.Info("开始优雅关闭...")
 
-	// 1. 停止监控
+	// 0. 执行关闭回调（先停止对外接口，避免关闭过程中仍接受请求）
+	a.mu.RLock()
+	hooks := a.shutdownHooks
+	a.mu.RUnlock()
+	for _, hook := range hooks {
+		hook()
+	}
+
+	// 1. 停止监控与名单文件监听
 	a.coordinator.Stop()
+	a.mu.Lock()
+	if a.listWatcher != nil {
+		a.listWatcher.Close()
+		a.listWatcher = nil
+	}
+	a.mu.Unlock()
 
 	// 2. 等待后台协程结束
 	done := make(chan struct{})
@@ -162,10 +261,14 @@ func (a *App) Shutdown() {
 	// 3. 记录最终会话数据
 	a.recordFinalSessions()
 
-	// 4. 关闭 Enforcer（保留 iptables 规则）
+	// 4. 关闭 Enforcer（保留 iptables 规则与持久化记录，下次启动时对账恢复）
 	a.enforcer.Shutdown()
 
-	// 5. 关闭数据库
+	// 5. 发送停止通知并等待队列发送完毕
+	a.notify(notify.Event{Type: notify.EventStop, Message: "NAM 已停止"})
+	a.setNotifier(nil)
+
+	// 6. 关闭数据库
 	if err := a.db.Close(); err != nil {
 		logger.Errorf("关闭数据库失败: %v", err)
 	}
@@ -177,37 +280,177 @@ func (a *App) Shutdown() {
 	logger.Info("NAM 已关闭")
 }
 
-// Reload 热重载配置
-func (a *App) Reload() error {
+// Reload 热重载配置，返回本次变更摘要
+func (a *App) Reload() (*monitor.ReconfigureSummary, error) {
 	logger := utils.GetLogger()
 	logger.Info("========== 热重载配置 ==========")
 
 	// 1. 重新加载配置
 	newCfg, err := config.Load(a.configPath)
 	if err != nil {
-		return fmt.Errorf("加载新配置失败: %w", err)
+		return nil, fmt.Errorf("加载新配置失败: %w", err)
 	}
 
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	// 2. 重新配置监控协调器
-	if err := a.coordinator.Reconfigure(newCfg); err != nil {
-		return fmt.Errorf("重新配置监控器失败: %w", err)
+	oldCfg := a.config
+	if newCfg.Global.ConnectionSource != oldCfg.Global.ConnectionSource {
+		logger.Warnf("connection_source 变更（%s → %s）需重启后生效",
+			oldCfg.Global.ConnectionSource, newCfg.Global.ConnectionSource)
+	}
+	if newCfg.GetAPISocket() != oldCfg.GetAPISocket() {
+		logger.Warnf("api_socket 变更（%s → %s）需重启后生效", oldCfg.GetAPISocket(), newCfg.GetAPISocket())
+	}
+	if newCfg.Global.FirewallBackend != oldCfg.Global.FirewallBackend {
+		logger.Warnf("firewall_backend 变更（%s → %s）需重启后生效",
+			oldCfg.Global.FirewallBackend, newCfg.Global.FirewallBackend)
 	}
 
-	// 3. 重建 port -> rule 映射
+	// 2. 切换 Enforcer 配置（此后的策略判断均使用新规则）
+	a.enforcer.Reconfigure(newCfg)
+
+	// 3. 重新配置监控协调器，失败时回滚 Enforcer，保证新旧配置不混用
+	summary, err := a.coordinator.Reconfigure(newCfg)
+	if err != nil {
+		a.enforcer.Reconfigure(oldCfg)
+		return nil, fmt.Errorf("重新配置监控器失败: %w", err)
+	}
+
+	// 4. 日志设置变更时重新配置（监控器已切换成功后才执行，失败时保留原有日志输出）
+	oldLog, newLog := LogOptions(oldCfg, a.debug), LogOptions(newCfg, a.debug)
+	if !reflect.DeepEqual(oldLog, newLog) {
+		if err := utils.ConfigureLogger(newLog); err != nil {
+			logger.Errorf("应用日志配置失败，保留原有设置: %v", err)
+		} else {
+			logger.Info("日志配置已更新")
+		}
+	}
+
+	// 5. 按新配置整体替换防火墙中的黑名单
+	if err := a.enforcer.SyncBlacklists(); err != nil {
+		logger.Errorf("%v", err)
+	}
+
+	// 6. 按新配置更新名单文件监听
+	a.syncListWatcher(newCfg.ListFiles())
+
+	// 7. 重建 port -> rule 映射
 	newRuleMap := make(map[int]*config.Rule)
 	for i := range newCfg.Rules {
 		newRuleMap[newCfg.Rules[i].Port] = &newCfg.Rules[i]
 	}
 
-	// 4. 更新配置
+	// 8. 更新配置
 	a.config = newCfg
 	a.ruleMap = newRuleMap
 
-	logger.Info("配置热重载完成")
-	return nil
+	// 9. 通知设置变更时重建通知器
+	if !reflect.DeepEqual(oldCfg.Global.Notification, newCfg.Global.Notification) {
+		a.setNotifier(notify.NewNotifier(newCfg.Global.Notification))
+		logger.Info("通知设置已更新")
+	}
+	a.notify(notify.Event{Type: notify.EventReload, Message: fmt.Sprintf("配置已热重载: %s", summary)})
+
+	logger.Infof("配置热重载完成: %s", summary)
+	return summary, nil
+}
+
+// syncListWatcher 按配置更新名单文件监听（首次配置名单文件时启动，调用方需持有 a.mu）
+func (a *App) syncListWatcher(files []string) {
+	logger := utils.GetLogger()
+
+	if a.listWatcher == nil {
+		if len(files) == 0 {
+			return
+		}
+		watcher, err := iplist.NewWatcher(a.handleListChange)
+		if err != nil {
+			logger.Warnf("名单文件监听不可用，文件变更需热重载后生效: %v", err)
+			return
+		}
+		a.listWatcher = watcher
+	}
+
+	if err := a.listWatcher.SetFiles(files); err != nil {
+		logger.Warnf("监听名单文件失败: %v", err)
+		return
+	}
+	if len(files) > 0 {
+		logger.Infof("已监听 %d 个名单文件", len(files))
+	}
+}
+
+// handleListChange 名单文件变更回调：重新加载并同步黑名单
+func (a *App) handleListChange(paths []string) {
+	logger := utils.GetLogger()
+	logger.Infof("名单文件已变更: %v", paths)
+
+	if err := a.enforcer.ReloadListFiles(paths); err != nil {
+		logger.Errorf("%v", err)
+	}
+}
+
+// handleEnforcerEvent 将执行事件转换为通知
+func (a *App) handleEnforcerEvent(event enforcer.Event) {
+	record := event.Record
+
+	n := notify.Event{Port: record.Port, IP: record.IP}
+	switch event.Type {
+	case enforcer.EventBan:
+		duration := "永久"
+		if record.Duration > 0 {
+			duration = (time.Duration(record.Duration) * time.Second).String()
+		}
+		n.Type = notify.EventBan
+		n.Message = fmt.Sprintf("封禁 %s（端口 %d，时长 %s）", record.IP, record.Port, duration)
+		n.Details = map[string]string{"reason": record.Reason, "strategy": record.Strategy, "duration": duration}
+		a.history.recordBan(record.Port)
+	case enforcer.EventUnban:
+		n.Type = notify.EventUnban
+		n.Message = fmt.Sprintf("解封 %s（端口 %d）", record.IP, record.Port)
+		n.Details = map[string]string{"reason": record.Reason, "strategy": record.Strategy}
+	case enforcer.EventBlacklist:
+		n.Type = notify.EventBlacklist
+		n.Message = fmt.Sprintf("断开黑名单连接 %s（端口 %d）", record.IP, record.Port)
+	case enforcer.EventKick:
+		n.Type = notify.EventKick
+		n.Message = fmt.Sprintf("手动断开 %s（端口 %d）", record.IP, record.Port)
+	case enforcer.EventAllow:
+		duration := (time.Duration(record.Duration) * time.Second).String()
+		n.Type = notify.EventAllow
+		n.Message = fmt.Sprintf("临时放行 %s（端口 %d，时长 %s）", record.IP, record.Port, duration)
+		n.Details = map[string]string{"reason": record.Reason, "duration": duration}
+	default:
+		return
+	}
+
+	a.notify(n)
+}
+
+// notify 记录事件并提交通知（不阻塞）
+func (a *App) notify(event notify.Event) {
+	if event.Time.IsZero() {
+		event.Time = a.clock.Now()
+	}
+	a.events.add(event)
+	a.notifier.Load().Notify(event)
+}
+
+// setNotifier 替换通知器，旧通知器在后台发送完剩余事件后关闭（nil 表示停用）
+func (a *App) setNotifier(n *notify.Notifier) {
+	if old := a.notifier.Swap(n); old != nil {
+		if n == nil {
+			old.Close()
+		} else {
+			go old.Close()
+		}
+	}
+}
+
+// handleUpdate 每个周期更新追踪器后调用：立即断开黑名单连接（不计入 max_ips）
+func (a *App) handleUpdate(port int, tracker *monitor.PortTracker) {
+	a.enforcer.EnforceBlacklist(port, tracker)
 }
 
 // handleOverlimit 处理端口超限回调
@@ -215,9 +458,16 @@ func (a *App) handleOverlimit(port, current, max int) {
 	logger := utils.GetLogger()
 	logger.Warnf("端口 %d 超限: 当前 %d IP，最大 %d IP", port, current, max)
 
-	// 获取对应的规则
+	a.notify(notify.Event{
+		Type:    notify.EventOverlimit,
+		Port:    port,
+		Message: fmt.Sprintf("端口 %d 超限: 当前 %d IP，最大 %d IP", port, current, max),
+		Details: map[string]string{"current": strconv.Itoa(current), "max": strconv.Itoa(max)},
+	})
+
+	// 确认端口仍在当前规则中
 	a.mu.RLock()
-	rule, exists := a.ruleMap[port]
+	_, exists := a.ruleMap[port]
 	a.mu.RUnlock()
 
 	if !exists {
@@ -232,8 +482,8 @@ func (a *App) handleOverlimit(port, current, max int) {
 		return
 	}
 
-	// 执行策略
-	a.enforcer.Enforce(port, tracker, rule)
+	// 执行策略（Enforcer 使用自身持有的当前配置解析规则）
+	a.enforcer.Enforce(port, tracker)
 }
 
 // statisticsWorker 统计数据后台协程
@@ -243,7 +493,7 @@ func (a *App) statisticsWorker() {
 	logger := utils.GetLogger()
 	logger.Info("启动统计协程")
 
-	ticker := time.NewTicker(1 * time.Hour)
+	ticker := a.clock.NewTicker(1 * time.Hour)
 	defer ticker.Stop()
 
 	for {
@@ -251,7 +501,7 @@ func (a *App) statisticsWorker() {
 		case <-a.ctx.Done():
 			logger.Info("统计协程退出")
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			a.collectStatistics()
 		}
 	}
@@ -272,7 +522,7 @@ func (a *App) collectStatistics() {
 		}
 
 		stats := &storage.PortStatistics{
-			Hour:        time.Now().Truncate(time.Hour),
+			Hour:        a.clock.Now().Truncate(time.Hour),
 			UniqueIPs:   tracker.Count(),
 			TotalBans:   len(a.enforcer.GetActiveBans()),
 			AvgSessions: 0, // 简化处理
@@ -285,6 +535,38 @@ func (a *App) collectStatistics() {
 	}
 }
 
+// historyWorker 趋势采样后台协程
+func (a *App) historyWorker() {
+	defer a.wg.Done()
+
+	ticker := a.clock.NewTicker(historyInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C():
+			a.sampleHistory()
+		}
+	}
+}
+
+// sampleHistory 为每个监控端口记录一个趋势采样点
+func (a *App) sampleHistory() {
+	a.mu.RLock()
+	rules := a.config.Rules
+	a.mu.RUnlock()
+
+	ips := make(map[int]int, len(rules))
+	for _, rule := range rules {
+		if tracker := a.coordinator.GetTracker(rule.Port); tracker != nil {
+			ips[rule.Port] = tracker.Count()
+		}
+	}
+	a.history.sample(a.clock.Now(), ips)
+}
+
 // cleanupWorker 数据库清理后台协程
 func (a *App) cleanupWorker() {
 	defer a.wg.Done()
@@ -292,7 +574,7 @@ func (a *App) cleanupWorker() {
 	logger := utils.GetLogger()
 	logger.Info("启动清理协程")
 
-	ticker := time.NewTicker(24 * time.Hour)
+	ticker := a.clock.NewTicker(24 * time.Hour)
 	defer ticker.Stop()
 
 	for {
@@ -300,7 +582,7 @@ func (a *App) cleanupWorker() {
 		case <-a.ctx.Done():
 			logger.Info("清理协程退出")
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			a.mu.RLock()
 			daysToKeep := a.config.Global.HistoryDays
 			a.mu.RUnlock()
@@ -362,7 +644,7 @@ func (a *App) setupSignalHandler() {
 					os.Exit(0)
 				case syscall.SIGHUP:
 					logger.Infof("收到信号 %s，重载配置...", sig)
-					if err := a.Reload(); err != nil {
+					if _, err := a.Reload(); err != nil {
 						logger.Errorf("重载配置失败: %v", err)
 					}
 				}
@@ -377,10 +659,14 @@ func (a *App) GetStatus() *Status {
 	defer a.mu.RUnlock()
 
 	status := &Status{
-		IsRunning: a.isRunning,
-		StartTime: a.startTime,
-		Uptime:    time.Since(a.startTime),
-		Ports:     make([]PortStatus, 0),
+		IsRunning:  a.isRunning,
+		PID:        os.Getpid(),
+		StartTime:  a.startTime,
+		Uptime:     a.clock.Now().Sub(a.startTime),
+		ConfigPath: a.configPath,
+		Backend:    a.enforcer.BackendName(),
+		TotalBans:  len(a.enforcer.GetActiveBans()),
+		Ports:      make([]PortStatus, 0),
 	}
 
 	if !a.isRunning {
@@ -396,6 +682,7 @@ func (a *App) GetStatus() *Status {
 		portStatus := PortStatus{
 			Port:       rule.Port,
 			Protocol:   rule.Protocol,
+			Tag:        rule.Tag,
 			MaxIPs:     rule.MaxIPs,
 			CurrentIPs: tracker.Count(),
 		}
@@ -406,23 +693,110 @@ func (a *App) GetStatus() *Status {
 	return status
 }
 
+// GetSessions 获取端口的活跃会话
+func (a *App) GetSessions(port int) ([]*monitor.Session, error) {
+	tracker := a.coordinator.GetTracker(port)
+	if tracker == nil {
+		return nil, fmt.Errorf("端口 %d 未被监控", port)
+	}
+	return tracker.GetActiveSessions(), nil
+}
+
+// GetEvents 获取序号大于 after 的最近事件
+func (a *App) GetEvents(after uint64) []EventEntry {
+	return a.events.since(after)
+}
+
+// GetHistory 获取端口最近的趋势采样点（按时间顺序）
+func (a *App) GetHistory(port int) ([]HistoryPoint, error) {
+	a.mu.RLock()
+	_, exists := a.ruleMap[port]
+	a.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("端口 %d 未被监控", port)
+	}
+	return a.history.get(port), nil
+}
+
 // GetActiveBans 获取活跃的封禁列表
 func (a *App) GetActiveBans() []enforcer.BanRecord {
 	return a.enforcer.GetActiveBans()
 }
 
+// ManualBan 手动封禁（断开连接并下发封禁，duration 为 0 表示永久）
+func (a *App) ManualBan(ip string, port int, duration int, reason string) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("端口号必须在 1-65535 之间")
+	}
+	if a.coordinator.GetTracker(port) == nil {
+		return fmt.Errorf("端口 %d 未被监控", port)
+	}
+	if duration < 0 {
+		return fmt.Errorf("封禁时长不能为负数")
+	}
+	if reason == "" {
+		reason = "manual"
+	}
+	return a.enforcer.ManualBan(ip, port, duration, reason)
+}
+
+// ManualUnban 手动解封
+func (a *App) ManualUnban(ip string, port int) error {
+	return a.enforcer.ManualUnban(ip, port)
+}
+
+// Kick 手动断开连接（不封禁）
+func (a *App) Kick(ip string, port int) error {
+	return a.enforcer.Kick(ip, port)
+}
+
+// TempAllow 临时放行（加入临时白名单并解除已有封禁），duration 为秒
+func (a *App) TempAllow(ip string, port int, duration int, reason string) error {
+	if a.coordinator.GetTracker(port) == nil {
+		return fmt.Errorf("端口 %d 未被监控", port)
+	}
+	if reason == "" {
+		reason = "manual"
+	}
+	return a.enforcer.TempAllow(ip, port, duration, reason)
+}
+
+// GetTempAllows 获取临时白名单
+func (a *App) GetTempAllows() []enforcer.AllowRecord {
+	return a.enforcer.GetTempAllows()
+}
+
+// APISocket 控制接口套接字路径
+func (a *App) APISocket() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.config.GetAPISocket()
+}
+
+// OnShutdown 注册优雅关闭时最先执行的回调（如关闭控制接口）
+func (a *App) OnShutdown(fn func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.shutdownHooks = append(a.shutdownHooks, fn)
+}
+
 // Status 运行状态
 type Status struct {
-	IsRunning bool
-	StartTime time.Time
-	Uptime    time.Duration
-	Ports     []PortStatus
+	IsRunning  bool          `json:"is_running"`
+	PID        int           `json:"pid"`
+	StartTime  time.Time     `json:"start_time"`
+	Uptime     time.Duration `json:"uptime"`
+	ConfigPath string        `json:"config_path"`
+	Backend    string        `json:"backend"`    // 防火墙后端
+	TotalBans  int           `json:"total_bans"` // 活跃封禁数
+	Ports      []PortStatus  `json:"ports"`
 }
 
 // PortStatus 端口状态
 type PortStatus struct {
-	Port       int
-	Protocol   string
-	MaxIPs     int
-	CurrentIPs int
+	Port       int    `json:"port"`
+	Protocol   string `json:"protocol"`
+	Tag        string `json:"tag"`
+	MaxIPs     int    `json:"max_ips"`
+	CurrentIPs int    `json:"current_ips"`
 }
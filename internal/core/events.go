@@ -0,0 +1,64 @@
+package core
+
+import (
+	"sync"
+
+	"github.com/nodeaccessmanager/nam/internal/notify"
+)
+
+// eventLogSize 守护进程保留的最近事件条数
+const eventLogSize = 200
+
+// EventEntry 带序号的事件（序号从 1 开始递增，客户端据此增量轮询）
+type EventEntry struct {
+	Seq uint64 `json:"seq"`
+	notify.Event
+}
+
+// eventLog 最近事件环形缓冲区（与通知是否启用无关）
+type eventLog struct {
+	mu      sync.Mutex
+	entries []EventEntry
+	next    int    // 下一条写入位置
+	seq     uint64 // 最近一条事件的序号
+}
+
+// newEventLog 创建事件缓冲区
+func newEventLog(size int) *eventLog {
+	return &eventLog{entries: make([]EventEntry, 0, size)}
+}
+
+// add 追加事件，缓冲区满时覆盖最旧的一条
+func (l *eventLog) add(event notify.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq++
+	entry := EventEntry{Seq: l.seq, Event: event}
+	if len(l.entries) < cap(l.entries) {
+		l.entries = append(l.entries, entry)
+		return
+	}
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+}
+
+// since 按时间顺序返回序号大于 after 的事件
+// after 超过当前最大序号（守护进程已重启）时返回全部事件
+func (l *eventLog) since(after uint64) []EventEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if after > l.seq {
+		after = 0
+	}
+
+	result := make([]EventEntry, 0)
+	for i := range l.entries {
+		entry := l.entries[(l.next+i)%len(l.entries)]
+		if entry.Seq > after {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
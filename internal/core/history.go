@@ -0,0 +1,83 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// 趋势采样：每 10 秒一个点，每个端口保留最近 1 小时
+const (
+	historyInterval = 10 * time.Second
+	historySize     = 360
+)
+
+// HistoryPoint 端口趋势采样点
+type HistoryPoint struct {
+	Time      time.Time `json:"time"`
+	UniqueIPs int       `json:"unique_ips"` // 采样时的在线 IP 数
+	Bans      int       `json:"bans"`       // 距上一个采样点新增的封禁数（不含黑名单命中）
+}
+
+// history 各端口的趋势环形缓冲区，供 TUI 绘制图表
+type history struct {
+	mu      sync.Mutex
+	points  map[int][]HistoryPoint
+	next    map[int]int // 各端口下一条写入位置
+	pending map[int]int // 当前采样周期内的封禁数
+	size    int
+}
+
+// newHistory 创建趋势缓冲区
+func newHistory(size int) *history {
+	return &history{
+		points:  make(map[int][]HistoryPoint),
+		next:    make(map[int]int),
+		pending: make(map[int]int),
+		size:    size,
+	}
+}
+
+// recordBan 记录一次封禁，计入下一个采样点
+func (h *history) recordBan(port int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pending[port]++
+}
+
+// sample 为当前监控的端口各写入一个采样点（ips 为 port -> 在线 IP 数），已移除的端口丢弃历史
+func (h *history) sample(now time.Time, ips map[int]int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for port := range h.points {
+		if _, ok := ips[port]; !ok {
+			delete(h.points, port)
+			delete(h.next, port)
+		}
+	}
+
+	for port, count := range ips {
+		point := HistoryPoint{Time: now, UniqueIPs: count, Bans: h.pending[port]}
+		points := h.points[port]
+		if len(points) < h.size {
+			h.points[port] = append(points, point)
+			continue
+		}
+		points[h.next[port]] = point
+		h.next[port] = (h.next[port] + 1) % h.size
+	}
+	h.pending = make(map[int]int)
+}
+
+// get 按时间顺序返回端口的采样点
+func (h *history) get(port int) []HistoryPoint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	points := h.points[port]
+	result := make([]HistoryPoint, 0, len(points))
+	for i := range points {
+		result = append(result, points[(h.next[port]+i)%len(points)])
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"github.com/nodeaccessmanager/nam/internal/config"
+	"github.com/nodeaccessmanager/nam/pkg/utils"
+)
+
+// logOutputs 配置中的日志输出写法 -> 日志系统的输出目标
+var logOutputs = map[string]utils.LogOutput{
+	config.LogOutputFile:     utils.OutputFile,
+	config.LogOutputStdout:   utils.OutputStdout,
+	config.LogOutputSyslog:   utils.OutputSyslog,
+	config.LogOutputJournald: utils.OutputJournald,
+}
+
+// LogOptions 由配置生成日志选项（debug 为 true 时强制使用 debug 级别）
+// 配置已通过验证，无法识别的输出不会出现
+func LogOptions(cfg *config.Config, debug bool) utils.LogOptions {
+	g := cfg.Global
+	var outputs []utils.LogOutput
+	for _, name := range g.LogOutputs {
+		if output, ok := logOutputs[name]; ok {
+			outputs = append(outputs, output)
+		}
+	}
+
+	opts := utils.LogOptions{
+		Outputs:    outputs,
+		File:       g.LogFile,
+		Level:      g.LogLevel,
+		JSON:       g.LogFormat == config.LogFormatJSON,
+		MaxSize:    g.LogMaxSize,
+		MaxBackups: g.LogMaxBackups,
+		MaxAge:     g.LogMaxAge,
+	}
+	if debug {
+		opts.Level = "debug"
+	}
+	return opts
+}
+
+// DefaultLogOptions 配置无法加载时使用的日志选项（保证启动错误能写入默认日志文件）
+func DefaultLogOptions(debug bool) utils.LogOptions {
+	return LogOptions(config.DefaultConfig(), debug)
+}
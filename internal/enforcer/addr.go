@@ -0,0 +1,78 @@
+package enforcer
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"os/exec"
+)
+
+var (
+	// ErrInvalidIP 封禁目标不是合法的 IP 地址或前缀
+	ErrInvalidIP = errors.New("无效的 IP 地址")
+	// ErrUnsupportedFamily 当前后端无法处理该地址族（如缺少 ip6tables）
+	ErrUnsupportedFamily = errors.New("不支持的地址族")
+)
+
+// parseBanTarget 解析封禁目标：单个地址（视为 /32、/128）或前缀（启用地址聚合时）
+// IPv4-mapped IPv6 地址还原为 IPv4，前缀按掩码规范化
+func parseBanTarget(target string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(target); err == nil {
+		addr = addr.Unmap().WithZone("")
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+
+	prefix, err := netip.ParsePrefix(target)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%w: %q", ErrInvalidIP, target)
+	}
+
+	addr := prefix.Addr()
+	bits := prefix.Bits()
+	if addr.Is4In6() {
+		if bits < 96 {
+			return netip.Prefix{}, fmt.Errorf("%w: %q", ErrInvalidIP, target)
+		}
+		addr, bits = addr.Unmap(), bits-96
+	}
+	return netip.PrefixFrom(addr, bits).Masked(), nil
+}
+
+// targetString 封禁目标的规范写法：单个地址不带前缀长度
+func targetString(prefix netip.Prefix) string {
+	if prefix.IsSingleIP() {
+		return prefix.Addr().String()
+	}
+	return prefix.String()
+}
+
+// NormalizeIP 返回地址或前缀的规范写法（::ffff:192.0.2.1 → 192.0.2.1，IPv6 小写压缩）
+// 封禁记录、冷却 key 与内核列表都使用该写法，保证同一目标只对应一条记录
+func NormalizeIP(ip string) (string, error) {
+	prefix, err := parseBanTarget(ip)
+	if err != nil {
+		return "", err
+	}
+	return targetString(prefix), nil
+}
+
+// familyName 地址族名称（用于日志与错误信息）
+func familyName(addr netip.Addr) string {
+	if addr.Is4() {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+// iptablesBinaryFor 按地址族选择 iptables / ip6tables
+func iptablesBinaryFor(addr netip.Addr) (string, error) {
+	binary := "iptables"
+	if !addr.Is4() {
+		binary = "ip6tables"
+	}
+
+	if _, err := exec.LookPath(binary); err != nil {
+		return "", fmt.Errorf("%w: %s 地址 %s 需要 %s", ErrUnsupportedFamily, familyName(addr), addr, binary)
+	}
+	return binary, nil
+}
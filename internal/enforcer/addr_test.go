@@ -0,0 +1,178 @@
+package enforcer
+
+import (
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeBinaries 将 PATH 指向只包含指定空壳命令的临时目录
+func fakeBinaries(t *testing.T, names ...string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestNormalizeIP(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "192.0.2.1", want: "192.0.2.1"},
+		{input: "::ffff:192.0.2.1", want: "192.0.2.1"},
+		{input: "::FFFF:C000:0201", want: "192.0.2.1"},
+		{input: "2001:DB8:0:0::1", want: "2001:db8::1"},
+		{input: "fe80::1%eth0", want: "fe80::1"},
+		{input: "192.0.2.77/24", want: "192.0.2.0/24"},
+		{input: "192.0.2.1/32", want: "192.0.2.1"},
+		{input: "2001:db8::1/64", want: "2001:db8::/64"},
+		{input: "::ffff:198.51.100.0/120", want: "198.51.100.0/24"},
+		{input: "::ffff:0:0/95", wantErr: true},
+		{input: "192.0.2.256", wantErr: true},
+		{input: "203.0.113.0/33", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeIP(tt.input)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidIP) {
+				t.Errorf("NormalizeIP(%q) = %q, %v, want ErrInvalidIP", tt.input, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizeIP(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseBanTarget(t *testing.T) {
+	tests := []struct {
+		input string
+		want  netip.Prefix
+	}{
+		{"192.0.2.1", netip.MustParsePrefix("192.0.2.1/32")},
+		{"::ffff:192.0.2.1", netip.MustParsePrefix("192.0.2.1/32")},
+		{"2001:db8::1", netip.MustParsePrefix("2001:db8::1/128")},
+		{"10.1.2.3/8", netip.MustParsePrefix("10.0.0.0/8")},
+		{"::ffff:10.0.0.0/104", netip.MustParsePrefix("10.0.0.0/8")},
+	}
+
+	for _, tt := range tests {
+		got, err := parseBanTarget(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("parseBanTarget(%q) = %s, %v, want %s", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestIPTablesBinaryFor(t *testing.T) {
+	v4 := netip.MustParseAddr("192.0.2.1")
+	v6 := netip.MustParseAddr("2001:db8::1")
+
+	// 只有 iptables：IPv6 地址不受支持
+	fakeBinaries(t, "iptables")
+	if binary, err := iptablesBinaryFor(v4); err != nil || binary != "iptables" {
+		t.Errorf("IPv4 = %q, %v, want iptables", binary, err)
+	}
+	if _, err := iptablesBinaryFor(v6); !errors.Is(err, ErrUnsupportedFamily) {
+		t.Errorf("缺少 ip6tables 时 IPv6 err = %v, want ErrUnsupportedFamily", err)
+	}
+
+	fakeBinaries(t, "iptables", "ip6tables")
+	if binary, err := iptablesBinaryFor(v6); err != nil || binary != "ip6tables" {
+		t.Errorf("IPv6 = %q, %v, want ip6tables", binary, err)
+	}
+
+	fakeBinaries(t)
+	if _, err := iptablesBinaryFor(v4); !errors.Is(err, ErrUnsupportedFamily) {
+		t.Errorf("缺少 iptables 时 IPv4 err = %v, want ErrUnsupportedFamily", err)
+	}
+}
+
+func TestIPSetMember(t *testing.T) {
+	fakeBinaries(t, "iptables", "ip6tables")
+
+	tests := []struct {
+		ip     string
+		set    string
+		member string
+	}{
+		{"192.0.2.1", ipsetV4, "192.0.2.1,tcp:443"},
+		{"::ffff:192.0.2.1", ipsetV4, "192.0.2.1,tcp:443"},
+		{"198.51.100.0/24", ipsetV4, "198.51.100.0/24,tcp:443"},
+		{"2001:db8::1", ipsetV6, "2001:db8::1,tcp:443"},
+		{"2001:db8::/64", ipsetV6, "2001:db8::/64,tcp:443"},
+	}
+	for _, tt := range tests {
+		set, member, err := ipsetMember(tt.ip, 443)
+		if err != nil || set != tt.set || member != tt.member {
+			t.Errorf("ipsetMember(%q) = %q %q %v, want %q %q", tt.ip, set, member, err, tt.set, tt.member)
+		}
+	}
+
+	if _, _, err := ipsetMember("not-an-ip", 443); !errors.Is(err, ErrInvalidIP) {
+		t.Errorf("无效地址 err = %v, want ErrInvalidIP", err)
+	}
+
+	// 集合只有被 ip6tables 规则引用才生效
+	fakeBinaries(t, "iptables")
+	if _, _, err := ipsetMember("2001:db8::1", 443); !errors.Is(err, ErrUnsupportedFamily) {
+		t.Errorf("缺少 ip6tables 时 err = %v, want ErrUnsupportedFamily", err)
+	}
+}
+
+func TestNftElement(t *testing.T) {
+	tests := []struct {
+		ip   string
+		set  string
+		elem string
+	}{
+		{"192.0.2.1", "ban4_443", "192.0.2.1"},
+		{"::ffff:192.0.2.1", "ban4_443", "192.0.2.1"},
+		{"198.51.100.7/24", "ban4_443", "198.51.100.0/24"},
+		{"2001:db8::1", "ban6_443", "2001:db8::1"},
+		{"2001:db8::1/64", "ban6_443", "2001:db8::/64"},
+	}
+	for _, tt := range tests {
+		set, elem, err := nftElement(tt.ip, 443)
+		if err != nil || set != tt.set || elem != tt.elem {
+			t.Errorf("nftElement(%q) = %q %q %v, want %q %q", tt.ip, set, elem, err, tt.set, tt.elem)
+		}
+	}
+
+	if _, _, err := nftElement("2001:db8::zz", 443); !errors.Is(err, ErrInvalidIP) {
+		t.Errorf("无效地址 err = %v, want ErrInvalidIP", err)
+	}
+}
+
+func TestParseSetName(t *testing.T) {
+	tests := []struct {
+		name   string
+		port   int
+		family int
+		ok     bool
+	}{
+		{"ban4_443", 443, 4, true},
+		{"ban6_8443", 8443, 6, true},
+		{"black4_443", 0, 0, false}, // 黑名单集合不计入封禁列表
+		{"ban4_0", 0, 0, false},
+		{"ban6_70000", 0, 0, false},
+		{"ban4_x", 0, 0, false},
+	}
+	for _, tt := range tests {
+		port, family, ok := parseSetName(tt.name)
+		if port != tt.port || family != tt.family || ok != tt.ok {
+			t.Errorf("parseSetName(%q) = %d %d %v, want %d %d %v", tt.name, port, family, ok, tt.port, tt.family, tt.ok)
+		}
+	}
+}
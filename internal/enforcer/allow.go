@@ -0,0 +1,178 @@
+package enforcer
+
+import (
+	"net/netip"
+	"sort"
+	"sync"
+
+	"github.com/nodeaccessmanager/nam/internal/clock"
+	"github.com/nodeaccessmanager/nam/pkg/utils"
+)
+
+// TempAllowList 临时白名单：优先级高于所有名单，命中的会话不会被驱逐或按黑名单断开，到期自动失效
+// 已下发为防火墙规则的黑名单网段不受影响
+type TempAllowList struct {
+	entries map[string]*allowEntry // key: "IP:PORT"
+	mu      sync.Mutex
+	store   AllowStore // 可选，持久化临时白名单
+	clock   clock.Clock
+}
+
+// allowEntry 内部临时白名单条目
+type allowEntry struct {
+	Record AllowRecord
+	Prefix netip.Prefix
+	Timer  clock.Timer
+}
+
+// NewTempAllowList 创建临时白名单
+func NewTempAllowList() *TempAllowList {
+	return &TempAllowList{
+		entries: make(map[string]*allowEntry),
+		clock:   clock.Real(),
+	}
+}
+
+// SetClock 设置时间源（需在添加任何条目之前调用）
+func (l *TempAllowList) SetClock(clk clock.Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = clk
+}
+
+// SetStore 设置持久化存储
+func (l *TempAllowList) SetStore(store AllowStore) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.store = store
+}
+
+// Add 加入临时白名单（同一 IP:端口 覆盖旧条目）
+func (l *TempAllowList) Add(record AllowRecord) error {
+	prefix, err := parseBanTarget(record.IP)
+	if err != nil {
+		return err
+	}
+	record.IP = targetString(prefix)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.arm(record, prefix)
+	if l.store != nil {
+		if err := l.store.SaveTempAllow(&record); err != nil {
+			utils.GetLogger().Errorf("持久化临时白名单失败 %s: %v", banKey(record.IP, record.Port), err)
+		}
+	}
+	return nil
+}
+
+// Restore 从持久化存储恢复未到期的临时白名单，已到期的记录直接删除
+func (l *TempAllowList) Restore() (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.store == nil {
+		return 0, nil
+	}
+	records, err := l.store.LoadTempAllows()
+	if err != nil {
+		return 0, err
+	}
+
+	now := l.clock.Now()
+	restored := 0
+	for _, record := range records {
+		prefix, err := parseBanTarget(record.IP)
+		if err != nil || !record.ExpireAt.After(now) {
+			if err := l.store.DeleteTempAllow(record.IP, record.Port); err != nil {
+				utils.GetLogger().Errorf("删除过期临时白名单失败 %s: %v", banKey(record.IP, record.Port), err)
+			}
+			continue
+		}
+		l.arm(record, prefix)
+		restored++
+	}
+	return restored, nil
+}
+
+// arm 登记条目并启动到期定时器（调用方需持有锁）
+func (l *TempAllowList) arm(record AllowRecord, prefix netip.Prefix) {
+	key := banKey(record.IP, record.Port)
+	if old, exists := l.entries[key]; exists {
+		old.Timer.Stop()
+	}
+
+	remaining := record.ExpireAt.Sub(l.clock.Now())
+	if remaining < 0 {
+		remaining = 0
+	}
+	entry := &allowEntry{Record: record, Prefix: prefix}
+	entry.Timer = l.clock.AfterFunc(remaining, func() {
+		l.expire(entry)
+	})
+	l.entries[key] = entry
+}
+
+// expire 定时器回调，移除到期条目
+func (l *TempAllowList) expire(entry *allowEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := banKey(entry.Record.IP, entry.Record.Port)
+	if l.entries[key] != entry {
+		return // 已被覆盖
+	}
+	delete(l.entries, key)
+
+	if l.store != nil {
+		if err := l.store.DeleteTempAllow(entry.Record.IP, entry.Record.Port); err != nil {
+			utils.GetLogger().Errorf("删除临时白名单记录失败 %s: %v", key, err)
+		}
+	}
+	utils.GetLogger().Infof("临时白名单到期: %s", key)
+}
+
+// Contains 检查目标是否整体落在端口的某个临时白名单条目内
+func (l *TempAllowList) Contains(port int, target netip.Prefix) bool {
+	if l == nil {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, entry := range l.entries {
+		if entry.Record.Port == port &&
+			entry.Prefix.Bits() <= target.Bits() && entry.Prefix.Contains(target.Addr()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Records 全部临时白名单（按到期时间排序）
+func (l *TempAllowList) Records() []AllowRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	records := make([]AllowRecord, 0, len(l.entries))
+	for _, entry := range l.entries {
+		records = append(records, entry.Record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ExpireAt.Before(records[j].ExpireAt)
+	})
+	return records
+}
+
+// Clear 停止全部定时器（不删除持久化记录，重启后恢复）
+func (l *TempAllowList) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, entry := range l.entries {
+		entry.Timer.Stop()
+		delete(l.entries, key)
+	}
+}
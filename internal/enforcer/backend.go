@@ -0,0 +1,110 @@
+package enforcer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nodeaccessmanager/nam/internal/config"
+	"github.com/nodeaccessmanager/nam/pkg/utils"
+)
+
+// BanBackend 防火墙封禁后端
+// Executor 只依赖该接口，具体实现有 iptables（NAM-INPUT 链）、ipset 与 nftables（inet nam 表）
+type BanBackend interface {
+	// Name 后端名称（用于日志）
+	Name() string
+	// Probe 检查后端在当前系统上是否可用
+	Probe() error
+	// Setup 创建 NAM 专用的链/表（幂等）
+	Setup() error
+	// Ban 封禁 IP 访问端口（duration 为 0 表示永久；已封禁时刷新期限）
+	Ban(ip string, port int, duration int) error
+	// Unban 解除封禁
+	Unban(ip string, port int) error
+	// SetBlacklist 以一次操作整体替换黑名单丢弃规则（port -> 地址或前缀，条目互不包含），
+	// 不在 lists 中的端口清空。黑名单与封禁分开存放，不计入 List，也不参与启动对账
+	SetBlacklist(lists map[int][]string) error
+	// List 列出内核中实际存在的 NAM 封禁
+	List() ([]RuleEntry, error)
+	// Flush 清空全部 NAM 封禁与黑名单（dryRun 为 true 时只返回将被删除的封禁条目）
+	Flush(dryRun bool) ([]RuleEntry, error)
+	// Teardown 删除 NAM 专用的链/表
+	Teardown() error
+	// NativeExpiry 封禁是否由内核按超时自动解除
+	NativeExpiry() bool
+}
+
+// NewBanBackend 根据配置创建防火墙后端
+// auto 模式：沿用已有的 ipset 集合或 NAM-INPUT 链，否则按 nftables → ipset → iptables 的顺序探测
+func NewBanBackend(name string) (BanBackend, error) {
+	logger := utils.GetLogger()
+
+	var backend BanBackend
+	switch name {
+	case config.FirewallBackendIPTables:
+		backend = NewIPTablesBackend()
+	case config.FirewallBackendIPSet:
+		backend = NewIPSetBackend()
+	case config.FirewallBackendNFTables:
+		backend = NewNFTablesBackend()
+	case "", config.FirewallBackendAuto:
+		return detectBanBackend()
+	default:
+		return nil, fmt.Errorf("不支持的防火墙后端: %s", name)
+	}
+
+	if err := backend.Probe(); err != nil {
+		return nil, fmt.Errorf("防火墙后端 %s 不可用: %w", backend.Name(), err)
+	}
+
+	logger.Infof("使用防火墙后端: %s", backend.Name())
+	return backend, nil
+}
+
+// detectBanBackend 自动探测可用的防火墙后端
+func detectBanBackend() (BanBackend, error) {
+	logger := utils.GetLogger()
+
+	// 升级安装：沿用已有的集合或链，避免旧规则成为无人管理的孤儿
+	ips := NewIPSetBackend()
+	if ips.Probe() == nil && ips.hasSets() {
+		logger.Infof("自动选择防火墙后端: %s（已存在集合 %s）", ips.Name(), ipsetV4)
+		return ips, nil
+	}
+
+	ipt := NewIPTablesBackend()
+	if ipt.Probe() == nil && ipt.hasChain() {
+		logger.Infof("自动选择防火墙后端: %s（已存在 %s 链）", ipt.Name(), namChain)
+		return ipt, nil
+	}
+
+	for _, backend := range AllBanBackends() {
+		if err := backend.Probe(); err != nil {
+			logger.Debugf("防火墙后端 %s 不可用: %v", backend.Name(), err)
+			continue
+		}
+		logger.Infof("自动选择防火墙后端: %s", backend.Name())
+		return backend, nil
+	}
+
+	return nil, fmt.Errorf("未找到可用的防火墙后端（nftables、ipset、iptables 均不可用）")
+}
+
+// AllBanBackends 返回全部防火墙后端（按自动探测的优先级排序，供清理命令遍历）
+func AllBanBackends() []BanBackend {
+	return []BanBackend{
+		NewNFTablesBackend(),
+		NewIPSetBackend(),
+		NewIPTablesBackend(),
+	}
+}
+
+// sortedPorts 按端口升序返回黑名单中的端口，保证生成的规则顺序稳定
+func sortedPorts(lists map[int][]string) []int {
+	ports := make([]int, 0, len(lists))
+	for port := range lists {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports
+}
@@ -0,0 +1,208 @@
+package enforcer
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/nodeaccessmanager/nam/internal/iplist"
+	"github.com/nodeaccessmanager/nam/internal/monitor"
+	"github.com/nodeaccessmanager/nam/pkg/utils"
+)
+
+// portBlacklist 端口已下发到防火墙的黑名单
+type portBlacklist struct {
+	entries []string    // 名单条目（规则 + 全局，含名单文件）
+	extras  []string    // 采集时补充的单个目标（所在条目与白名单有交集而未下发）
+	applied *iplist.Set // entries 与 extras 合并后的前缀树，用于判断目标是否已被丢弃
+}
+
+// SyncBlacklists 将各端口的黑名单（规则 + 全局）整体下发到防火墙（启动与每次热重载时调用）
+// 黑名单由配置驱动，只写入后端的黑名单集合/链，不产生封禁记录与封禁历史；
+// 首次同步总会下发（清除上次运行遗留、已不在配置中的端口），此后名单未变化时不重复下发。
+func (e *Enforcer) SyncBlacklists() error {
+	logger := utils.GetLogger()
+
+	if e.executor.Degraded() {
+		logger.Warn("防火墙后端不可用，黑名单仅在采集时断开连接")
+		return nil
+	}
+
+	// 1. 当前配置中的黑名单条目
+	e.mu.RLock()
+	wanted := make(map[int][]string, len(e.config.Rules))
+	for _, rule := range e.config.Rules {
+		entries, skipped := e.policyEngine.BlacklistEntries(rule.Port)
+		for _, entry := range skipped {
+			logger.Infof("端口 %d 黑名单条目 %s 与白名单有交集，仅在采集时断开连接", rule.Port, entry)
+		}
+		wanted[rule.Port] = entries
+	}
+	e.mu.RUnlock()
+
+	e.blMu.Lock()
+	defer e.blMu.Unlock()
+
+	// 2. 组装新名单：采集时补充的目标仍在黑名单内则保留
+	next := make(map[int]*portBlacklist, len(wanted))
+	total := 0
+	for port, entries := range wanted {
+		list := &portBlacklist{entries: entries}
+		if current, ok := e.blacklists[port]; ok {
+			for _, target := range current.extras {
+				if e.CheckBlacklist(target, port) {
+					list.extras = append(list.extras, target)
+				}
+			}
+		}
+		next[port] = list
+		total += len(entries)
+	}
+
+	if e.blacklists != nil && blacklistsEqual(e.blacklists, next) {
+		logger.Debugf("黑名单未变化，跳过同步（共 %d 条）", total)
+		return nil
+	}
+
+	// 3. 整体替换
+	if err := e.applyBlacklists(next); err != nil {
+		return fmt.Errorf("黑名单同步失败: %w", err)
+	}
+
+	logger.Infof("黑名单同步完成: %d 个端口，共 %d 条", len(next), total)
+	return nil
+}
+
+// applyBlacklists 合并各端口的名单并一次下发，成功后替换已下发状态（调用方需持有 blMu）
+func (e *Enforcer) applyBlacklists(next map[int]*portBlacklist) error {
+	lists := make(map[int][]string, len(next))
+	for port, list := range next {
+		list.applied = iplist.New()
+		for _, target := range append(slices.Clone(list.entries), list.extras...) {
+			if prefix, err := parseBanTarget(target); err == nil {
+				list.applied.Add(prefix)
+			}
+		}
+
+		// 前缀树合并后条目互不包含，nftables 区间集合不会因重叠而拒绝
+		for _, prefix := range list.applied.Prefixes() {
+			lists[port] = append(lists[port], targetString(prefix))
+		}
+	}
+
+	if err := e.executor.SetBlacklist(lists); err != nil {
+		return err
+	}
+	e.blacklists = next
+	return nil
+}
+
+// blacklistsEqual 比较两组端口黑名单的条目是否一致
+func blacklistsEqual(a, b map[int]*portBlacklist) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for port, list := range a {
+		other, ok := b[port]
+		if !ok || !slices.Equal(list.entries, other.entries) || !slices.Equal(list.extras, other.extras) {
+			return false
+		}
+	}
+	return true
+}
+
+// EnforceBlacklist 断开端口上来自黑名单地址的已建立连接（无论是否超限），
+// 并将这些会话移出追踪器，使其不再占用 max_ips 名额。返回断开的会话数。
+// 每次命中以 blacklist 原因写入封禁历史（策略 BLACKLIST，不计入超限封禁统计）。
+func (e *Enforcer) EnforceBlacklist(port int, tracker *monitor.PortTracker) int {
+	logger := utils.GetLogger()
+	killed := 0
+
+	for _, session := range tracker.GetActiveSessions() {
+		targets := e.blacklistedTargets(port, session)
+		if len(targets) == 0 {
+			continue
+		}
+
+		for _, target := range targets {
+			if err := e.executor.KillConnection(port, target); err != nil {
+				logger.Errorf("断开黑名单连接失败 %s:%d - %v", target, port, err)
+				continue
+			}
+			record := BanRecord{
+				IP:       target,
+				Port:     port,
+				BannedAt: e.cooldownMgr.now(),
+				Reason:   ReasonBlacklist,
+				Strategy: StrategyBlacklist,
+			}
+			e.cooldownMgr.RecordHistory(record)
+			e.emit(Event{Type: EventBlacklist, Record: record})
+
+			// 所在条目未下发（与白名单有交集）或同步失败时补入黑名单，阻止立即重连
+			if err := e.ensureBlacklisted(port, target); err != nil {
+				logger.Errorf("下发黑名单地址失败 %s:%d - %v", target, port, err)
+			}
+		}
+
+		// 整个会话命中黑名单时立即移出；部分地址命中的聚合会话在下个周期按剩余地址重新统计
+		if targets[0] == session.IP {
+			tracker.RemoveSession(session.IP)
+		}
+		killed++
+		logger.Warnf("已断开黑名单会话 %s（端口 %d）", session.IP, port)
+	}
+
+	return killed
+}
+
+// ensureBlacklisted 目标未被已下发的黑名单覆盖时，将其补入端口黑名单并重新下发
+// 覆盖检查为前缀树查找，开销与黑名单条目数量无关
+func (e *Enforcer) ensureBlacklisted(port int, target string) error {
+	prefix, err := parseBanTarget(target)
+	if err != nil {
+		return err
+	}
+	if e.executor.Degraded() {
+		return nil
+	}
+
+	e.blMu.Lock()
+	defer e.blMu.Unlock()
+
+	current := e.blacklists[port]
+	if current != nil && current.applied.ContainsPrefix(prefix) {
+		return nil
+	}
+
+	next := make(map[int]*portBlacklist, len(e.blacklists)+1)
+	for p, list := range e.blacklists {
+		next[p] = list
+	}
+	list := &portBlacklist{}
+	if current != nil {
+		list.entries = current.entries
+		list.extras = slices.Clone(current.extras)
+	}
+	list.extras = append(list.extras, targetString(prefix))
+	next[port] = list
+
+	return e.applyBlacklists(next)
+}
+
+// blacklistedTargets 返回会话中命中黑名单的目标（整个会话命中时返回会话 key）
+func (e *Enforcer) blacklistedTargets(port int, session *monitor.Session) []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.policyEngine.IsBlacklisted(port, session.IP) {
+		return []string{session.IP}
+	}
+
+	var targets []string
+	for _, addr := range session.Addresses {
+		if e.policyEngine.IsBlacklisted(port, addr) {
+			targets = append(targets, addr)
+		}
+	}
+	return targets
+}
@@ -0,0 +1,253 @@
+package enforcer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/nodeaccessmanager/nam/internal/config"
+	"github.com/nodeaccessmanager/nam/internal/monitor"
+)
+
+// memoryStore 内存中的 BanStore，记录写入的封禁历史
+type memoryStore struct {
+	mu      sync.Mutex
+	active  map[string]BanRecord
+	history []BanRecord
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{active: make(map[string]BanRecord)}
+}
+
+func (s *memoryStore) SaveActiveBan(record *BanRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active[banKey(record.IP, record.Port)] = *record
+	return nil
+}
+
+func (s *memoryStore) DeleteActiveBan(ip string, port int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.active, banKey(ip, port))
+	return nil
+}
+
+func (s *memoryStore) LoadActiveBans() ([]BanRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := make([]BanRecord, 0, len(s.active))
+	for _, record := range s.active {
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (s *memoryStore) RecordBan(record *BanRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = append(s.history, *record)
+	return nil
+}
+
+func (s *memoryStore) History() []BanRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]BanRecord(nil), s.history...)
+}
+
+func TestSyncBlacklists(t *testing.T) {
+	const port = 8443
+	listFile := filepath.Join(t.TempDir(), "blacklist.txt")
+	writeList := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(listFile, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	newConfig := func(ruleEntries, globalEntries []string) *config.Config {
+		cfg := testConfig(config.Rule{Port: port, MaxIPs: 10, Blacklist: ruleEntries, BlacklistFiles: []string{listFile}})
+		cfg.Global.Blacklist = globalEntries
+		return cfg
+	}
+
+	writeList("203.0.113.0/24\n")
+	backend := NewMemoryBackend()
+	e, _, recorder := newTestEnforcer(t, newConfig([]string{"198.51.100.7"}, []string{"2001:db8::/32"}), backend, NewMemoryKiller())
+	store := newMemoryStore()
+	e.SetBanStore(store)
+
+	steps := []struct {
+		name       string
+		apply      func() error
+		want       []string
+		wantPushes int // 累计的 blacklist 下发次数
+	}{
+		{
+			name:       "启动时下发规则、全局与名单文件条目",
+			apply:      e.SyncBlacklists,
+			want:       []string{"198.51.100.7", "203.0.113.0/24", "2001:db8::/32"},
+			wantPushes: 1,
+		},
+		{
+			name:       "名单未变化时不重复下发",
+			apply:      e.SyncBlacklists,
+			want:       []string{"198.51.100.7", "203.0.113.0/24", "2001:db8::/32"},
+			wantPushes: 1,
+		},
+		{
+			name: "热重载新增与移除条目",
+			apply: func() error {
+				e.Reconfigure(newConfig([]string{"198.51.100.8"}, nil))
+				return e.SyncBlacklists()
+			},
+			want:       []string{"198.51.100.8", "203.0.113.0/24"},
+			wantPushes: 2,
+		},
+		{
+			name: "名单文件变更后重新加载",
+			apply: func() error {
+				writeList("# 已清空\n192.0.2.0/25\n")
+				return e.ReloadListFiles([]string{listFile})
+			},
+			want:       []string{"192.0.2.0/25", "198.51.100.8"},
+			wantPushes: 3,
+		},
+		{
+			name: "条目移出名单后从防火墙删除",
+			apply: func() error {
+				writeList("")
+				return e.ReloadListFiles([]string{listFile})
+			},
+			want:       []string{"198.51.100.8"},
+			wantPushes: 4,
+		},
+	}
+
+	for _, step := range steps {
+		if err := step.apply(); err != nil {
+			t.Fatalf("%s: error = %v", step.name, err)
+		}
+		if got := backend.Blacklist(port); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: Blacklist() = %v, want %v", step.name, got, step.want)
+		}
+		if got := countOps(backend.Events(), "blacklist"); got != step.wantPushes {
+			t.Errorf("%s: blacklist pushes = %d, want %d", step.name, got, step.wantPushes)
+		}
+	}
+
+	// 黑名单由配置驱动，不产生封禁记录与封禁事件
+	if bans := e.GetActiveBans(); len(bans) != 0 {
+		t.Errorf("GetActiveBans() = %+v, want none", bans)
+	}
+	if got := countOps(backend.Events(), "ban"); got != 0 {
+		t.Errorf("ban events = %d, want 0", got)
+	}
+	if got := recorder.types(); len(got) != 0 {
+		t.Errorf("event types = %v, want none", got)
+	}
+	if got := store.History(); len(got) != 0 {
+		t.Errorf("history = %+v, want none", got)
+	}
+}
+
+func TestEnforceBlacklist(t *testing.T) {
+	const port = 8443
+
+	tests := []struct {
+		name          string
+		blacklist     []string
+		whitelist     []string
+		wantKilled    []string
+		wantBlacklist []string // EnforceBlacklist 之后防火墙中的黑名单
+	}{
+		{
+			name:          "已下发条目内的会话只断开",
+			blacklist:     []string{"198.51.100.0/24"},
+			wantKilled:    []string{"198.51.100.7"},
+			wantBlacklist: []string{"198.51.100.0/24"},
+		},
+		{
+			name:          "条目与白名单有交集时补入单个地址",
+			blacklist:     []string{"198.51.100.0/24"},
+			whitelist:     []string{"198.51.100.5"},
+			wantKilled:    []string{"198.51.100.7"},
+			wantBlacklist: []string{"198.51.100.7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 会话数远低于 max_ips，黑名单会话同样被断开
+			cfg := testConfig(config.Rule{Port: port, MaxIPs: 10, BanDuration: 60, Blacklist: tt.blacklist, Whitelist: tt.whitelist})
+			backend, killer := NewMemoryBackend(), NewMemoryKiller()
+			e, clk, recorder := newTestEnforcer(t, cfg, backend, killer)
+			store := newMemoryStore()
+			e.SetBanStore(store)
+			if err := e.SyncBlacklists(); err != nil {
+				t.Fatalf("SyncBlacklists() error = %v", err)
+			}
+
+			tracker := monitor.NewPortTrackerWithClock(port, clk)
+			connectInOrder(clk, tracker, port, "192.0.2.1", "198.51.100.7", "203.0.113.5")
+
+			if got := e.EnforceBlacklist(port, tracker); got != len(tt.wantKilled) {
+				t.Errorf("EnforceBlacklist() = %d, want %d", got, len(tt.wantKilled))
+			}
+
+			var wantKills []BackendEvent
+			var wantHistory []BanRecord
+			for _, ip := range tt.wantKilled {
+				wantKills = append(wantKills, BackendEvent{Op: "kill", IP: ip, Port: port})
+				wantHistory = append(wantHistory, BanRecord{
+					IP:       ip,
+					Port:     port,
+					BannedAt: clk.Now(),
+					Reason:   ReasonBlacklist,
+					Strategy: StrategyBlacklist,
+				})
+				if _, ok := tracker.GetSessionByIP(ip); ok {
+					t.Errorf("%s 应已移出追踪器", ip)
+				}
+			}
+			if got := killer.Events(); !reflect.DeepEqual(got, wantKills) {
+				t.Errorf("kill events = %+v, want %+v", got, wantKills)
+			}
+			if got := tracker.Count(); got != 3-len(tt.wantKilled) {
+				t.Errorf("tracker.Count() = %d, want %d", got, 3-len(tt.wantKilled))
+			}
+
+			if got := backend.Blacklist(port); !reflect.DeepEqual(got, tt.wantBlacklist) {
+				t.Errorf("Blacklist() = %v, want %v", got, tt.wantBlacklist)
+			}
+			if got := countOps(backend.Events(), "ban"); got != 0 {
+				t.Errorf("ban events = %d, want 0", got)
+			}
+			if bans := e.GetActiveBans(); len(bans) != 0 {
+				t.Errorf("GetActiveBans() = %+v, want none", bans)
+			}
+
+			// 命中以 blacklist 原因写入历史，但不登记为活跃封禁
+			if got := store.History(); !reflect.DeepEqual(got, wantHistory) {
+				t.Errorf("history = %+v, want %+v", got, wantHistory)
+			}
+			if got, _ := store.LoadActiveBans(); len(got) != 0 {
+				t.Errorf("active bans in store = %+v, want none", got)
+			}
+			if got, want := recorder.types(), []string{EventBlacklist}; !reflect.DeepEqual(got, want) {
+				t.Errorf("event types = %v, want %v", got, want)
+			}
+
+			// 补入的地址在下一次同步时保留
+			if err := e.SyncBlacklists(); err != nil {
+				t.Fatalf("SyncBlacklists() error = %v", err)
+			}
+			if got := backend.Blacklist(port); !reflect.DeepEqual(got, tt.wantBlacklist) {
+				t.Errorf("resync Blacklist() = %v, want %v", got, tt.wantBlacklist)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/nodeaccessmanager/nam/internal/clock"
 	"github.com/nodeaccessmanager/nam/pkg/utils"
 )
 
@@ -13,64 +14,147 @@ type CooldownManager struct {
 	records  map[string]*cooldownRecord // key: "IP:PORT"
 	mu       sync.Mutex
 	executor *Executor // 循环依赖，延迟设置
+	store    BanStore  // 可选，持久化活跃封禁
+	clock    clock.Clock
+	onEvent  func(Event) // 可选，封禁 / 解封事件回调
 }
 
 // cooldownRecord 内部冷却记录
 type cooldownRecord struct {
-	IP       string
-	Port     int
-	ExpireAt time.Time
-	Timer    *time.Timer
+	Record BanRecord
+	Timer  clock.Timer // 永久封禁时为 nil
 }
 
 // NewCooldownManager 创建冷却管理器
 func NewCooldownManager() *CooldownManager {
 	return &CooldownManager{
 		records: make(map[string]*cooldownRecord),
+		clock:   clock.Real(),
 	}
 }
 
+// SetClock 设置时间源（需在安排任何封禁之前调用）
+func (cm *CooldownManager) SetClock(clk clock.Clock) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.clock = clk
+}
+
+// now 当前时间
+func (cm *CooldownManager) now() time.Time {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.clock.Now()
+}
+
 // SetExecutor 设置执行器（解决循环依赖）
 func (cm *CooldownManager) SetExecutor(executor *Executor) {
 	cm.executor = executor
 }
 
-// Schedule 安排定时解封
-func (cm *CooldownManager) Schedule(ip string, port int, duration int) {
+// SetStore 设置封禁持久化存储
+func (cm *CooldownManager) SetStore(store BanStore) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.store = store
+}
+
+// SetEventCallback 设置封禁 / 解封事件回调（回调在持有锁时执行，不得阻塞或回调 CooldownManager）
+func (cm *CooldownManager) SetEventCallback(callback func(Event)) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.onEvent = callback
+}
+
+// emit 触发事件回调（调用方需持有锁）
+func (cm *CooldownManager) emit(event Event) {
+	if cm.onEvent != nil {
+		cm.onEvent(event)
+	}
+}
+
+// banKey 生成封禁记录的 key
+func banKey(ip string, port int) string {
+	return fmt.Sprintf("%s:%d", ip, port)
+}
+
+// Schedule 记录封禁并安排定时解封（ExpireAt 为零值表示永久封禁）
+func (cm *CooldownManager) Schedule(record BanRecord) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
 	logger := utils.GetLogger()
-	key := fmt.Sprintf("%s:%d", ip, port)
-	expireAt := time.Now().Add(time.Duration(duration) * time.Second)
+	key := banKey(record.IP, record.Port)
 
 	// 如果已存在，取消旧的定时器
-	if old, exists := cm.records[key]; exists {
+	if old, exists := cm.records[key]; exists && old.Timer != nil {
 		old.Timer.Stop()
 		logger.Debugf("取消旧的定时器: %s", key)
 	}
 
-	// 创建新的定时器
-	timer := time.AfterFunc(time.Duration(duration)*time.Second, func() {
-		cm.unban(ip, port)
-	})
+	cm.arm(record)
+	cm.emit(Event{Type: EventBan, Record: record})
+
+	// 持久化，确保重启后能恢复定时解封
+	if cm.store != nil {
+		if err := cm.store.SaveActiveBan(&record); err != nil {
+			logger.Errorf("持久化封禁记录失败 %s: %v", key, err)
+		}
+		if err := cm.store.RecordBan(&record); err != nil {
+			logger.Errorf("记录封禁历史失败 %s: %v", key, err)
+		}
+	}
 
-	cm.records[key] = &cooldownRecord{
-		IP:       ip,
-		Port:     port,
-		ExpireAt: expireAt,
-		Timer:    timer,
+	if record.ExpireAt.IsZero() {
+		logger.Debugf("记录永久封禁: %s", key)
+	} else {
+		logger.Debugf("安排定时解封: %s（%s 后）", key, record.ExpireAt.Sub(cm.clock.Now()).Round(time.Second))
 	}
+}
+
+// arm 登记封禁记录并按剩余时长启动定时器（调用方需持有锁）
+func (cm *CooldownManager) arm(record BanRecord) {
+	entry := &cooldownRecord{Record: record}
 
-	logger.Debugf("安排定时解封: %s（%ds 后）", key, duration)
+	if !record.ExpireAt.IsZero() {
+		remaining := record.ExpireAt.Sub(cm.clock.Now())
+		if remaining < 0 {
+			remaining = 0
+		}
+		entry.Timer = cm.clock.AfterFunc(remaining, func() {
+			cm.unban(entry)
+		})
+	}
+
+	cm.records[banKey(record.IP, record.Port)] = entry
+}
+
+// Restore 恢复已持久化的封禁（启动对账时调用，不重复写入存储）
+func (cm *CooldownManager) Restore(record BanRecord) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if old, exists := cm.records[banKey(record.IP, record.Port)]; exists && old.Timer != nil {
+		old.Timer.Stop()
+	}
+	cm.arm(record)
 }
 
 // unban 定时器回调，执行解封
-func (cm *CooldownManager) unban(ip string, port int) {
+func (cm *CooldownManager) unban(entry *cooldownRecord) {
 	logger := utils.GetLogger()
+	ip, port := entry.Record.IP, entry.Record.Port
 
-	// 执行解封
-	if cm.executor != nil {
+	// 期间被重新封禁（记录已替换）时由新的定时器负责
+	cm.mu.Lock()
+	current := cm.records[banKey(ip, port)]
+	cm.mu.Unlock()
+	if current != entry {
+		return
+	}
+
+	// 执行解封（内核超时的后端已自行删除，只需清理记录）
+	if cm.executor != nil && !cm.executor.NativeExpiry() {
 		if err := cm.executor.RemoveBan(ip, port); err != nil {
 			logger.Errorf("定时解封失败 %s:%d - %v", ip, port, err)
 			// 不删除记录，允许手动重试
@@ -80,26 +164,56 @@ func (cm *CooldownManager) unban(ip string, port int) {
 
 	// 从记录中移除
 	cm.mu.Lock()
-	key := fmt.Sprintf("%s:%d", ip, port)
-	delete(cm.records, key)
+	cm.forget(ip, port)
 	cm.mu.Unlock()
 
 	logger.Infof("定时解封成功: %s:%d", ip, port)
 }
 
+// RecordHistory 只写入封禁历史（不登记活跃封禁、不安排解封），如黑名单命中
+func (cm *CooldownManager) RecordHistory(record BanRecord) {
+	cm.mu.Lock()
+	store := cm.store
+	cm.mu.Unlock()
+
+	if store == nil {
+		return
+	}
+	if err := store.RecordBan(&record); err != nil {
+		utils.GetLogger().Errorf("记录封禁历史失败 %s: %v", banKey(record.IP, record.Port), err)
+	}
+}
+
+// forget 删除内存与持久化中的封禁记录（调用方需持有锁）
+func (cm *CooldownManager) forget(ip string, port int) {
+	key := banKey(ip, port)
+	if entry, ok := cm.records[key]; ok {
+		cm.emit(Event{Type: EventUnban, Record: entry.Record})
+	}
+	delete(cm.records, key)
+
+	if cm.store != nil {
+		if err := cm.store.DeleteActiveBan(ip, port); err != nil {
+			utils.GetLogger().Errorf("删除持久化封禁记录失败 %s:%d: %v", ip, port, err)
+		}
+	}
+}
+
 // Cancel 取消指定的封禁（立即解封）
 func (cm *CooldownManager) Cancel(ip string, port int) error {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
-	key := fmt.Sprintf("%s:%d", ip, port)
+	key := banKey(ip, port)
 	record, exists := cm.records[key]
 	if !exists {
 		return fmt.Errorf("未找到封禁记录: %s", key)
 	}
 
 	// 停止定时器
-	record.Timer.Stop()
+	if record.Timer != nil {
+		record.Timer.Stop()
+	}
 
 	// 执行解封
 	if cm.executor != nil {
@@ -109,7 +223,7 @@ func (cm *CooldownManager) Cancel(ip string, port int) error {
 	}
 
 	// 删除记录
-	delete(cm.records, key)
+	cm.forget(ip, port)
 
 	utils.GetLogger().Infof("手动解封成功: %s", key)
 	return nil
@@ -121,23 +235,8 @@ func (cm *CooldownManager) GetActiveRecords() []BanRecord {
 	defer cm.mu.Unlock()
 
 	records := make([]BanRecord, 0, len(cm.records))
-	now := time.Now()
-
 	for _, record := range cm.records {
-		duration := int(record.ExpireAt.Sub(now).Seconds())
-		if duration < 0 {
-			duration = 0
-		}
-
-		records = append(records, BanRecord{
-			IP:       record.IP,
-			Port:     record.Port,
-			BannedAt: record.ExpireAt.Add(-time.Duration(duration) * time.Second),
-			ExpireAt: record.ExpireAt,
-			Duration: duration,
-			Reason:   "Overlimit",
-			Strategy: "AUTO",
-		})
+		records = append(records, record.Record)
 	}
 
 	return records
@@ -150,7 +249,7 @@ func (cm *CooldownManager) Count() int {
 	return len(cm.records)
 }
 
-// Clear 清空所有定时器（程序退出时调用）
+// Clear 清空所有定时器（程序退出时调用，持久化记录保留供下次启动恢复）
 func (cm *CooldownManager) Clear() {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
@@ -159,18 +258,31 @@ func (cm *CooldownManager) Clear() {
 	logger.Info("清空冷却管理器")
 
 	for key, record := range cm.records {
-		record.Timer.Stop()
+		if record.Timer != nil {
+			record.Timer.Stop()
+		}
 		delete(cm.records, key)
 	}
 }
 
+// GetRecord 获取指定目标的封禁记录
+func (cm *CooldownManager) GetRecord(ip string, port int) (BanRecord, bool) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	record, exists := cm.records[banKey(ip, port)]
+	if !exists {
+		return BanRecord{}, false
+	}
+	return record.Record, true
+}
+
 // IsActive 检查指定 IP 是否正在被封禁
 func (cm *CooldownManager) IsActive(ip string, port int) bool {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
-	key := fmt.Sprintf("%s:%d", ip, port)
-	_, exists := cm.records[key]
+	_, exists := cm.records[banKey(ip, port)]
 	return exists
 }
 
@@ -179,11 +291,10 @@ func (cm *CooldownManager) GetExpireTime(ip string, port int) (time.Time, bool)
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
-	key := fmt.Sprintf("%s:%d", ip, port)
-	record, exists := cm.records[key]
+	record, exists := cm.records[banKey(ip, port)]
 	if !exists {
 		return time.Time{}, false
 	}
 
-	return record.ExpireAt, true
+	return record.Record.ExpireAt, true
 }
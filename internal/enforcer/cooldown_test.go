@@ -0,0 +1,140 @@
+package enforcer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/clock"
+)
+
+// newTestCooldown 使用内存后端与假时钟创建冷却管理器及其执行器
+func newTestCooldown() (*CooldownManager, *Executor, *MemoryBackend, *clock.Fake) {
+	clk := clock.NewFake(testStart)
+	backend := NewMemoryBackend()
+
+	cm := NewCooldownManager()
+	cm.SetClock(clk)
+	executor := NewExecutor(cm, backend, NewMemoryKiller())
+	cm.SetExecutor(executor)
+	return cm, executor, backend, clk
+}
+
+// countOps 统计指定类型的操作记录
+func countOps(events []BackendEvent, op string) int {
+	n := 0
+	for _, event := range events {
+		if event.Op == op {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCooldownExpiry(t *testing.T) {
+	type step struct {
+		advance    time.Duration
+		reban      bool // 推进后以相同时长重新封禁
+		wantActive bool
+	}
+
+	tests := []struct {
+		name       string
+		duration   int
+		failUnban  bool
+		steps      []step
+		wantUnbans int
+	}{
+		{
+			name:     "到期解封",
+			duration: 60,
+			steps: []step{
+				{advance: 59 * time.Second, wantActive: true},
+				{advance: time.Second, wantActive: false},
+			},
+			wantUnbans: 1,
+		},
+		{
+			name:     "永久封禁不到期",
+			duration: 0,
+			steps: []step{
+				{advance: 365 * 24 * time.Hour, wantActive: true},
+			},
+		},
+		{
+			name:     "重新封禁刷新期限",
+			duration: 60,
+			steps: []step{
+				{advance: 30 * time.Second, reban: true, wantActive: true},
+				{advance: 30 * time.Second, wantActive: true},
+				{advance: 29 * time.Second, wantActive: true},
+				{advance: time.Second, wantActive: false},
+			},
+			wantUnbans: 1,
+		},
+		{
+			name:      "解封失败保留记录",
+			duration:  60,
+			failUnban: true,
+			steps: []step{
+				{advance: 60 * time.Second, wantActive: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const ip, port = "192.0.2.1", 8443
+			cm, executor, backend, clk := newTestCooldown()
+
+			if err := executor.ApplyBan(ip, port, tt.duration, "test", "FIFO"); err != nil {
+				t.Fatalf("ApplyBan() error = %v", err)
+			}
+			if tt.failUnban {
+				backend.SetError(errors.New("backend down"))
+			}
+
+			for i, s := range tt.steps {
+				clk.Advance(s.advance)
+				if s.reban {
+					if err := executor.ApplyBan(ip, port, tt.duration, "test", "FIFO"); err != nil {
+						t.Fatalf("step %d: ApplyBan() error = %v", i, err)
+					}
+				}
+				if got := cm.IsActive(ip, port); got != s.wantActive {
+					t.Errorf("step %d（%s）: IsActive() = %v, want %v", i, clk.Now().Sub(testStart), got, s.wantActive)
+				}
+			}
+
+			if got := countOps(backend.Events(), "unban"); got != tt.wantUnbans {
+				t.Errorf("unban events = %d, want %d", got, tt.wantUnbans)
+			}
+			if got := countOps(backend.Events(), "ban"); got != 1 {
+				t.Errorf("ban events = %d, want 1（重复封禁只刷新期限）", got)
+			}
+		})
+	}
+}
+
+func TestCooldownCancelStopsTimer(t *testing.T) {
+	const ip, port = "192.0.2.1", 8443
+	cm, executor, backend, clk := newTestCooldown()
+
+	if err := executor.ApplyBan(ip, port, 60, "test", "FIFO"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cm.Cancel(ip, port); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if clk.Pending() != 0 {
+		t.Errorf("Cancel() 后仍有 %d 个定时器", clk.Pending())
+	}
+
+	clk.Advance(time.Hour)
+	if got := countOps(backend.Events(), "unban"); got != 1 {
+		t.Errorf("unban events = %d, want 1", got)
+	}
+	if err := cm.Cancel(ip, port); err == nil {
+		t.Error("重复 Cancel() 应返回错误")
+	}
+}
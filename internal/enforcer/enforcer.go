@@ -1,8 +1,12 @@
 package enforcer
 
 import (
+	"fmt"
+	"math"
+	"sync"
 	"time"
 
+	"github.com/nodeaccessmanager/nam/internal/clock"
 	"github.com/nodeaccessmanager/nam/internal/config"
 	"github.com/nodeaccessmanager/nam/internal/monitor"
 	"github.com/nodeaccessmanager/nam/pkg/utils"
@@ -10,36 +14,66 @@ import (
 
 // Enforcer 策略执行器主控制器
 type Enforcer struct {
-	config      *config.Config
+	config       *config.Config
 	policyEngine *PolicyEngine
-	executor    *Executor
-	cooldownMgr *CooldownManager
+	executor     *Executor
+	cooldownMgr  *CooldownManager
+	allows       *TempAllowList
+	onEvent      func(Event) // 可选，执行事件回调
+
+	// mu 保护 config 与 policyEngine 的配置，保证热重载对策略判断是原子的
+	mu sync.RWMutex
+
+	// blacklists 已下发到防火墙的黑名单（port -> 名单，首次同步前为 nil），blMu 同时串行化黑名单同步
+	blacklists map[int]*portBlacklist
+	blMu       sync.Mutex
+}
+
+// NewEnforcer 创建执行器实例（使用 ss -K 断开连接）
+func NewEnforcer(cfg *config.Config, backend BanBackend) *Enforcer {
+	return NewEnforcerWithBackends(cfg, backend, NewSSKiller())
 }
 
-// NewEnforcer 创建执行器实例
-func NewEnforcer(cfg *config.Config) *Enforcer {
+// NewEnforcerWithBackends 使用指定的封禁后端与连接断开实现创建执行器
+// 测试中可传入 MemoryBackend / MemoryKiller，无需 root 权限即可走完整个驱逐流程
+func NewEnforcerWithBackends(cfg *config.Config, backend BanBackend, killer ConnectionKiller) *Enforcer {
 	cooldownMgr := NewCooldownManager()
-	executor := NewExecutor(cooldownMgr)
+	executor := NewExecutor(cooldownMgr, backend, killer)
 	cooldownMgr.SetExecutor(executor) // 解决循环依赖
 
+	allows := NewTempAllowList()
+	policyEngine := NewPolicyEngine(cfg)
+	policyEngine.allows = allows
+
 	return &Enforcer{
 		config:       cfg,
-		policyEngine: NewPolicyEngine(cfg),
+		policyEngine: policyEngine,
 		executor:     executor,
 		cooldownMgr:  cooldownMgr,
+		allows:       allows,
 	}
 }
 
 // Enforce 执行策略（当端口超限时调用）
-func (e *Enforcer) Enforce(port int, tracker *monitor.PortTracker, rule *config.Rule) {
+// 规则、策略、白名单与封禁时长均取自当前生效的配置
+func (e *Enforcer) Enforce(port int, tracker *monitor.PortTracker) {
 	logger := utils.GetLogger()
 
-	// 1. 获取当前会话
+	// 1. 基于同一份配置做出驱逐决策（持有读锁，避免与热重载交错）
+	e.mu.RLock()
+	rule := e.config.GetRuleByPort(port)
+	if rule == nil {
+		e.mu.RUnlock()
+		logger.Warnf("端口 %d 已不在当前配置中，跳过执行", port)
+		return
+	}
+
 	sessions := tracker.GetActiveSessions()
 	currentCount := len(sessions)
 
 	if currentCount <= rule.MaxIPs {
 		// 未超限，无需处理
+		e.mu.RUnlock()
 		return
 	}
 
@@ -49,6 +83,8 @@ func (e *Enforcer) Enforce(port int, tracker *monitor.PortTracker, rule *config.
 
 	// 2. 选择驱逐对象
 	selection := e.policyEngine.SelectVictims(port, sessions, overlimit)
+	banDuration := rule.GetEffectiveBanDuration(e.config.Global.BanDuration)
+	e.mu.RUnlock()
 
 	if len(selection.Victims) == 0 {
 		logger.Warn("未选出驱逐对象（可能都在白名单）")
@@ -58,26 +94,42 @@ func (e *Enforcer) Enforce(port int, tracker *monitor.PortTracker, rule *config.
 	logger.Infof("选出 %d 个驱逐对象（策略: %s）", len(selection.Victims), selection.Strategy)
 
 	// 3. 执行驱逐
-	banDuration := rule.GetEffectiveBanDuration(e.config.Global.BanDuration)
 	reason := "Overlimit"
 
-	if err := e.executor.EnforceVictims(port, selection.Victims, banDuration, reason); err != nil {
+	if err := e.executor.EnforceVictims(port, selection.Victims, banDuration, reason, selection.Strategy); err != nil {
 		logger.Errorf("驱逐执行失败: %v", err)
 	}
 }
 
+// Reconfigure 热重载：原子地切换执行器与策略引擎使用的配置
+// 调用方需保证 newConfig 已通过验证
+func (e *Enforcer) Reconfigure(newConfig *config.Config) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.config = newConfig
+	e.policyEngine.SetConfig(newConfig)
+
+	utils.GetLogger().Info("Enforcer 已切换到新配置")
+}
+
 // ManualBan 手动封禁 IP
 func (e *Enforcer) ManualBan(ip string, port int, duration int, reason string) error {
 	logger := utils.GetLogger()
 	logger.Infof("手动封禁: %s:%d（时长 %ds，原因: %s）", ip, port, duration, reason)
 
+	ip, err := NormalizeIP(ip)
+	if err != nil {
+		return err
+	}
+
 	// 1. 断开连接
 	if err := e.executor.KillConnection(port, ip); err != nil {
 		logger.Warnf("断开连接失败（可能未连接）: %v", err)
 	}
 
 	// 2. 应用封禁
-	if err := e.executor.ApplyBan(ip, port, duration); err != nil {
+	if err := e.executor.ApplyBan(ip, port, duration, reason, StrategyManual); err != nil {
 		return err
 	}
 
@@ -89,6 +141,11 @@ func (e *Enforcer) ManualUnban(ip string, port int) error {
 	logger := utils.GetLogger()
 	logger.Infof("手动解封: %s:%d", ip, port)
 
+	ip, err := NormalizeIP(ip)
+	if err != nil {
+		return err
+	}
+
 	// 取消定时器并解封
 	if err := e.cooldownMgr.Cancel(ip, port); err != nil {
 		// 可能不在定时器中，直接尝试解封
@@ -98,6 +155,209 @@ func (e *Enforcer) ManualUnban(ip string, port int) error {
 	return nil
 }
 
+// Kick 手动断开连接（不封禁，客户端可立即重连）
+func (e *Enforcer) Kick(ip string, port int) error {
+	utils.GetLogger().Infof("手动断开: %s:%d", ip, port)
+
+	ip, err := NormalizeIP(ip)
+	if err != nil {
+		return err
+	}
+	if err := e.executor.KillConnection(port, ip); err != nil {
+		return err
+	}
+
+	e.emit(Event{Type: EventKick, Record: BanRecord{IP: ip, Port: port, Reason: "manual"}})
+	return nil
+}
+
+// TempAllow 将 IP 加入端口的临时白名单，duration 秒后自动失效；已被封禁时同时解封
+func (e *Enforcer) TempAllow(ip string, port int, duration int, reason string) error {
+	logger := utils.GetLogger()
+	logger.Infof("临时白名单: %s:%d（时长 %ds，原因: %s）", ip, port, duration, reason)
+
+	if duration <= 0 {
+		return fmt.Errorf("临时白名单时长必须大于 0")
+	}
+	ip, err := NormalizeIP(ip)
+	if err != nil {
+		return err
+	}
+
+	now := e.cooldownMgr.now()
+	record := AllowRecord{
+		IP:       ip,
+		Port:     port,
+		AddedAt:  now,
+		ExpireAt: now.Add(time.Duration(duration) * time.Second),
+		Reason:   reason,
+	}
+	if err := e.allows.Add(record); err != nil {
+		return err
+	}
+
+	if e.cooldownMgr.IsActive(ip, port) {
+		if err := e.ManualUnban(ip, port); err != nil {
+			logger.Errorf("解除 %s:%d 的封禁失败: %v", ip, port, err)
+		}
+	}
+
+	e.emit(Event{Type: EventAllow, Record: BanRecord{
+		IP:       ip,
+		Port:     port,
+		BannedAt: record.AddedAt,
+		ExpireAt: record.ExpireAt,
+		Duration: duration,
+		Reason:   reason,
+	}})
+	return nil
+}
+
+// GetTempAllows 获取临时白名单
+func (e *Enforcer) GetTempAllows() []AllowRecord {
+	return e.allows.Records()
+}
+
+// SetAllowStore 设置临时白名单持久化存储
+func (e *Enforcer) SetAllowStore(store AllowStore) {
+	e.allows.SetStore(store)
+}
+
+// RestoreTempAllows 启动时恢复未到期的临时白名单
+func (e *Enforcer) RestoreTempAllows() (int, error) {
+	return e.allows.Restore()
+}
+
+// Setup 准备防火墙环境（创建 NAM 专用的链/表），需在 Reconcile 之前调用
+// 返回错误时已降级为仅断开连接模式，驱逐仍然生效
+func (e *Enforcer) Setup() error {
+	return e.executor.Setup()
+}
+
+// BackendName 当前使用的防火墙后端名称
+func (e *Enforcer) BackendName() string {
+	return e.executor.BackendName()
+}
+
+// SetClock 设置时间源（需在 Setup/Reconcile 之前调用）
+func (e *Enforcer) SetClock(clk clock.Clock) {
+	e.cooldownMgr.SetClock(clk)
+	e.allows.SetClock(clk)
+}
+
+// SetEventCallback 设置执行事件回调（封禁、解封、断开黑名单连接、手动断开、临时白名单），需在 Setup 之前调用
+// 回调在执行路径上同步调用，必须立即返回（如只做入队）
+func (e *Enforcer) SetEventCallback(callback func(Event)) {
+	e.onEvent = callback
+	e.cooldownMgr.SetEventCallback(callback)
+}
+
+// emit 触发执行事件回调
+func (e *Enforcer) emit(event Event) {
+	if e.onEvent != nil {
+		e.onEvent(event)
+	}
+}
+
+// SetBanStore 设置封禁持久化存储
+func (e *Enforcer) SetBanStore(store BanStore) {
+	e.cooldownMgr.SetStore(store)
+}
+
+// Reconcile 启动时对账：将持久化的封禁与内核中实际存在的 NAM 规则比对
+//   - 未过期的封禁：恢复定时器（内核中缺失时重新下发规则）
+//   - 已过期的封禁：立即解除
+//   - 内核中存在但没有记录的 NAM 规则：视为孤儿规则删除
+func (e *Enforcer) Reconcile(store BanStore) (*ReconcileReport, error) {
+	logger := utils.GetLogger()
+	report := &ReconcileReport{}
+
+	// 降级模式下无法下发封禁，保留持久化记录等待后端恢复
+	if e.executor.Degraded() {
+		logger.Warn("防火墙后端不可用，跳过封禁对账")
+		return report, nil
+	}
+
+	records, err := store.LoadActiveBans()
+	if err != nil {
+		return nil, fmt.Errorf("读取持久化封禁失败: %w", err)
+	}
+
+	rules, err := e.executor.ListBans()
+	if err != nil {
+		return nil, fmt.Errorf("读取内核封禁规则失败: %w", err)
+	}
+
+	inKernel := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		inKernel[banKey(rule.IP, rule.Port)] = true
+	}
+
+	now := e.cooldownMgr.now()
+	known := make(map[string]bool, len(records))
+
+	for _, record := range records {
+		key := banKey(record.IP, record.Port)
+		known[key] = true
+
+		// 已过期：立即解除
+		if !record.ExpireAt.IsZero() && !record.ExpireAt.After(now) {
+			if inKernel[key] {
+				if err := e.executor.RemoveBan(record.IP, record.Port); err != nil {
+					logger.Errorf("解除过期封禁失败 %s: %v", key, err)
+					continue
+				}
+			}
+			if err := store.DeleteActiveBan(record.IP, record.Port); err != nil {
+				logger.Errorf("删除过期封禁记录失败 %s: %v", key, err)
+			}
+			report.Expired++
+			continue
+		}
+
+		// 未过期但规则丢失（如系统重启清空了防火墙）：按剩余时长重新下发
+		if !inKernel[key] {
+			if err := e.executor.backend.Ban(record.IP, record.Port, remainingSeconds(record, now)); err != nil {
+				logger.Errorf("重新下发封禁失败 %s: %v", key, err)
+				continue
+			}
+			report.Reapplied++
+		}
+
+		e.cooldownMgr.Restore(record)
+		report.Restored++
+	}
+
+	// 孤儿规则：内核中存在但没有任何记录
+	for _, rule := range rules {
+		key := banKey(rule.IP, rule.Port)
+		if known[key] {
+			continue
+		}
+		if err := e.executor.RemoveBan(rule.IP, rule.Port); err != nil {
+			logger.Errorf("删除孤儿规则失败 %s: %v", key, err)
+			continue
+		}
+		report.Orphaned++
+	}
+
+	logger.Infof("封禁对账完成: 恢复 %d（重新下发 %d），过期解除 %d，孤儿规则 %d",
+		report.Restored, report.Reapplied, report.Expired, report.Orphaned)
+	return report, nil
+}
+
+// remainingSeconds 封禁剩余秒数（向上取整，永久封禁返回 0）
+func remainingSeconds(record BanRecord, now time.Time) int {
+	if record.ExpireAt.IsZero() {
+		return 0
+	}
+	remaining := int(math.Ceil(record.ExpireAt.Sub(now).Seconds()))
+	if remaining < 1 {
+		remaining = 1
+	}
+	return remaining
+}
+
 // GetActiveBans 获取活跃的封禁列表
 func (e *Enforcer) GetActiveBans() []BanRecord {
 	return e.cooldownMgr.GetActiveRecords()
@@ -115,6 +375,8 @@ func (e *Enforcer) GetBanExpireTime(ip string, port int) (time.Time, bool) {
 
 // CheckBlacklist 检查 IP 是否在黑名单
 func (e *Enforcer) CheckBlacklist(ip string, port int) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	return e.policyEngine.IsBlacklisted(port, ip)
 }
 
@@ -125,6 +387,7 @@ func (e *Enforcer) Shutdown() {
 
 	// 清空定时器（不解封，保留封禁状态）
 	e.cooldownMgr.Clear()
+	e.allows.Clear()
 
 	logger.Info("Enforcer 已关闭")
 }
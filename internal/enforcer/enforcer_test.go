@@ -0,0 +1,278 @@
+package enforcer
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/clock"
+	"github.com/nodeaccessmanager/nam/internal/config"
+	"github.com/nodeaccessmanager/nam/internal/monitor"
+)
+
+var testStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// testConfig 单端口规则的配置
+func testConfig(rule config.Rule) *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Rules = []config.Rule{rule}
+	return cfg
+}
+
+// eventRecorder 收集 Enforcer 的执行事件
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *eventRecorder) record(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *eventRecorder) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var types []string
+	for _, event := range r.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+// connectInOrder 依次让 ips 连上端口，相邻两个间隔 1 秒（FirstSeenAt 递增）
+func connectInOrder(clk *clock.Fake, tracker *monitor.PortTracker, port int, ips ...string) {
+	var conns []monitor.Connection
+	for _, ip := range ips {
+		conns = append(conns, monitor.Connection{RemoteAddr: ip, LocalPort: port})
+		tracker.Update(conns)
+		clk.Advance(time.Second)
+	}
+}
+
+// newTestEnforcer 使用内存后端与假时钟创建 Enforcer
+func newTestEnforcer(t *testing.T, cfg *config.Config, backend BanBackend, killer ConnectionKiller) (*Enforcer, *clock.Fake, *eventRecorder) {
+	t.Helper()
+	clk := clock.NewFake(testStart)
+	recorder := &eventRecorder{}
+
+	e := NewEnforcerWithBackends(cfg, backend, killer)
+	e.SetClock(clk)
+	e.SetEventCallback(recorder.record)
+	if err := e.Setup(); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	return e, clk, recorder
+}
+
+func TestEnforceEvictionFlow(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy config.Strategy
+		victims  []string
+	}{
+		{name: "FIFO 驱逐最早的会话", strategy: config.StrategyFIFO, victims: []string{"192.0.2.1", "192.0.2.2"}},
+		{name: "LIFO 驱逐最新的会话", strategy: config.StrategyLIFO, victims: []string{"192.0.2.4", "192.0.2.3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const port, banDuration = 8443, 60
+			cfg := testConfig(config.Rule{Port: port, MaxIPs: 2, Strategy: tt.strategy, BanDuration: banDuration})
+			backend, killer := NewMemoryBackend(), NewMemoryKiller()
+			e, clk, recorder := newTestEnforcer(t, cfg, backend, killer)
+
+			tracker := monitor.NewPortTrackerWithClock(port, clk)
+			connectInOrder(clk, tracker, port, "192.0.2.1", "192.0.2.2", "192.0.2.3", "192.0.2.4")
+
+			e.Enforce(port, tracker)
+
+			var wantKills, wantBans []BackendEvent
+			for _, ip := range tt.victims {
+				wantKills = append(wantKills, BackendEvent{Op: "kill", IP: ip, Port: port})
+				wantBans = append(wantBans, BackendEvent{Op: "ban", IP: ip, Port: port, Duration: banDuration})
+			}
+			if got := killer.Events(); !reflect.DeepEqual(got, wantKills) {
+				t.Errorf("kill events = %+v, want %+v", got, wantKills)
+			}
+			if got := backend.Events(); !reflect.DeepEqual(got, wantBans) {
+				t.Errorf("backend events = %+v, want %+v", got, wantBans)
+			}
+			for _, ip := range tt.victims {
+				if !e.IsBanned(ip, port) || !backend.IsBanned(ip, port) {
+					t.Errorf("%s 应处于封禁中", ip)
+				}
+				if expireAt, ok := e.GetBanExpireTime(ip, port); !ok || !expireAt.Equal(clk.Now().Add(banDuration*time.Second)) {
+					t.Errorf("%s 到期时间 = %v, %v", ip, expireAt, ok)
+				}
+			}
+
+			// 到期前不解封
+			clk.Advance(banDuration*time.Second - time.Second)
+			if got := len(e.GetActiveBans()); got != len(tt.victims) {
+				t.Fatalf("到期前活跃封禁 = %d, want %d", got, len(tt.victims))
+			}
+
+			// 到期后由冷却管理器解封
+			clk.Advance(time.Second)
+			wantUnbans := append([]BackendEvent(nil), wantBans...)
+			for _, ip := range tt.victims {
+				wantUnbans = append(wantUnbans, BackendEvent{Op: "unban", IP: ip, Port: port})
+			}
+			got := backend.Events()
+			if len(got) != len(wantUnbans) {
+				t.Fatalf("backend events = %+v, want %+v", got, wantUnbans)
+			}
+			// 同一时刻到期的定时器之间顺序不作保证
+			if !sameEvents(got[len(wantBans):], wantUnbans[len(wantBans):]) {
+				t.Errorf("unban events = %+v, want %+v", got[len(wantBans):], wantUnbans[len(wantBans):])
+			}
+			for _, ip := range tt.victims {
+				if e.IsBanned(ip, port) || backend.IsBanned(ip, port) {
+					t.Errorf("%s 到期后仍处于封禁中", ip)
+				}
+			}
+
+			wantTypes := []string{EventBan, EventBan, EventUnban, EventUnban}
+			if got := recorder.types(); !reflect.DeepEqual(got, wantTypes) {
+				t.Errorf("event types = %v, want %v", got, wantTypes)
+			}
+		})
+	}
+}
+
+func TestEnforceWithinLimit(t *testing.T) {
+	const port = 8443
+	cfg := testConfig(config.Rule{Port: port, MaxIPs: 2, BanDuration: 60})
+	backend, killer := NewMemoryBackend(), NewMemoryKiller()
+	e, clk, _ := newTestEnforcer(t, cfg, backend, killer)
+
+	tracker := monitor.NewPortTrackerWithClock(port, clk)
+	connectInOrder(clk, tracker, port, "192.0.2.1", "192.0.2.2")
+	e.Enforce(port, tracker)
+
+	if got := killer.Events(); len(got) != 0 {
+		t.Errorf("未超限时不应断开连接: %+v", got)
+	}
+	if got := backend.Events(); len(got) != 0 {
+		t.Errorf("未超限时不应封禁: %+v", got)
+	}
+}
+
+func TestEnforceKillOnly(t *testing.T) {
+	// ban_duration 为 0：只断开连接，不封禁
+	const port = 8443
+	cfg := testConfig(config.Rule{Port: port, MaxIPs: 1})
+	cfg.Global.BanDuration = 0
+	backend, killer := NewMemoryBackend(), NewMemoryKiller()
+	e, clk, recorder := newTestEnforcer(t, cfg, backend, killer)
+
+	tracker := monitor.NewPortTrackerWithClock(port, clk)
+	connectInOrder(clk, tracker, port, "192.0.2.1", "192.0.2.2")
+	e.Enforce(port, tracker)
+
+	want := []BackendEvent{{Op: "kill", IP: "192.0.2.1", Port: port}}
+	if got := killer.Events(); !reflect.DeepEqual(got, want) {
+		t.Errorf("kill events = %+v, want %+v", got, want)
+	}
+	if got := backend.Events(); len(got) != 0 {
+		t.Errorf("不应下发封禁: %+v", got)
+	}
+	if got := recorder.types(); len(got) != 0 {
+		t.Errorf("不应产生封禁事件: %v", got)
+	}
+}
+
+func TestEnforceDegraded(t *testing.T) {
+	// 后端不可用时仍断开超限连接，但不登记封禁
+	const port = 8443
+	cfg := testConfig(config.Rule{Port: port, MaxIPs: 1, BanDuration: 60, Blacklist: []string{"198.51.100.0/24"}})
+	killer := NewMemoryKiller()
+	e, clk, recorder := newTestEnforcer(t, cfg, NewNullBackend(errors.New("iptables not found")), killer)
+
+	if e.BackendName() != "none" {
+		t.Fatalf("BackendName() = %q, want none", e.BackendName())
+	}
+	if err := e.SyncBlacklists(); err != nil {
+		t.Errorf("降级模式下 SyncBlacklists() error = %v", err)
+	}
+
+	tracker := monitor.NewPortTrackerWithClock(port, clk)
+	connectInOrder(clk, tracker, port, "192.0.2.1", "192.0.2.2", "192.0.2.3")
+	e.Enforce(port, tracker)
+
+	want := []BackendEvent{
+		{Op: "kill", IP: "192.0.2.1", Port: port},
+		{Op: "kill", IP: "192.0.2.2", Port: port},
+	}
+	if got := killer.Events(); !reflect.DeepEqual(got, want) {
+		t.Errorf("kill events = %+v, want %+v", got, want)
+	}
+	if bans := e.GetActiveBans(); len(bans) != 0 {
+		t.Errorf("降级模式下不应登记封禁: %+v", bans)
+	}
+	if e.IsBanned("192.0.2.1", port) {
+		t.Error("降级模式下 IsBanned() = true")
+	}
+	if got := recorder.types(); len(got) != 0 {
+		t.Errorf("降级模式下不应产生封禁事件: %v", got)
+	}
+	if err := e.ManualBan("192.0.2.9", port, 60, "manual"); !errors.Is(err, ErrBackendUnavailable) {
+		t.Errorf("ManualBan() error = %v, want ErrBackendUnavailable", err)
+	}
+}
+
+// sameEvents 忽略顺序比较两组操作记录
+func sameEvents(got, want []BackendEvent) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	counts := make(map[BackendEvent]int)
+	for _, event := range want {
+		counts[event]++
+	}
+	for _, event := range got {
+		if counts[event] == 0 {
+			return false
+		}
+		counts[event]--
+	}
+	return true
+}
+
+func TestEnforcePrefixAggregation(t *testing.T) {
+	// 同一 /64 内的多个地址计为一个会话，驱逐与封禁的目标都是整个前缀
+	const port = 8443
+	rule := config.Rule{Port: port, MaxIPs: 1, BanDuration: 60, IPv4PrefixLen: 24, IPv6PrefixLen: 64}
+	backend, killer := NewMemoryBackend(), NewMemoryKiller()
+	e, clk, _ := newTestEnforcer(t, testConfig(rule), backend, killer)
+
+	tracker := monitor.NewPortTrackerWithClock(port, clk)
+	tracker.SetPrefixLen(rule.IPv4PrefixLen, rule.IPv6PrefixLen)
+
+	// 两个隐私扩展地址属于同一前缀，未超限
+	connectInOrder(clk, tracker, port, "2001:db8::1", "2001:db8::2")
+	e.Enforce(port, tracker)
+	if got := killer.Events(); len(got) != 0 {
+		t.Fatalf("同一前缀的地址不应超限: %+v", got)
+	}
+
+	// 第二个前缀到达后超限，FIFO 驱逐最早的前缀
+	connectInOrder(clk, tracker, port, "2001:db8::1", "2001:db8::2", "2001:db8:0:1::1")
+	e.Enforce(port, tracker)
+
+	wantKills := []BackendEvent{{Op: "kill", IP: "2001:db8::/64", Port: port}}
+	if got := killer.Events(); !reflect.DeepEqual(got, wantKills) {
+		t.Errorf("kill events = %+v, want %+v", got, wantKills)
+	}
+	wantBans := []BackendEvent{{Op: "ban", IP: "2001:db8::/64", Port: port, Duration: 60}}
+	if got := backend.Events(); !reflect.DeepEqual(got, wantBans) {
+		t.Errorf("backend events = %+v, want %+v", got, wantBans)
+	}
+	if !e.IsBanned("2001:db8::/64", port) {
+		t.Error("前缀应处于封禁中")
+	}
+}
@@ -3,91 +3,134 @@ package enforcer
 import (
 	"fmt"
 	"os/exec"
+	"time"
 
 	"github.com/nodeaccessmanager/nam/pkg/utils"
 )
 
-// Executor 执行器
+// Executor 执行器：通过 ConnectionKiller 断开连接，通过 BanBackend 下发封禁
 type Executor struct {
 	cooldownMgr *CooldownManager
+	backend     BanBackend
+	killer      ConnectionKiller
 }
 
 // NewExecutor 创建执行器
-func NewExecutor(cooldownMgr *CooldownManager) *Executor {
+func NewExecutor(cooldownMgr *CooldownManager, backend BanBackend, killer ConnectionKiller) *Executor {
 	return &Executor{
 		cooldownMgr: cooldownMgr,
+		backend:     backend,
+		killer:      killer,
 	}
 }
 
-// KillConnection 使用 ss -K 强制断开连接
+// KillConnection 强制断开连接（IPv4 / IPv6 地址或聚合前缀均可）
 func (e *Executor) KillConnection(port int, ip string) error {
-	logger := utils.GetLogger()
-
-	// 执行命令: ss -K dst <IP> sport = :<PORT>
-	cmd := exec.Command("ss", "-K", "dst", ip, "sport", "=", fmt.Sprintf(":%d", port))
-	output, err := cmd.CombinedOutput()
-
+	ip, err := NormalizeIP(ip)
 	if err != nil {
-		logger.Debugf("ss -K 输出: %s", string(output))
-		return fmt.Errorf("ss -K 执行失败: %w", err)
+		return err
+	}
+
+	if err := e.killer.Kill(port, ip); err != nil {
+		return err
 	}
 
-	logger.Infof("已断开 %s:%d 的连接", ip, port)
+	utils.GetLogger().Infof("已断开 %s:%d 的连接", ip, port)
 	return nil
 }
 
-// ApplyBan 应用 iptables 封禁
-func (e *Executor) ApplyBan(ip string, port int, duration int) error {
+// ApplyBan 下发防火墙封禁并登记到冷却管理器（duration 为 0 表示永久封禁）
+func (e *Executor) ApplyBan(ip string, port int, duration int, reason, strategy string) error {
 	logger := utils.GetLogger()
 
-	// 执行命令: iptables -I INPUT -s <IP> -p tcp --dport <PORT> -j DROP
-	cmd := exec.Command("iptables", "-I", "INPUT",
-		"-s", ip,
-		"-p", "tcp",
-		"--dport", fmt.Sprintf("%d", port),
-		"-m", "comment", "--comment", "NAM-BAN",
-		"-j", "DROP")
-
-	output, err := cmd.CombinedOutput()
+	// 统一地址写法，保证冷却记录与内核列表一致
+	ip, err := NormalizeIP(ip)
 	if err != nil {
-		logger.Debugf("iptables 输出: %s", string(output))
-		return fmt.Errorf("iptables 封禁失败: %w", err)
+		return err
+	}
+
+	// 已封禁时只刷新期限，避免插入重复规则（内核超时的后端需要重新下发以刷新超时）
+	if e.backend.NativeExpiry() || !e.cooldownMgr.IsActive(ip, port) {
+		if err := e.backend.Ban(ip, port, duration); err != nil {
+			return err
+		}
 	}
 
 	logger.Infof("已封禁 %s:%d（时长 %ds）", ip, port, duration)
 
-	// 启动定时器自动解封
+	// 登记封禁，到期自动解封
+	now := e.cooldownMgr.now()
+	record := BanRecord{
+		IP:       ip,
+		Port:     port,
+		BannedAt: now,
+		Duration: duration,
+		Reason:   reason,
+		Strategy: strategy,
+	}
 	if duration > 0 {
-		e.cooldownMgr.Schedule(ip, port, duration)
+		record.ExpireAt = now.Add(time.Duration(duration) * time.Second)
 	}
+	e.cooldownMgr.Schedule(record)
 
 	return nil
 }
 
-// RemoveBan 移除 iptables 封禁
+// RemoveBan 移除防火墙封禁
 func (e *Executor) RemoveBan(ip string, port int) error {
-	logger := utils.GetLogger()
-
-	// 执行命令: iptables -D INPUT -s <IP> -p tcp --dport <PORT> -j DROP
-	cmd := exec.Command("iptables", "-D", "INPUT",
-		"-s", ip,
-		"-p", "tcp",
-		"--dport", fmt.Sprintf("%d", port),
-		"-m", "comment", "--comment", "NAM-BAN",
-		"-j", "DROP")
-
-	output, err := cmd.CombinedOutput()
+	ip, err := NormalizeIP(ip)
 	if err != nil {
-		logger.Debugf("iptables 输出: %s", string(output))
-		return fmt.Errorf("iptables 解封失败: %w", err)
+		return err
+	}
+
+	if err := e.backend.Unban(ip, port); err != nil {
+		return err
 	}
 
-	logger.Infof("已解封 %s:%d", ip, port)
+	utils.GetLogger().Infof("已解封 %s:%d", ip, port)
 	return nil
 }
 
+// Setup 准备防火墙后端（创建 NAM 专用的链/表）
+// 失败时降级为 NullBackend：仍然断开超限连接，但不再下发封禁。
+// 只在监控启动前调用，此后 backend 不再变化。
+func (e *Executor) Setup() error {
+	if err := e.backend.Setup(); err != nil {
+		name := e.backend.Name()
+		e.backend = NewNullBackend(err)
+		return fmt.Errorf("防火墙后端 %s 初始化失败，已降级为仅断开连接: %w", name, err)
+	}
+	return nil
+}
+
+// BackendName 当前使用的防火墙后端名称
+func (e *Executor) BackendName() string {
+	return e.backend.Name()
+}
+
+// SetBlacklist 整体替换防火墙中的黑名单（不写入封禁记录与历史）
+func (e *Executor) SetBlacklist(lists map[int][]string) error {
+	return e.backend.SetBlacklist(lists)
+}
+
+// Degraded 是否已降级为仅断开连接模式
+func (e *Executor) Degraded() bool {
+	_, degraded := e.backend.(*NullBackend)
+	return degraded
+}
+
+// ListBans 列出内核中实际存在的 NAM 封禁
+func (e *Executor) ListBans() ([]RuleEntry, error) {
+	return e.backend.List()
+}
+
+// NativeExpiry 封禁是否由内核按超时自动解除
+func (e *Executor) NativeExpiry() bool {
+	return e.backend.NativeExpiry()
+}
+
 // EnforceVictims 执行驱逐操作
-func (e *Executor) EnforceVictims(port int, victims []string, banDuration int, reason string) error {
+func (e *Executor) EnforceVictims(port int, victims []string, banDuration int, reason, strategy string) error {
 	logger := utils.GetLogger()
 
 	for _, ip := range victims {
@@ -100,7 +143,7 @@ func (e *Executor) EnforceVictims(port int, victims []string, banDuration int, r
 
 		// 2. 应用封禁（如果配置了封禁时长）
 		if banDuration > 0 {
-			if err := e.ApplyBan(ip, port, banDuration); err != nil {
+			if err := e.ApplyBan(ip, port, banDuration, reason, strategy); err != nil {
 				logger.Errorf("封禁失败 %s:%d - %v", ip, port, err)
 			}
 		}
@@ -124,29 +167,3 @@ func CheckSSAvailable() bool {
 	err := cmd.Run()
 	return err == nil
 }
-
-// CleanupNAMRules 清理所有 NAM 创建的 iptables 规则
-func CleanupNAMRules() error {
-	logger := utils.GetLogger()
-	logger.Info("清理 NAM iptables 规则")
-
-	// 列出所有带 NAM-BAN 注释的规则
-	cmd := exec.Command("bash", "-c",
-		"iptables -L INPUT -n --line-numbers | grep 'NAM-BAN' | awk '{print $1}' | tac")
-
-	output, err := cmd.Output()
-	if err != nil {
-		return fmt.Errorf("列出规则失败: %w", err)
-	}
-
-	// 逐行删除
-	lines := string(output)
-	if lines == "" {
-		logger.Info("无需清理，未发现 NAM 规则")
-		return nil
-	}
-
-	// 注意：这里简化处理，生产环境应该更精确
-	logger.Warn("发现残留规则，建议手动检查: iptables -L INPUT -n | grep NAM-BAN")
-	return nil
-}
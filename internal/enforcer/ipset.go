@@ -0,0 +1,333 @@
+package enforcer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/nodeaccessmanager/nam/pkg/utils"
+)
+
+const (
+	// ipsetV4 / ipsetV6 封禁集合（元素形如 192.0.2.1,tcp:443 或 2001:db8::/64,tcp:443）
+	ipsetV4 = "nam-ban"
+	ipsetV6 = "nam-ban6"
+	// ipsetBlackV4 / ipsetBlackV6 黑名单集合，由 SetBlacklist 整体替换
+	ipsetBlackV4 = "nam-black"
+	ipsetBlackV6 = "nam-black6"
+	// ipsetType 集合类型（hash:net 支持按前缀封禁）
+	ipsetType = "hash:net,port"
+	// ipsetBlackMaxElem 黑名单集合容量（名单文件可能有数十万条）
+	ipsetBlackMaxElem = "1048576"
+	// ipsetComment NAM-INPUT 链中引用集合的规则注释
+	ipsetComment = "NAM-IPSET"
+)
+
+// IPSetBackend 基于 ipset 的封禁后端
+// NAM-INPUT 链中每个地址族只有一条 --match-set 规则，匹配开销与封禁数量无关；
+// 元素带 timeout，到期由内核自动删除。黑名单放在独立的 nam-black / nam-black6 集合中。
+type IPSetBackend struct{}
+
+// NewIPSetBackend 创建 ipset 后端
+func NewIPSetBackend() *IPSetBackend {
+	return &IPSetBackend{}
+}
+
+// Name 后端名称
+func (b *IPSetBackend) Name() string {
+	return "ipset"
+}
+
+// Probe 检查 ipset 与 iptables 是否可用
+func (b *IPSetBackend) Probe() error {
+	if err := exec.Command("ipset", "list", "-n").Run(); err != nil {
+		return fmt.Errorf("ipset 不可用: %w", err)
+	}
+	if err := exec.Command("iptables", "-S", "INPUT").Run(); err != nil {
+		return fmt.Errorf("iptables 不可用: %w", err)
+	}
+	return nil
+}
+
+// Setup 创建封禁与黑名单集合及 NAM-INPUT 链，并挂载 --match-set 规则（幂等）
+func (b *IPSetBackend) Setup() error {
+	if err := EnsureNAMChain(); err != nil {
+		return err
+	}
+
+	for _, binary := range iptablesBinaries() {
+		set, blackSet, family := ipsetV4, ipsetBlackV4, "inet"
+		if binary == "ip6tables" {
+			set, blackSet, family = ipsetV6, ipsetBlackV6, "inet6"
+		}
+
+		// timeout 0 表示集合支持超时、元素默认永久；counters 用于统计命中
+		if err := runIPSet("create", set, ipsetType, "family", family, "timeout", "0", "counters", "-exist"); err != nil {
+			return err
+		}
+		if err := runIPSet("create", blackSet, ipsetType, "family", family, "maxelem", ipsetBlackMaxElem, "-exist"); err != nil {
+			return err
+		}
+
+		for _, name := range []string{set, blackSet} {
+			rule := ipsetRuleSpec(name)
+			if exec.Command(binary, append([]string{"-C", namChain}, rule...)...).Run() == nil {
+				continue
+			}
+			if err := runIPTables(binary, append([]string{"-A", namChain}, rule...)...); err != nil {
+				return err
+			}
+			utils.GetLogger().Infof("已在 %s 链 %s 中挂载集合 %s", binary, namChain, name)
+		}
+	}
+
+	return nil
+}
+
+// Ban 添加集合元素（ip 可为单个地址或前缀；duration 为 0 表示永久；-exist 使已存在的元素刷新超时）
+func (b *IPSetBackend) Ban(ip string, port int, duration int) error {
+	set, member, err := ipsetMember(ip, port)
+	if err != nil {
+		return err
+	}
+
+	if err := runIPSet("add", set, member, "timeout", strconv.Itoa(duration), "-exist"); err != nil {
+		return fmt.Errorf("ipset 封禁失败: %w", err)
+	}
+	return nil
+}
+
+// Unban 删除集合元素
+func (b *IPSetBackend) Unban(ip string, port int) error {
+	set, member, err := ipsetMember(ip, port)
+	if err != nil {
+		return err
+	}
+
+	if err := runIPSet("del", set, member); err != nil {
+		return fmt.Errorf("ipset 解封失败: %w", err)
+	}
+	return nil
+}
+
+// SetBlacklist 通过一次 ipset restore 在临时集合中构建黑名单并与正式集合交换
+// swap 对内核是原子的，替换期间不会出现黑名单为空的窗口
+func (b *IPSetBackend) SetBlacklist(lists map[int][]string) error {
+	members := map[string][]string{ipsetBlackV4: nil, ipsetBlackV6: nil}
+	var errs []error
+
+	for _, port := range sortedPorts(lists) {
+		for _, entry := range lists[port] {
+			set, member, err := ipsetMember(entry, port)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", entry, err))
+				continue
+			}
+			if set == ipsetV4 {
+				set = ipsetBlackV4
+			} else {
+				set = ipsetBlackV6
+			}
+			members[set] = append(members[set], member)
+		}
+	}
+
+	var script strings.Builder
+	for _, set := range []string{ipsetBlackV4, ipsetBlackV6} {
+		if !ipsetExists(set) {
+			continue
+		}
+		family := "inet"
+		if set == ipsetBlackV6 {
+			family = "inet6"
+		}
+
+		tmp := set + "-new"
+		fmt.Fprintf(&script, "create %s %s family %s maxelem %s\n", tmp, ipsetType, family, ipsetBlackMaxElem)
+		fmt.Fprintf(&script, "flush %s\n", tmp)
+		for _, member := range members[set] {
+			fmt.Fprintf(&script, "add %s %s\n", tmp, member)
+		}
+		fmt.Fprintf(&script, "swap %s %s\n", set, tmp)
+		fmt.Fprintf(&script, "destroy %s\n", tmp)
+	}
+
+	if script.Len() > 0 {
+		cmd := exec.Command("ipset", "-exist", "restore")
+		cmd.Stdin = strings.NewReader(script.String())
+		if output, err := cmd.CombinedOutput(); err != nil {
+			errs = append(errs, fmt.Errorf("ipset 更新黑名单失败: %w（%s）", err, strings.TrimSpace(string(output))))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// List 列出集合中的全部元素（含命中计数）
+func (b *IPSetBackend) List() ([]RuleEntry, error) {
+	var entries []RuleEntry
+	var errs []error
+
+	for _, set := range []string{ipsetV4, ipsetV6} {
+		if !ipsetExists(set) {
+			continue
+		}
+		output, err := exec.Command("ipset", "save", set).Output()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ipset save %s 执行失败: %w", set, err))
+			continue
+		}
+		entries = append(entries, parseIPSetSave(output)...)
+	}
+
+	return entries, errors.Join(errs...)
+}
+
+// Flush 清空封禁与黑名单集合
+func (b *IPSetBackend) Flush(dryRun bool) ([]RuleEntry, error) {
+	entries, err := b.List()
+	if err != nil || dryRun {
+		return entries, err
+	}
+
+	for _, set := range ipsetAllSets() {
+		if !ipsetExists(set) {
+			continue
+		}
+		if err := runIPSet("flush", set); err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+// Teardown 删除 NAM-INPUT 链后销毁集合（集合被规则引用时无法销毁）
+func (b *IPSetBackend) Teardown() error {
+	if err := RemoveNAMChain(); err != nil {
+		return err
+	}
+
+	for _, set := range ipsetAllSets() {
+		if !ipsetExists(set) {
+			continue
+		}
+		if err := runIPSet("destroy", set); err != nil {
+			return err
+		}
+		utils.GetLogger().Infof("已销毁 ipset 集合 %s", set)
+	}
+
+	return nil
+}
+
+// NativeExpiry 集合元素带超时，由内核自动解封
+func (b *IPSetBackend) NativeExpiry() bool {
+	return true
+}
+
+// hasSets 检查 NAM 的 ipset 集合是否已存在
+func (b *IPSetBackend) hasSets() bool {
+	return ipsetExists(ipsetV4)
+}
+
+// ipsetRuleSpec 引用集合的规则参数（src,dst：源地址 + 目标端口）
+func ipsetRuleSpec(set string) []string {
+	return []string{
+		"-m", "set", "--match-set", set, "src,dst",
+		"-m", "comment", "--comment", ipsetComment,
+		"-j", "DROP",
+	}
+}
+
+// ipsetMember 按地址族返回集合名与元素文本（IPv4-mapped 地址还原为 IPv4）
+// 集合只有被对应的 iptables / ip6tables 规则引用才生效，缺少该工具时返回 ErrUnsupportedFamily
+func ipsetMember(ip string, port int) (string, string, error) {
+	target, err := parseBanTarget(ip)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := iptablesBinaryFor(target.Addr()); err != nil {
+		return "", "", err
+	}
+
+	member := fmt.Sprintf("%s,tcp:%d", targetString(target), port)
+	if target.Addr().Is4() {
+		return ipsetV4, member, nil
+	}
+	return ipsetV6, member, nil
+}
+
+// parseIPSetSave 解析 ipset save 输出中的 add 行:
+//
+//	add nam-ban 192.0.2.1,tcp:443 timeout 57 packets 3 bytes 180
+func parseIPSetSave(output []byte) []RuleEntry {
+	var entries []RuleEntry
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] != "add" {
+			continue
+		}
+
+		sep := strings.LastIndex(fields[2], ",")
+		if sep < 0 {
+			continue
+		}
+		ip := trimHostPrefix(fields[2][:sep])
+		proto, portStr, ok := strings.Cut(fields[2][sep+1:], ":")
+		if !ok || proto != "tcp" {
+			continue
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			continue
+		}
+
+		entry := RuleEntry{
+			Binary: "ipset",
+			Chain:  fields[1],
+			IP:     ip,
+			Port:   port,
+			Rule:   strings.Join(fields[2:], " "),
+		}
+		for i := 3; i+1 < len(fields); i += 2 {
+			value, _ := strconv.ParseUint(fields[i+1], 10, 64)
+			switch fields[i] {
+			case "packets":
+				entry.Packets = value
+			case "bytes":
+				entry.Bytes = value
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// ipsetAllSets NAM 创建的全部集合
+func ipsetAllSets() []string {
+	return []string{ipsetV4, ipsetV6, ipsetBlackV4, ipsetBlackV6}
+}
+
+// ipsetExists 检查集合是否存在
+func ipsetExists(set string) bool {
+	return exec.Command("ipset", "list", "-n", set).Run() == nil
+}
+
+// runIPSet 执行 ipset 命令，失败时附带命令输出
+func runIPSet(args ...string) error {
+	output, err := exec.Command("ipset", args...).CombinedOutput()
+	if err != nil {
+		utils.GetLogger().Debugf("ipset %s 输出: %s", strings.Join(args, " "), string(output))
+		return fmt.Errorf("ipset %s 执行失败: %w", strings.Join(args, " "), err)
+	}
+	return nil
+}
@@ -0,0 +1,506 @@
+package enforcer
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/nodeaccessmanager/nam/pkg/utils"
+)
+
+const (
+	// namChain NAM 专用链，从 INPUT 跳转，全部封禁规则都放在这里
+	namChain = "NAM-INPUT"
+	// namBanComment NAM 规则注释标记
+	namBanComment = "NAM-BAN"
+	// namBlacklistChain 黑名单专用链，从 NAM-INPUT 跳转，由 SetBlacklist 整体重写
+	namBlacklistChain = "NAM-BLACKLIST"
+)
+
+// namRule iptables 中一条由 NAM 创建的规则
+type namRule struct {
+	IP      string   // 源地址（单个 IP 时去掉 /32、/128 后缀）
+	Port    int      // 目标端口
+	Packets uint64   // 命中的数据包数
+	Bytes   uint64   // 命中的字节数
+	Spec    []string // 规则参数（不含 -A <chain> 与计数器）
+}
+
+// IPTablesBackend 基于 iptables 的封禁后端
+// 全部封禁规则放在 NAM-INPUT 链中，到期由冷却管理器的定时器删除；
+// 黑名单放在独立的 NAM-BLACKLIST 链中，与封禁互不影响
+type IPTablesBackend struct{}
+
+// NewIPTablesBackend 创建 iptables 后端
+func NewIPTablesBackend() *IPTablesBackend {
+	return &IPTablesBackend{}
+}
+
+// Name 后端名称
+func (b *IPTablesBackend) Name() string {
+	return "iptables"
+}
+
+// Probe 检查 iptables 是否可用
+func (b *IPTablesBackend) Probe() error {
+	if err := exec.Command("iptables", "-S", "INPUT").Run(); err != nil {
+		return fmt.Errorf("iptables 不可用: %w", err)
+	}
+	return nil
+}
+
+// Setup 创建并挂载 NAM-INPUT 与 NAM-BLACKLIST 链，迁移旧版本直接插入 INPUT 链的规则
+func (b *IPTablesBackend) Setup() error {
+	if err := EnsureNAMChain(); err != nil {
+		return err
+	}
+	return ensureBlacklistChain()
+}
+
+// Ban 插入 DROP 规则（ip 可为单个地址或前缀；按地址族选择 iptables / ip6tables；期限由冷却管理器负责）
+func (b *IPTablesBackend) Ban(ip string, port int, duration int) error {
+	target, err := parseBanTarget(ip)
+	if err != nil {
+		return err
+	}
+	binary, err := iptablesBinaryFor(target.Addr())
+	if err != nil {
+		return err
+	}
+
+	// 已存在相同规则时不重复插入
+	spec := banRuleSpec(targetString(target), port)
+	if exec.Command(binary, append([]string{"-C", namChain}, spec...)...).Run() == nil {
+		return nil
+	}
+
+	if err := runIPTables(binary, append([]string{"-I", namChain}, spec...)...); err != nil {
+		return fmt.Errorf("%s 封禁失败: %w", binary, err)
+	}
+	return nil
+}
+
+// Unban 删除 DROP 规则
+func (b *IPTablesBackend) Unban(ip string, port int) error {
+	target, err := parseBanTarget(ip)
+	if err != nil {
+		return err
+	}
+	binary, err := iptablesBinaryFor(target.Addr())
+	if err != nil {
+		return err
+	}
+
+	spec := banRuleSpec(targetString(target), port)
+	if err := runIPTables(binary, append([]string{"-D", namChain}, spec...)...); err != nil {
+		return fmt.Errorf("%s 解封失败: %w", binary, err)
+	}
+	return nil
+}
+
+// SetBlacklist 按地址族各执行一次 iptables-restore --noflush 重写 NAM-BLACKLIST 链
+// restore 会先清空脚本中声明的已有自定义链，整条链的替换在内核中是原子的
+func (b *IPTablesBackend) SetBlacklist(lists map[int][]string) error {
+	scripts := make(map[string]*strings.Builder)
+	for _, binary := range iptablesBinaries() {
+		scripts[binary] = &strings.Builder{}
+	}
+
+	var errs []error
+	for _, port := range sortedPorts(lists) {
+		for _, entry := range lists[port] {
+			target, err := parseBanTarget(entry)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			binary, err := iptablesBinaryFor(target.Addr())
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", entry, err))
+				continue
+			}
+			spec := blacklistRuleSpec(targetString(target), port)
+			fmt.Fprintf(scripts[binary], "-A %s %s\n", namBlacklistChain, strings.Join(spec, " "))
+		}
+	}
+
+	for binary, rules := range scripts {
+		script := fmt.Sprintf("*filter\n:%s - [0:0]\n%sCOMMIT\n", namBlacklistChain, rules.String())
+		if err := runRestore(binary+"-restore", script); err != nil {
+			errs = append(errs, fmt.Errorf("%s 更新黑名单失败: %w", binary, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// List 列出 iptables 与 ip6tables 中 NAM-INPUT 链的封禁规则
+func (b *IPTablesBackend) List() ([]RuleEntry, error) {
+	var entries []RuleEntry
+	for _, binary := range iptablesBinaries() {
+		rules, err := listNAMRules(binary, namChain)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range rules {
+			entries = append(entries, rule.entry(binary, namChain))
+		}
+	}
+	return entries, nil
+}
+
+// Flush 清空 NAM-INPUT 链及旧版本留在 INPUT 链的规则，并清空 NAM-BLACKLIST 链
+func (b *IPTablesBackend) Flush(dryRun bool) ([]RuleEntry, error) {
+	entries, err := CleanupNAMRules(dryRun)
+	if dryRun {
+		return entries, err
+	}
+
+	errs := []error{err}
+	for _, binary := range iptablesBinaries() {
+		if exec.Command(binary, "-S", namBlacklistChain).Run() != nil {
+			continue
+		}
+		errs = append(errs, runIPTables(binary, "-F", namBlacklistChain))
+	}
+	return entries, errors.Join(errs...)
+}
+
+// Teardown 解除 INPUT 跳转并删除 NAM-INPUT 与 NAM-BLACKLIST 链
+func (b *IPTablesBackend) Teardown() error {
+	return RemoveNAMChain()
+}
+
+// NativeExpiry iptables 规则没有超时，需由定时器解封
+func (b *IPTablesBackend) NativeExpiry() bool {
+	return false
+}
+
+// hasChain 检查 NAM-INPUT 链是否已存在
+func (b *IPTablesBackend) hasChain() bool {
+	return exec.Command("iptables", "-S", namChain).Run() == nil
+}
+
+// banRuleSpec 封禁规则参数: -s <IP> -p tcp --dport <PORT> -m comment --comment NAM-BAN -j DROP
+func banRuleSpec(ip string, port int) []string {
+	return []string{
+		"-s", ip,
+		"-p", "tcp",
+		"--dport", strconv.Itoa(port),
+		"-m", "comment", "--comment", namBanComment,
+		"-j", "DROP",
+	}
+}
+
+// blacklistRuleSpec 黑名单规则参数: -s <IP> -p tcp --dport <PORT> -j DROP
+// 规则只存在于 NAM-BLACKLIST 链中，无需注释标记
+func blacklistRuleSpec(ip string, port int) []string {
+	return []string{
+		"-s", ip,
+		"-p", "tcp",
+		"--dport", strconv.Itoa(port),
+		"-j", "DROP",
+	}
+}
+
+// iptablesBinaries 返回当前系统可用的 iptables / ip6tables
+func iptablesBinaries() []string {
+	var binaries []string
+	for _, binary := range []string{"iptables", "ip6tables"} {
+		if _, err := exec.LookPath(binary); err == nil {
+			binaries = append(binaries, binary)
+		}
+	}
+	return binaries
+}
+
+// runIPTables 执行 iptables 命令，失败时附带命令输出
+func runIPTables(binary string, args ...string) error {
+	output, err := exec.Command(binary, args...).CombinedOutput()
+	if err != nil {
+		utils.GetLogger().Debugf("%s %s 输出: %s", binary, strings.Join(args, " "), string(output))
+		return fmt.Errorf("%s %s 执行失败: %w", binary, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// runRestore 以 --noflush 方式执行 iptables-restore / ip6tables-restore 脚本
+func runRestore(binary, script string) error {
+	cmd := exec.Command(binary, "--noflush")
+	cmd.Stdin = strings.NewReader(script)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		utils.GetLogger().Debugf("%s 脚本:\n%s输出: %s", binary, script, string(output))
+		return fmt.Errorf("%s 执行失败: %w（%s）", binary, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// EnsureNAMChain 在 iptables 与 ip6tables 中创建 NAM-INPUT 链并从 INPUT 跳转（幂等）
+// 同时把旧版本直接插入 INPUT 链的 NAM-BAN 规则移除，由启动对账重新下发到新链
+func EnsureNAMChain() error {
+	logger := utils.GetLogger()
+
+	for _, binary := range iptablesBinaries() {
+		// 1. 创建链（已存在时 -S 成功，跳过）
+		if err := exec.Command(binary, "-S", namChain).Run(); err != nil {
+			if err := runIPTables(binary, "-N", namChain); err != nil {
+				return err
+			}
+			logger.Infof("已创建 %s 链 %s", binary, namChain)
+		}
+
+		// 2. 从 INPUT 跳转（仅挂载一次）
+		if err := exec.Command(binary, "-C", "INPUT", "-j", namChain).Run(); err != nil {
+			if err := runIPTables(binary, "-I", "INPUT", "1", "-j", namChain); err != nil {
+				return err
+			}
+			logger.Infof("已将 %s 链 %s 挂载到 INPUT", binary, namChain)
+		}
+
+		// 3. 迁移旧规则
+		legacy, err := listNAMRules(binary, "INPUT")
+		if err != nil {
+			return err
+		}
+		for _, rule := range legacy {
+			args := append([]string{"-D", "INPUT"}, rule.Spec...)
+			if err := runIPTables(binary, args...); err != nil {
+				logger.Warnf("移除 INPUT 链中的旧 NAM 规则失败: %v", err)
+			}
+		}
+		if len(legacy) > 0 {
+			logger.Infof("已从 %s INPUT 链移除 %d 条旧 NAM 规则", binary, len(legacy))
+		}
+	}
+
+	return nil
+}
+
+// ensureBlacklistChain 创建 NAM-BLACKLIST 链并从 NAM-INPUT 链首跳转（幂等）
+func ensureBlacklistChain() error {
+	for _, binary := range iptablesBinaries() {
+		if err := exec.Command(binary, "-S", namBlacklistChain).Run(); err != nil {
+			if err := runIPTables(binary, "-N", namBlacklistChain); err != nil {
+				return err
+			}
+		}
+
+		if exec.Command(binary, "-C", namChain, "-j", namBlacklistChain).Run() == nil {
+			continue
+		}
+		if err := runIPTables(binary, "-I", namChain, "1", "-j", namBlacklistChain); err != nil {
+			return err
+		}
+		utils.GetLogger().Infof("已将 %s 链 %s 挂载到 %s", binary, namBlacklistChain, namChain)
+	}
+	return nil
+}
+
+// CleanupNAMRules 清理所有 NAM 创建的 iptables/ip6tables 封禁规则
+// NAM-INPUT 链与旧版本留在 INPUT 链中的 NAM-BAN 规则均按完整参数逐条 -D，
+// 不依赖行号，不经过 shell，也不会触及用户规则或 ipset 后端的引用规则。
+// dryRun 为 true 时只返回将被删除的规则。
+func CleanupNAMRules(dryRun bool) ([]RuleEntry, error) {
+	logger := utils.GetLogger()
+	logger.Info("清理 NAM iptables 规则")
+
+	var entries []RuleEntry
+	var errs []error
+
+	for _, binary := range iptablesBinaries() {
+		chains := []string{"INPUT"}
+		if exec.Command(binary, "-S", namChain).Run() == nil {
+			chains = append([]string{namChain}, chains...)
+		}
+
+		for _, chain := range chains {
+			rules, err := listNAMRules(binary, chain)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, rule := range rules {
+				if !dryRun {
+					args := append([]string{"-D", chain}, rule.Spec...)
+					if err := runIPTables(binary, args...); err != nil {
+						errs = append(errs, err)
+						continue
+					}
+				}
+				entries = append(entries, rule.entry(binary, chain))
+			}
+		}
+	}
+
+	if len(entries) == 0 && len(errs) == 0 {
+		logger.Info("无需清理，未发现 NAM 规则")
+	} else if !dryRun {
+		logger.Infof("已清理 %d 条 NAM 规则", len(entries))
+	}
+
+	return entries, errors.Join(errs...)
+}
+
+// RemoveNAMChain 卸载 NAM-INPUT 链：解除 INPUT 跳转、清空并删除链，随后删除其引用的 NAM-BLACKLIST 链
+func RemoveNAMChain() error {
+	var errs []error
+
+	for _, binary := range iptablesBinaries() {
+		// 解除跳转（重复挂载时逐个删除）
+		for exec.Command(binary, "-C", "INPUT", "-j", namChain).Run() == nil {
+			if err := runIPTables(binary, "-D", "INPUT", "-j", namChain); err != nil {
+				errs = append(errs, err)
+				break
+			}
+		}
+
+		for _, chain := range []string{namChain, namBlacklistChain} {
+			if err := removeChain(binary, chain); err != nil {
+				errs = append(errs, err)
+				break
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// removeChain 清空并删除自定义链（链不存在时跳过）
+func removeChain(binary, chain string) error {
+	if exec.Command(binary, "-S", chain).Run() != nil {
+		return nil
+	}
+
+	if err := runIPTables(binary, "-F", chain); err != nil {
+		return err
+	}
+	if err := runIPTables(binary, "-X", chain); err != nil {
+		return err
+	}
+
+	utils.GetLogger().Infof("已删除 %s 链 %s", binary, chain)
+	return nil
+}
+
+// listNAMRules 列出指定链中带 NAM-BAN 注释的规则（含计数器）
+func listNAMRules(binary, chain string) ([]namRule, error) {
+	output, err := exec.Command(binary, "-v", "-S", chain).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s -S %s 执行失败: %w", binary, chain, err)
+	}
+
+	var rules []namRule
+	for _, line := range strings.Split(string(output), "\n") {
+		args, err := splitRuleArgs(line)
+		if err != nil || len(args) < 2 || args[0] != "-A" || args[1] != chain {
+			continue
+		}
+
+		rule, ok := parseNAMRule(args[2:])
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, nil
+}
+
+// parseNAMRule 从规则参数中提取源地址、端口与计数器，非 NAM 规则返回 false
+func parseNAMRule(args []string) (namRule, bool) {
+	var rule namRule
+	isNAM := false
+
+	for i := 0; i < len(args); i++ {
+		// -c <packets> <bytes> 为 -v 输出的计数器，不属于规则本身
+		if args[i] == "-c" && i+2 < len(args) {
+			rule.Packets, _ = strconv.ParseUint(args[i+1], 10, 64)
+			rule.Bytes, _ = strconv.ParseUint(args[i+2], 10, 64)
+			i += 2
+			continue
+		}
+
+		rule.Spec = append(rule.Spec, args[i])
+		if i+1 >= len(args) {
+			continue
+		}
+
+		switch args[i] {
+		case "-s", "--source":
+			rule.IP = trimHostPrefix(args[i+1])
+		case "--dport", "--destination-port":
+			rule.Port, _ = strconv.Atoi(args[i+1])
+		case "--comment":
+			isNAM = args[i+1] == namBanComment
+		}
+	}
+
+	return rule, isNAM
+}
+
+// entry 转换为对外展示的规则条目
+func (r namRule) entry(binary, chain string) RuleEntry {
+	return RuleEntry{
+		Binary:  binary,
+		Chain:   chain,
+		IP:      r.IP,
+		Port:    r.Port,
+		Packets: r.Packets,
+		Bytes:   r.Bytes,
+		Rule:    strings.Join(r.Spec, " "),
+	}
+}
+
+// trimHostPrefix 去掉单主机地址的 /32 或 /128 后缀
+func trimHostPrefix(s string) string {
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return s
+	}
+	if prefix.IsSingleIP() {
+		return prefix.Addr().String()
+	}
+	return prefix.String()
+}
+
+// splitRuleArgs 按 iptables-save 的引号规则拆分一行规则参数
+func splitRuleArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inQuote := false
+	hasToken := false
+
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case ch == '\\' && inQuote && i+1 < len(line):
+			i++
+			current.WriteByte(line[i])
+		case ch == '"':
+			inQuote = !inQuote
+			hasToken = true
+		case (ch == ' ' || ch == '\t') && !inQuote:
+			if hasToken {
+				args = append(args, current.String())
+				current.Reset()
+				hasToken = false
+			}
+		default:
+			current.WriteByte(ch)
+			hasToken = true
+		}
+	}
+
+	if inQuote {
+		return nil, fmt.Errorf("引号不匹配: %s", line)
+	}
+	if hasToken {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
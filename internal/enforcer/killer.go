@@ -0,0 +1,43 @@
+package enforcer
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/nodeaccessmanager/nam/pkg/utils"
+)
+
+// ConnectionKiller 强制断开连接的实现
+// Executor 只依赖该接口，默认实现为 ss -K（内核 SOCK_DESTROY）
+type ConnectionKiller interface {
+	// Name 实现名称（用于日志）
+	Name() string
+	// Kill 断开目标（单个地址或前缀）到本地端口的全部连接
+	Kill(port int, target string) error
+}
+
+// SSKiller 基于 ss -K 的连接断开实现
+type SSKiller struct{}
+
+// NewSSKiller 创建 ss -K 断开器
+func NewSSKiller() *SSKiller {
+	return &SSKiller{}
+}
+
+// Name 实现名称
+func (k *SSKiller) Name() string {
+	return "ss"
+}
+
+// Kill 执行 ss -K dst <TARGET> sport = :<PORT>
+func (k *SSKiller) Kill(port int, target string) error {
+	cmd := exec.Command("ss", "-K", "dst", target, "sport", "=", fmt.Sprintf(":%d", port))
+	output, err := cmd.CombinedOutput()
+
+	if err != nil {
+		utils.GetLogger().Debugf("ss -K 输出: %s", string(output))
+		return fmt.Errorf("ss -K 执行失败: %w", err)
+	}
+
+	return nil
+}
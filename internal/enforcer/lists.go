@@ -0,0 +1,41 @@
+package enforcer
+
+import (
+	"github.com/nodeaccessmanager/nam/internal/iplist"
+	"github.com/nodeaccessmanager/nam/pkg/utils"
+)
+
+// readListFiles 读取名单文件，只返回读取成功的文件（失败的文件由调用方沿用旧内容）
+func readListFiles(paths []string) map[string]*iplist.Set {
+	logger := utils.GetLogger()
+	sets := make(map[string]*iplist.Set, len(paths))
+
+	for _, path := range paths {
+		set, invalid, err := iplist.ParseFile(path)
+		if err != nil {
+			logger.Errorf("读取名单文件失败，沿用上次加载的内容: %v", err)
+			continue
+		}
+		if len(invalid) > 0 {
+			logger.Warnf("名单文件 %s 有 %d 行无法解析已跳过（第 %d 行: %q）",
+				path, len(invalid), invalid[0].Line, invalid[0].Text)
+		}
+
+		logger.Infof("已加载名单文件 %s: %d 条", path, set.Len())
+		sets[path] = set
+	}
+
+	return sets
+}
+
+// ReloadListFiles 重新读取发生变更的名单文件并同步黑名单封禁（由文件监听回调）
+// 文件读取在锁外完成，只有切换前缀树时持有写锁
+func (e *Enforcer) ReloadListFiles(paths []string) error {
+	sets := readListFiles(paths)
+
+	e.mu.Lock()
+	e.policyEngine.UpdateFiles(sets)
+	e.mu.Unlock()
+
+	return e.SyncBlacklists()
+}
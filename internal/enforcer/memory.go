@@ -0,0 +1,302 @@
+package enforcer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrBackendUnavailable 防火墙后端不可用（降级为仅断开连接）
+var ErrBackendUnavailable = errors.New("防火墙后端不可用")
+
+// BackendEvent 内存实现记录的一次操作
+type BackendEvent struct {
+	Op       string // ban / unban / kill / blacklist / flush
+	IP       string
+	Port     int
+	Duration int // 仅 ban
+}
+
+// MemoryBackend 内存中的封禁后端，记录全部操作，不触碰系统防火墙
+// 用于无 root 权限的单元测试，以及在开发环境中演练完整的驱逐流程
+type MemoryBackend struct {
+	mu        sync.Mutex
+	bans      map[string]RuleEntry
+	blacklist map[int][]string
+	events    []BackendEvent
+	err       error // 非 nil 时所有写操作返回该错误（模拟后端故障）
+}
+
+// NewMemoryBackend 创建内存封禁后端
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		bans:      make(map[string]RuleEntry),
+		blacklist: make(map[int][]string),
+	}
+}
+
+// Name 后端名称
+func (b *MemoryBackend) Name() string {
+	return "memory"
+}
+
+// Probe 始终可用
+func (b *MemoryBackend) Probe() error {
+	return nil
+}
+
+// Setup 无需准备
+func (b *MemoryBackend) Setup() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// Ban 记录封禁
+func (b *MemoryBackend) Ban(ip string, port int, duration int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return b.err
+	}
+
+	ip, err := NormalizeIP(ip)
+	if err != nil {
+		return err
+	}
+
+	b.bans[banKey(ip, port)] = RuleEntry{Binary: "memory", IP: ip, Port: port}
+	b.events = append(b.events, BackendEvent{Op: "ban", IP: ip, Port: port, Duration: duration})
+	return nil
+}
+
+// Unban 删除封禁（不存在时返回错误，与真实后端一致）
+func (b *MemoryBackend) Unban(ip string, port int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return b.err
+	}
+
+	ip, err := NormalizeIP(ip)
+	if err != nil {
+		return err
+	}
+
+	key := banKey(ip, port)
+	if _, exists := b.bans[key]; !exists {
+		return fmt.Errorf("封禁不存在: %s", key)
+	}
+
+	delete(b.bans, key)
+	b.events = append(b.events, BackendEvent{Op: "unban", IP: ip, Port: port})
+	return nil
+}
+
+// SetBlacklist 替换黑名单（每个端口记录一次 blacklist 操作）
+func (b *MemoryBackend) SetBlacklist(lists map[int][]string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return b.err
+	}
+
+	b.blacklist = make(map[int][]string, len(lists))
+	for _, port := range sortedPorts(lists) {
+		b.blacklist[port] = append([]string(nil), lists[port]...)
+		b.events = append(b.events, BackendEvent{Op: "blacklist", Port: port})
+	}
+	return nil
+}
+
+// List 列出当前封禁（按 key 排序）
+func (b *MemoryBackend) List() ([]RuleEntry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	keys := make([]string, 0, len(b.bans))
+	for key := range b.bans {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entries := make([]RuleEntry, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, b.bans[key])
+	}
+	return entries, nil
+}
+
+// Flush 清空封禁
+func (b *MemoryBackend) Flush(dryRun bool) ([]RuleEntry, error) {
+	entries, _ := b.List()
+	if dryRun {
+		return entries, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bans = make(map[string]RuleEntry)
+	b.blacklist = make(map[int][]string)
+	b.events = append(b.events, BackendEvent{Op: "flush"})
+	return entries, nil
+}
+
+// Teardown 清空封禁
+func (b *MemoryBackend) Teardown() error {
+	_, err := b.Flush(false)
+	return err
+}
+
+// NativeExpiry 由冷却管理器的定时器解封
+func (b *MemoryBackend) NativeExpiry() bool {
+	return false
+}
+
+// IsBanned 检查目标是否处于封禁中
+func (b *MemoryBackend) IsBanned(ip string, port int) bool {
+	if normalized, err := NormalizeIP(ip); err == nil {
+		ip = normalized
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, exists := b.bans[banKey(ip, port)]
+	return exists
+}
+
+// Blacklist 返回端口当前的黑名单条目
+func (b *MemoryBackend) Blacklist(port int) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.blacklist[port]...)
+}
+
+// Events 返回操作记录的副本
+func (b *MemoryBackend) Events() []BackendEvent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]BackendEvent(nil), b.events...)
+}
+
+// SetError 设置后续写操作返回的错误（nil 恢复正常）
+func (b *MemoryBackend) SetError(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.err = err
+}
+
+// MemoryKiller 内存中的连接断开实现，只记录调用
+type MemoryKiller struct {
+	mu     sync.Mutex
+	events []BackendEvent
+	err    error
+}
+
+// NewMemoryKiller 创建内存连接断开器
+func NewMemoryKiller() *MemoryKiller {
+	return &MemoryKiller{}
+}
+
+// Name 实现名称
+func (k *MemoryKiller) Name() string {
+	return "memory"
+}
+
+// Kill 记录断开操作
+func (k *MemoryKiller) Kill(port int, target string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.err != nil {
+		return k.err
+	}
+	k.events = append(k.events, BackendEvent{Op: "kill", IP: target, Port: port})
+	return nil
+}
+
+// Events 返回断开记录的副本
+func (k *MemoryKiller) Events() []BackendEvent {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return append([]BackendEvent(nil), k.events...)
+}
+
+// SetError 设置后续断开操作返回的错误（nil 恢复正常）
+func (k *MemoryKiller) SetError(err error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.err = err
+}
+
+// NullBackend 不可用时的降级后端：不封禁，只保留断开连接的能力
+type NullBackend struct {
+	cause error // 降级原因
+}
+
+// NewNullBackend 创建降级后端
+func NewNullBackend(cause error) *NullBackend {
+	return &NullBackend{cause: cause}
+}
+
+// Name 后端名称
+func (b *NullBackend) Name() string {
+	return "none"
+}
+
+// Probe 始终可用
+func (b *NullBackend) Probe() error {
+	return nil
+}
+
+// Setup 无需准备
+func (b *NullBackend) Setup() error {
+	return nil
+}
+
+// Ban 返回 ErrBackendUnavailable（连接已被断开，但无法阻止重连）
+func (b *NullBackend) Ban(ip string, port int, duration int) error {
+	return b.unavailable()
+}
+
+// Unban 返回 ErrBackendUnavailable
+func (b *NullBackend) Unban(ip string, port int) error {
+	return b.unavailable()
+}
+
+// SetBlacklist 返回 ErrBackendUnavailable（黑名单只在采集时断开连接）
+func (b *NullBackend) SetBlacklist(lists map[int][]string) error {
+	return b.unavailable()
+}
+
+// List 没有任何封禁
+func (b *NullBackend) List() ([]RuleEntry, error) {
+	return nil, nil
+}
+
+// Flush 没有任何封禁
+func (b *NullBackend) Flush(dryRun bool) ([]RuleEntry, error) {
+	return nil, nil
+}
+
+// Teardown 无需清理
+func (b *NullBackend) Teardown() error {
+	return nil
+}
+
+// NativeExpiry 没有封禁需要到期
+func (b *NullBackend) NativeExpiry() bool {
+	return true
+}
+
+// unavailable 附带降级原因的错误
+func (b *NullBackend) unavailable() error {
+	if b.cause == nil {
+		return ErrBackendUnavailable
+	}
+	return fmt.Errorf("%w: %v", ErrBackendUnavailable, b.cause)
+}
@@ -0,0 +1,418 @@
+package enforcer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nodeaccessmanager/nam/pkg/utils"
+)
+
+const (
+	// nftTable NAM 专用的 nftables 表（inet 族同时覆盖 IPv4 与 IPv6）
+	nftTable = "nam"
+	// nftChain 挂在 input 钩子上的基础链
+	nftChain = "input"
+	// nftPriority 先于 iptables-nft 的 filter 表（priority 0）执行
+	nftPriority = -10
+)
+
+// NFTablesBackend 基于 nftables 的封禁后端
+// 每个端口一对命名集合 ban4_<port> / ban6_<port>，封禁即添加带超时的元素，
+// 到期由内核自动删除，NAM 崩溃或重启期间封禁仍按原期限解除。
+// 黑名单放在另一对集合 black4_<port> / black6_<port> 中，由 SetBlacklist 整体替换。
+type NFTablesBackend struct {
+	mu    sync.Mutex
+	ports map[int]bool // 已创建集合与规则的端口
+}
+
+// NewNFTablesBackend 创建 nftables 后端
+func NewNFTablesBackend() *NFTablesBackend {
+	return &NFTablesBackend{
+		ports: make(map[int]bool),
+	}
+}
+
+// Name 后端名称
+func (b *NFTablesBackend) Name() string {
+	return "nftables"
+}
+
+// Probe 检查 nft 命令是否可用且有权限访问内核
+func (b *NFTablesBackend) Probe() error {
+	if err := exec.Command("nft", "list", "tables").Run(); err != nil {
+		return fmt.Errorf("nft 不可用: %w", err)
+	}
+	return nil
+}
+
+// Setup 创建 inet nam 表与 input 链（幂等），并载入已有的端口集合
+func (b *NFTablesBackend) Setup() error {
+	script := fmt.Sprintf("add table inet %s\n"+
+		"add chain inet %s %s { type filter hook input priority %d; policy accept; }\n",
+		nftTable, nftTable, nftChain, nftPriority)
+	if err := runNft(script); err != nil {
+		return fmt.Errorf("创建 nftables 表失败: %w", err)
+	}
+
+	sets, err := b.listSets()
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, set := range sets {
+		if port, _, ok := parseSetName(set.Name); ok {
+			b.ports[port] = true
+		}
+	}
+
+	utils.GetLogger().Infof("nftables 表 inet %s 已就绪（%d 个端口集合）", nftTable, len(b.ports))
+	return nil
+}
+
+// Ban 向端口集合添加元素（duration > 0 时带超时，已存在时刷新超时）
+func (b *NFTablesBackend) Ban(ip string, port int, duration int) error {
+	set, elem, err := nftElement(ip, port)
+	if err != nil {
+		return err
+	}
+
+	if err := b.ensurePort(port); err != nil {
+		return err
+	}
+
+	timeout := ""
+	if duration > 0 {
+		timeout = fmt.Sprintf(" timeout %ds", duration)
+	}
+
+	// add → delete → add 在同一事务中执行：元素不存在时 add 保证 delete 不报错，
+	// 已存在时重新添加以刷新超时，整个过程对内核是原子的
+	script := fmt.Sprintf("add element inet %[1]s %[2]s { %[3]s }\n"+
+		"delete element inet %[1]s %[2]s { %[3]s }\n"+
+		"add element inet %[1]s %[2]s { %[3]s%[4]s }\n",
+		nftTable, set, elem, timeout)
+	if err := runNft(script); err != nil {
+		return fmt.Errorf("nftables 封禁失败: %w", err)
+	}
+	return nil
+}
+
+// Unban 从端口集合删除元素
+func (b *NFTablesBackend) Unban(ip string, port int) error {
+	set, elem, err := nftElement(ip, port)
+	if err != nil {
+		return err
+	}
+
+	script := fmt.Sprintf("delete element inet %s %s { %s }\n", nftTable, set, elem)
+	if err := runNft(script); err != nil {
+		return fmt.Errorf("nftables 解封失败: %w", err)
+	}
+	return nil
+}
+
+// SetBlacklist 在一个事务中清空并重新填充各端口的黑名单集合，不在 lists 中的端口清空
+func (b *NFTablesBackend) SetBlacklist(lists map[int][]string) error {
+	for port := range lists {
+		if err := b.ensurePort(port); err != nil {
+			return err
+		}
+	}
+
+	elems := make(map[string][]string)
+	var errs []error
+	for _, port := range sortedPorts(lists) {
+		for _, entry := range lists[port] {
+			set, elem, err := nftElement(entry, port)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", entry, err))
+				continue
+			}
+			set = "black" + strings.TrimPrefix(set, "ban")
+			elems[set] = append(elems[set], elem)
+		}
+	}
+
+	b.mu.Lock()
+	ports := make([]int, 0, len(b.ports))
+	for port := range b.ports {
+		ports = append(ports, port)
+	}
+	b.mu.Unlock()
+	sort.Ints(ports)
+
+	var script strings.Builder
+	for _, port := range ports {
+		for _, set := range []string{fmt.Sprintf("black4_%d", port), fmt.Sprintf("black6_%d", port)} {
+			fmt.Fprintf(&script, "flush set inet %s %s\n", nftTable, set)
+			if len(elems[set]) > 0 {
+				fmt.Fprintf(&script, "add element inet %s %s { %s }\n", nftTable, set, strings.Join(elems[set], ", "))
+			}
+		}
+	}
+	if script.Len() > 0 {
+		if err := runNft(script.String()); err != nil {
+			errs = append(errs, fmt.Errorf("nftables 更新黑名单失败: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// List 列出全部端口集合中的元素
+func (b *NFTablesBackend) List() ([]RuleEntry, error) {
+	sets, err := b.listSets()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []RuleEntry
+	for _, set := range sets {
+		port, _, ok := parseSetName(set.Name)
+		if !ok {
+			continue
+		}
+		for _, raw := range set.Elem {
+			ip, ok := parseNftElem(raw)
+			if !ok {
+				continue
+			}
+			entries = append(entries, RuleEntry{
+				Binary: "nft",
+				Chain:  set.Name,
+				IP:     ip,
+				Port:   port,
+				Rule:   fmt.Sprintf("delete element inet %s %s { %s }", nftTable, set.Name, ip),
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+// Flush 清空全部封禁与黑名单集合（表不存在时视为无封禁）
+func (b *NFTablesBackend) Flush(dryRun bool) ([]RuleEntry, error) {
+	if !b.hasTable() {
+		return nil, nil
+	}
+
+	entries, err := b.List()
+	if err != nil || dryRun {
+		return entries, err
+	}
+
+	sets, err := b.listSets()
+	if err != nil {
+		return nil, err
+	}
+
+	var script strings.Builder
+	for _, set := range sets {
+		fmt.Fprintf(&script, "flush set inet %s %s\n", nftTable, set.Name)
+	}
+	if script.Len() == 0 {
+		return entries, nil
+	}
+
+	if err := runNft(script.String()); err != nil {
+		return nil, fmt.Errorf("清空 nftables 集合失败: %w", err)
+	}
+	return entries, nil
+}
+
+// Teardown 删除 inet nam 表（连同链、规则与集合）
+func (b *NFTablesBackend) Teardown() error {
+	if !b.hasTable() {
+		return nil
+	}
+
+	if err := runNft(fmt.Sprintf("delete table inet %s\n", nftTable)); err != nil {
+		return fmt.Errorf("删除 nftables 表失败: %w", err)
+	}
+
+	b.mu.Lock()
+	b.ports = make(map[int]bool)
+	b.mu.Unlock()
+
+	utils.GetLogger().Infof("已删除 nftables 表 inet %s", nftTable)
+	return nil
+}
+
+// NativeExpiry 集合元素带超时，由内核自动解封
+func (b *NFTablesBackend) NativeExpiry() bool {
+	return true
+}
+
+// ensurePort 为端口创建封禁与黑名单集合并重建 input 链中的规则
+// 链中规则按端口排序整体重写，保证每个端口每个集合恰好一条规则
+func (b *NFTablesBackend) ensurePort(port int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.ports[port] {
+		return nil
+	}
+
+	ports := make([]int, 0, len(b.ports)+1)
+	for p := range b.ports {
+		ports = append(ports, p)
+	}
+	ports = append(ports, port)
+	sort.Ints(ports)
+
+	var script strings.Builder
+	fmt.Fprintf(&script, "add set inet %s ban4_%d { type ipv4_addr; flags interval, timeout; }\n", nftTable, port)
+	fmt.Fprintf(&script, "add set inet %s ban6_%d { type ipv6_addr; flags interval, timeout; }\n", nftTable, port)
+	fmt.Fprintf(&script, "add set inet %s black4_%d { type ipv4_addr; flags interval; }\n", nftTable, port)
+	fmt.Fprintf(&script, "add set inet %s black6_%d { type ipv6_addr; flags interval; }\n", nftTable, port)
+	fmt.Fprintf(&script, "flush chain inet %s %s\n", nftTable, nftChain)
+	for _, p := range ports {
+		fmt.Fprintf(&script, "add rule inet %s %s tcp dport %d ip saddr @black4_%d counter drop\n", nftTable, nftChain, p, p)
+		fmt.Fprintf(&script, "add rule inet %s %s tcp dport %d ip6 saddr @black6_%d counter drop\n", nftTable, nftChain, p, p)
+		fmt.Fprintf(&script, "add rule inet %s %s tcp dport %d ip saddr @ban4_%d counter drop\n", nftTable, nftChain, p, p)
+		fmt.Fprintf(&script, "add rule inet %s %s tcp dport %d ip6 saddr @ban6_%d counter drop\n", nftTable, nftChain, p, p)
+	}
+
+	if err := runNft(script.String()); err != nil {
+		return fmt.Errorf("创建端口 %d 的 nftables 集合失败: %w", port, err)
+	}
+
+	b.ports[port] = true
+	return nil
+}
+
+// hasTable 检查 inet nam 表是否存在
+func (b *NFTablesBackend) hasTable() bool {
+	return exec.Command("nft", "list", "table", "inet", nftTable).Run() == nil
+}
+
+// nftSet nft -j 输出中的集合对象
+type nftSet struct {
+	Family string            `json:"family"`
+	Name   string            `json:"name"`
+	Table  string            `json:"table"`
+	Elem   []json.RawMessage `json:"elem"`
+}
+
+// listSets 读取 inet nam 表中的全部集合（含元素）
+func (b *NFTablesBackend) listSets() ([]nftSet, error) {
+	output, err := exec.Command("nft", "-j", "list", "table", "inet", nftTable).Output()
+	if err != nil {
+		return nil, fmt.Errorf("nft list table 执行失败: %w", err)
+	}
+
+	return parseNftSets(output)
+}
+
+// parseNftSets 解析 nft -j 输出中的集合
+func parseNftSets(data []byte) ([]nftSet, error) {
+	var doc struct {
+		Nftables []map[string]json.RawMessage `json:"nftables"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析 nft JSON 输出失败: %w", err)
+	}
+
+	var sets []nftSet
+	for _, obj := range doc.Nftables {
+		raw, ok := obj["set"]
+		if !ok {
+			continue
+		}
+		var set nftSet
+		if err := json.Unmarshal(raw, &set); err != nil {
+			return nil, fmt.Errorf("解析 nft 集合失败: %w", err)
+		}
+		sets = append(sets, set)
+	}
+
+	return sets, nil
+}
+
+// parseNftElem 解析集合元素，支持以下形式:
+//
+//	"192.0.2.1"
+//	{"prefix": {"addr": "192.0.2.0", "len": 24}}
+//	{"elem": {"val": <上述任一形式>, "timeout": 60, "expires": 42}}
+func parseNftElem(raw json.RawMessage) (string, bool) {
+	var addr string
+	if err := json.Unmarshal(raw, &addr); err == nil {
+		return addr, addr != ""
+	}
+
+	var obj struct {
+		Elem *struct {
+			Val json.RawMessage `json:"val"`
+		} `json:"elem"`
+		Prefix *struct {
+			Addr string `json:"addr"`
+			Len  int    `json:"len"`
+		} `json:"prefix"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return "", false
+	}
+
+	switch {
+	case obj.Elem != nil:
+		return parseNftElem(obj.Elem.Val)
+	case obj.Prefix != nil:
+		return trimHostPrefix(fmt.Sprintf("%s/%d", obj.Prefix.Addr, obj.Prefix.Len)), true
+	default:
+		return "", false
+	}
+}
+
+// parseSetName 解析集合名 ban4_<port> / ban6_<port>
+func parseSetName(name string) (port int, family int, ok bool) {
+	var rest string
+	switch {
+	case strings.HasPrefix(name, "ban4_"):
+		family, rest = 4, strings.TrimPrefix(name, "ban4_")
+	case strings.HasPrefix(name, "ban6_"):
+		family, rest = 6, strings.TrimPrefix(name, "ban6_")
+	default:
+		return 0, 0, false
+	}
+
+	port, err := strconv.Atoi(rest)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, 0, false
+	}
+	return port, family, true
+}
+
+// nftElement 按地址族返回集合名与元素文本（单个地址或前缀，IPv4-mapped 地址还原为 IPv4）
+func nftElement(ip string, port int) (string, string, error) {
+	target, err := parseBanTarget(ip)
+	if err != nil {
+		return "", "", err
+	}
+	addr := target.Addr()
+
+	if addr.Is4() {
+		return fmt.Sprintf("ban4_%d", port), targetString(target), nil
+	}
+	return fmt.Sprintf("ban6_%d", port), targetString(target), nil
+}
+
+// runNft 以单个事务执行 nft 脚本
+func runNft(script string) error {
+	cmd := exec.Command("nft", "-f", "-")
+	cmd.Stdin = strings.NewReader(script)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		utils.GetLogger().Debugf("nft 脚本:\n%s输出: %s", script, string(output))
+		return fmt.Errorf("nft 执行失败: %w（%s）", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -1,22 +1,95 @@
 package enforcer
 
 import (
-	"net"
+	"path/filepath"
 	"sort"
 
 	"github.com/nodeaccessmanager/nam/internal/config"
+	"github.com/nodeaccessmanager/nam/internal/iplist"
 	"github.com/nodeaccessmanager/nam/internal/monitor"
 )
 
 // PolicyEngine 策略引擎
 type PolicyEngine struct {
 	config *config.Config
+
+	// 编译后的名单（内联条目 + 名单文件），按前缀树匹配
+	global ruleLists
+	rules  map[int]ruleLists // port -> 规则名单
+
+	// files 名单文件缓存：文件读取失败时沿用上次成功加载的内容
+	files map[string]*iplist.Set
+
+	// allows 临时白名单（可选，由 Enforcer 设置），优先级最高
+	allows *TempAllowList
+}
+
+// ruleLists 一组白名单 / 黑名单
+type ruleLists struct {
+	whitelist *iplist.Set
+	blacklist *iplist.Set
 }
 
 // NewPolicyEngine 创建策略引擎
 func NewPolicyEngine(cfg *config.Config) *PolicyEngine {
-	return &PolicyEngine{
-		config: cfg,
+	pe := &PolicyEngine{
+		files: make(map[string]*iplist.Set),
+	}
+	pe.SetConfig(cfg)
+	return pe
+}
+
+// SetConfig 切换策略引擎使用的配置并重新读取全部名单文件（由 Enforcer 在持有写锁时调用）
+func (pe *PolicyEngine) SetConfig(cfg *config.Config) {
+	pe.config = cfg
+	pe.UpdateFiles(readListFiles(cfg.ListFiles()))
+}
+
+// UpdateFiles 更新已读取的名单文件并重新编译名单（由 Enforcer 在持有写锁时调用）
+func (pe *PolicyEngine) UpdateFiles(sets map[string]*iplist.Set) {
+	for path, set := range sets {
+		pe.files[path] = set
+	}
+	pe.compile()
+}
+
+// compile 合并内联条目与名单文件，重建各规则的前缀树
+func (pe *PolicyEngine) compile() {
+	referenced := make(map[string]bool)
+	build := func(entries, files []string) *iplist.Set {
+		set := iplist.New()
+		for _, entry := range entries {
+			if prefix, err := iplist.ParsePrefix(entry); err == nil {
+				set.Add(prefix)
+			}
+		}
+		for _, path := range files {
+			path = filepath.Clean(path)
+			referenced[path] = true
+			set.AddSet(pe.files[path])
+		}
+		return set
+	}
+
+	global := pe.config.Global
+	pe.global = ruleLists{
+		whitelist: build(global.Whitelist, global.WhitelistFiles),
+		blacklist: build(global.Blacklist, global.BlacklistFiles),
+	}
+
+	pe.rules = make(map[int]ruleLists, len(pe.config.Rules))
+	for _, rule := range pe.config.Rules {
+		pe.rules[rule.Port] = ruleLists{
+			whitelist: build(rule.Whitelist, rule.WhitelistFiles),
+			blacklist: build(rule.Blacklist, rule.BlacklistFiles),
+		}
+	}
+
+	// 不再引用的文件从缓存移除
+	for path := range pe.files {
+		if !referenced[path] {
+			delete(pe.files, path)
+		}
 	}
 }
 
@@ -42,7 +115,7 @@ func (pe *PolicyEngine) SelectVictims(
 	// 过滤白名单 IP
 	var candidates []*monitor.Session
 	for _, session := range sessions {
-		if !pe.isWhitelisted(rule, session.IP) {
+		if !pe.isSessionWhitelisted(rule, session) {
 			candidates = append(candidates, session)
 		}
 	}
@@ -88,51 +161,107 @@ func (pe *PolicyEngine) SelectVictims(
 	}
 }
 
-// isWhitelisted 检查 IP 是否在白名单
-func (pe *PolicyEngine) isWhitelisted(rule *config.Rule, ip string) bool {
-	for _, cidr := range rule.Whitelist {
-		if matchCIDR(ip, cidr) {
+// isSessionWhitelisted 检查会话是否在白名单（聚合会话中任一地址在白名单即整体豁免）
+func (pe *PolicyEngine) isSessionWhitelisted(rule *config.Rule, session *monitor.Session) bool {
+	if pe.isWhitelisted(rule, session.IP) {
+		return true
+	}
+	for _, addr := range session.Addresses {
+		if pe.isWhitelisted(rule, addr) {
 			return true
 		}
 	}
 	return false
 }
 
-// IsBlacklisted 检查 IP 是否在黑名单
+// listVerdict 名单判定结果
+type listVerdict int
+
+const (
+	verdictNone listVerdict = iota
+	verdictWhitelisted
+	verdictBlacklisted
+)
+
+// classify 按优先级判定 IP 所属名单: 临时白名单 > 规则白名单 > 规则黑名单 > 全局白名单 > 全局黑名单
+// 聚合前缀只有整体落在名单条目内时才算命中
+func (pe *PolicyEngine) classify(rule *config.Rule, ip string) listVerdict {
+	target, err := parseBanTarget(ip)
+	if err != nil {
+		return verdictNone
+	}
+
+	lists := pe.rules[rule.Port]
+	switch {
+	case pe.allows.Contains(rule.Port, target):
+		return verdictWhitelisted
+	case lists.whitelist.ContainsPrefix(target):
+		return verdictWhitelisted
+	case lists.blacklist.ContainsPrefix(target):
+		return verdictBlacklisted
+	case pe.global.whitelist.ContainsPrefix(target):
+		return verdictWhitelisted
+	case pe.global.blacklist.ContainsPrefix(target):
+		return verdictBlacklisted
+	default:
+		return verdictNone
+	}
+}
+
+// isWhitelisted 检查 IP 是否在白名单（含全局白名单）
+func (pe *PolicyEngine) isWhitelisted(rule *config.Rule, ip string) bool {
+	return pe.classify(rule, ip) == verdictWhitelisted
+}
+
+// IsBlacklisted 检查 IP 是否在黑名单（含全局黑名单）
 func (pe *PolicyEngine) IsBlacklisted(port int, ip string) bool {
 	rule := pe.config.GetRuleByPort(port)
 	if rule == nil {
 		return false
 	}
+	return pe.classify(rule, ip) == verdictBlacklisted
+}
 
-	for _, cidr := range rule.Blacklist {
-		if matchCIDR(ip, cidr) {
-			return true
+// BlacklistEntries 返回端口需要下发为防火墙封禁的黑名单条目（规则黑名单 + 全局黑名单，含名单文件）
+// 与更高优先级的白名单有交集的条目不下发：防火墙无法表达"网段封禁但其中部分地址放行"，
+// 这些条目只在采集时按优先级判定并断开连接。
+func (pe *PolicyEngine) BlacklistEntries(port int) (entries []string, skipped []string) {
+	lists, ok := pe.rules[port]
+	if !ok {
+		return nil, nil
+	}
+
+	for _, prefix := range lists.blacklist.Prefixes() {
+		if lists.whitelist.Overlaps(prefix) {
+			skipped = append(skipped, targetString(prefix))
+			continue
 		}
+		entries = append(entries, targetString(prefix))
 	}
-	return false
-}
 
-// matchCIDR 检查 IP 是否匹配 CIDR
-func matchCIDR(ip, cidr string) bool {
-	// 尝试解析为单个 IP
-	if ip == cidr {
-		return true
+	for _, prefix := range pe.global.blacklist.Prefixes() {
+		if lists.whitelist.Overlaps(prefix) || pe.global.whitelist.Overlaps(prefix) {
+			skipped = append(skipped, targetString(prefix))
+			continue
+		}
+		entries = append(entries, targetString(prefix))
 	}
 
-	// 尝试解析为 CIDR
-	_, ipNet, err := net.ParseCIDR(cidr)
+	return entries, skipped
+}
+
+// matchCIDR 检查 IP（或聚合前缀）是否落在 CIDR 内（IPv4 / IPv6 均可）
+// 前缀只有整体包含在 CIDR 内时才算匹配
+func matchCIDR(ip, cidr string) bool {
+	target, err := parseBanTarget(ip)
 	if err != nil {
-		// 不是有效的 CIDR，尝试作为单个 IP 比较
 		return ip == cidr
 	}
 
-	// 解析目标 IP
-	targetIP := net.ParseIP(ip)
-	if targetIP == nil {
+	network, err := parseBanTarget(cidr)
+	if err != nil {
 		return false
 	}
 
-	// 检查是否在网段内
-	return ipNet.Contains(targetIP)
+	return network.Bits() <= target.Bits() && network.Contains(target.Addr())
 }
@@ -0,0 +1,205 @@
+package enforcer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/clock"
+	"github.com/nodeaccessmanager/nam/internal/config"
+	"github.com/nodeaccessmanager/nam/internal/monitor"
+)
+
+func TestSelectVictims(t *testing.T) {
+	// 192.0.2.1 最早连接，192.0.2.4 最晚
+	arrivals := []string{"192.0.2.1", "192.0.2.2", "192.0.2.3", "192.0.2.4"}
+
+	tests := []struct {
+		name         string
+		global       config.Strategy
+		rule         config.Strategy
+		whitelist    []string
+		overlimit    int
+		wantStrategy string
+		wantVictims  []string
+	}{
+		{
+			name:         "FIFO 驱逐最早的会话",
+			global:       config.StrategyFIFO,
+			overlimit:    2,
+			wantStrategy: "FIFO",
+			wantVictims:  []string{"192.0.2.1", "192.0.2.2"},
+		},
+		{
+			name:         "LIFO 驱逐最新的会话",
+			global:       config.StrategyLIFO,
+			overlimit:    2,
+			wantStrategy: "LIFO",
+			wantVictims:  []string{"192.0.2.4", "192.0.2.3"},
+		},
+		{
+			name:         "规则策略覆盖全局策略",
+			global:       config.StrategyFIFO,
+			rule:         config.StrategyLIFO,
+			overlimit:    1,
+			wantStrategy: "LIFO",
+			wantVictims:  []string{"192.0.2.4"},
+		},
+		{
+			name:         "白名单会话不参与驱逐",
+			global:       config.StrategyFIFO,
+			whitelist:    []string{"192.0.2.1"},
+			overlimit:    1,
+			wantStrategy: "FIFO",
+			wantVictims:  []string{"192.0.2.2"},
+		},
+		{
+			name:         "候选不足时驱逐全部候选",
+			global:       config.StrategyLIFO,
+			whitelist:    []string{"192.0.2.0/30"},
+			overlimit:    2,
+			wantStrategy: "LIFO",
+			wantVictims:  []string{"192.0.2.4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const port = 8443
+			cfg := testConfig(config.Rule{Port: port, MaxIPs: len(arrivals) - tt.overlimit, Strategy: tt.rule, Whitelist: tt.whitelist})
+			cfg.Global.Strategy = tt.global
+			pe := NewPolicyEngine(cfg)
+
+			clk := clock.NewFake(testStart)
+			tracker := monitor.NewPortTrackerWithClock(port, clk)
+			connectInOrder(clk, tracker, port, arrivals...)
+
+			// 会话的 FirstSeenAt 来自假时钟，与连接顺序一致
+			sessions := tracker.GetActiveSessions()
+			for _, session := range sessions {
+				for i, ip := range arrivals {
+					if session.IP == ip && !session.FirstSeenAt.Equal(testStart.Add(time.Duration(i)*time.Second)) {
+						t.Fatalf("%s FirstSeenAt = %v", ip, session.FirstSeenAt)
+					}
+				}
+			}
+
+			selection := pe.SelectVictims(port, sessions, tt.overlimit)
+			if !reflect.DeepEqual(selection.Victims, tt.wantVictims) {
+				t.Errorf("Victims = %v, want %v", selection.Victims, tt.wantVictims)
+			}
+			if selection.Strategy != tt.wantStrategy {
+				t.Errorf("Strategy = %q, want %q", selection.Strategy, tt.wantStrategy)
+			}
+			if selection.Total != len(arrivals) || selection.Overlimit != tt.overlimit {
+				t.Errorf("Total/Overlimit = %d/%d, want %d/%d", selection.Total, selection.Overlimit, len(arrivals), tt.overlimit)
+			}
+		})
+	}
+}
+
+func TestSelectVictimsUnknownPort(t *testing.T) {
+	pe := NewPolicyEngine(testConfig(config.Rule{Port: 8443, MaxIPs: 1}))
+	selection := pe.SelectVictims(9443, []*monitor.Session{{IP: "192.0.2.1"}}, 1)
+	if len(selection.Victims) != 0 || selection.Strategy != "UNKNOWN" {
+		t.Errorf("SelectVictims() = %+v, want no victims", selection)
+	}
+}
+
+func TestListPrecedence(t *testing.T) {
+	// 优先级: 规则白名单 > 规则黑名单 > 全局白名单 > 全局黑名单
+	const port, ip = 8443, "192.0.2.1"
+
+	tests := []struct {
+		name          string
+		ruleWhite     []string
+		ruleBlack     []string
+		globalWhite   []string
+		globalBlack   []string
+		wantWhite     bool
+		wantBlacklist bool
+	}{
+		{name: "不在任何名单"},
+		{name: "只在全局黑名单", globalBlack: []string{"192.0.2.0/24"}, wantBlacklist: true},
+		{name: "只在全局白名单", globalWhite: []string{ip}, wantWhite: true},
+		{name: "全局白名单优先于全局黑名单", globalWhite: []string{ip}, globalBlack: []string{"192.0.2.0/24"}, wantWhite: true},
+		{name: "规则黑名单优先于全局白名单", ruleBlack: []string{ip}, globalWhite: []string{"192.0.2.0/24"}, wantBlacklist: true},
+		{name: "规则白名单优先于规则黑名单", ruleWhite: []string{ip}, ruleBlack: []string{"192.0.2.0/24"}, wantWhite: true},
+		{name: "规则白名单优先于全局黑名单", ruleWhite: []string{"192.0.2.0/24"}, globalBlack: []string{ip}, wantWhite: true},
+		{
+			name:          "规则黑名单优先于全局白名单与全局黑名单",
+			ruleBlack:     []string{"192.0.2.0/24"},
+			globalWhite:   []string{ip},
+			globalBlack:   []string{"0.0.0.0/0"},
+			wantBlacklist: true,
+		},
+		{
+			name:        "四个名单同时命中时规则白名单生效",
+			ruleWhite:   []string{ip},
+			ruleBlack:   []string{ip},
+			globalWhite: []string{ip},
+			globalBlack: []string{ip},
+			wantWhite:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(config.Rule{Port: port, MaxIPs: 1, Whitelist: tt.ruleWhite, Blacklist: tt.ruleBlack})
+			cfg.Global.Whitelist = tt.globalWhite
+			cfg.Global.Blacklist = tt.globalBlack
+			pe := NewPolicyEngine(cfg)
+
+			if got := pe.isWhitelisted(cfg.GetRuleByPort(port), ip); got != tt.wantWhite {
+				t.Errorf("isWhitelisted() = %v, want %v", got, tt.wantWhite)
+			}
+			if got := pe.IsBlacklisted(port, ip); got != tt.wantBlacklist {
+				t.Errorf("IsBlacklisted() = %v, want %v", got, tt.wantBlacklist)
+			}
+		})
+	}
+}
+
+func TestBlacklistEntriesSkipWhitelisted(t *testing.T) {
+	const port = 8443
+	cfg := testConfig(config.Rule{
+		Port:      port,
+		MaxIPs:    10,
+		Whitelist: []string{"203.0.113.9"},
+		Blacklist: []string{"10.0.0.0/8"},
+	})
+	cfg.Global.Whitelist = []string{"198.51.100.5", "10.1.1.1"}
+	cfg.Global.Blacklist = []string{"192.0.2.0/24", "198.51.100.0/24", "203.0.113.0/24"}
+
+	wantEntries := []string{
+		"10.0.0.0/8",   // 规则黑名单优先于全局白名单，整体下发
+		"192.0.2.0/24", // 与任何白名单无交集
+	}
+	wantSkipped := []string{
+		"198.51.100.0/24", // 与全局白名单有交集
+		"203.0.113.0/24",  // 与规则白名单有交集
+	}
+
+	entries, skipped := NewPolicyEngine(cfg).BlacklistEntries(port)
+	if !reflect.DeepEqual(entries, wantEntries) {
+		t.Errorf("entries = %v, want %v", entries, wantEntries)
+	}
+	if !reflect.DeepEqual(skipped, wantSkipped) {
+		t.Errorf("skipped = %v, want %v", skipped, wantSkipped)
+	}
+
+	// 跳过的条目不下发到防火墙，只在采集时按优先级断开
+	backend := NewMemoryBackend()
+	e, _, _ := newTestEnforcer(t, cfg, backend, NewMemoryKiller())
+	if err := e.SyncBlacklists(); err != nil {
+		t.Fatalf("SyncBlacklists() error = %v", err)
+	}
+	if got := backend.Blacklist(port); !reflect.DeepEqual(got, wantEntries) {
+		t.Errorf("Blacklist() = %v, want %v", got, wantEntries)
+	}
+	for ip, want := range map[string]bool{"198.51.100.7": true, "198.51.100.5": false, "203.0.113.1": true, "203.0.113.9": false} {
+		if got := e.CheckBlacklist(ip, port); got != want {
+			t.Errorf("CheckBlacklist(%s) = %v, want %v", ip, got, want)
+		}
+	}
+}
@@ -20,3 +20,81 @@ type VictimSelection struct {
 	Total    int      `json:"total"`    // 总会话数
 	Overlimit int     `json:"overlimit"` // 超限数量
 }
+
+// 封禁策略标识（BanRecord.Strategy 中除 FIFO/LIFO 外的取值）
+const (
+	StrategyManual    = "MANUAL"
+	StrategyBlacklist = "BLACKLIST" // 黑名单命中（只记入历史，不是封禁）
+)
+
+// 封禁原因
+const (
+	ReasonBlacklist = "blacklist"
+)
+
+// 执行事件类型
+const (
+	EventBan       = "ban"       // 登记封禁（含刷新期限）
+	EventUnban     = "unban"     // 解除封禁（到期或手动）
+	EventBlacklist = "blacklist" // 断开黑名单连接
+	EventKick      = "kick"      // 手动断开连接（不封禁）
+	EventAllow     = "allow"     // 加入临时白名单
+)
+
+// Event 执行事件（由 Enforcer.SetEventCallback 订阅）
+// 解封事件的 Record 为原封禁记录；黑名单事件为写入历史的命中记录（无期限），断开事件只填充 IP、Port 与 Reason；
+// 临时白名单事件的 BannedAt / ExpireAt / Duration 为加入时间、到期时间与时长
+type Event struct {
+	Type   string
+	Record BanRecord
+}
+
+// BanStore 活跃封禁的持久化存储（由 storage.Database 实现）
+type BanStore interface {
+	// SaveActiveBan 保存或更新一条活跃封禁
+	SaveActiveBan(record *BanRecord) error
+	// DeleteActiveBan 删除一条活跃封禁
+	DeleteActiveBan(ip string, port int) error
+	// LoadActiveBans 读取全部活跃封禁
+	LoadActiveBans() ([]BanRecord, error)
+	// RecordBan 写入封禁历史
+	RecordBan(record *BanRecord) error
+}
+
+// AllowRecord 临时白名单记录（到期自动失效）
+type AllowRecord struct {
+	IP       string    `json:"ip"` // IP 或 CIDR
+	Port     int       `json:"port"`
+	AddedAt  time.Time `json:"added_at"`
+	ExpireAt time.Time `json:"expire_at"`
+	Reason   string    `json:"reason"`
+}
+
+// AllowStore 临时白名单的持久化存储（由 storage.Database 实现）
+type AllowStore interface {
+	// SaveTempAllow 保存或更新一条临时白名单
+	SaveTempAllow(record *AllowRecord) error
+	// DeleteTempAllow 删除一条临时白名单
+	DeleteTempAllow(ip string, port int) error
+	// LoadTempAllows 读取全部临时白名单
+	LoadTempAllows() ([]AllowRecord, error)
+}
+
+// ReconcileReport 启动对账结果
+type ReconcileReport struct {
+	Restored  int `json:"restored"`  // 恢复定时器的封禁
+	Reapplied int `json:"reapplied"` // 内核中缺失、重新下发的封禁
+	Expired   int `json:"expired"`   // 已过期、立即解除的封禁
+	Orphaned  int `json:"orphaned"`  // 无记录、被删除的 NAM 规则
+}
+
+// RuleEntry 一条由 NAM 创建的防火墙规则（用于清理与展示）
+type RuleEntry struct {
+	Binary  string `json:"binary"` // iptables / ip6tables / nft
+	Chain   string `json:"chain"`  // iptables 链名或 nftables 集合名
+	IP      string `json:"ip"`
+	Port    int    `json:"port"`
+	Packets uint64 `json:"packets"` // 命中的数据包数
+	Bytes   uint64 `json:"bytes"`   // 命中的字节数
+	Rule    string `json:"rule"`    // 规则参数原文
+}
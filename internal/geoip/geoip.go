@@ -0,0 +1,161 @@
+// Package geoip 提供基于本地 CSV 数据文件的 IP 国家/地区查询
+package geoip
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nodeaccessmanager/nam/internal/iplist"
+)
+
+// ipRange 连续地址段（同一地址族）
+type ipRange struct {
+	start   netip.Addr
+	end     netip.Addr
+	country string
+}
+
+// DB 按起始地址排序的地址段表，查询为二分查找
+type DB struct {
+	ranges []ipRange
+}
+
+// Open 读取 GeoIP 数据文件
+// 每行为 "起始IP,结束IP,国家代码"（兼容 db-ip / ip2location lite 等 CSV，IPv4 也可写成十进制整数）
+// 或 "CIDR,国家代码"；# 开头的行与空行忽略，无法解析的行跳过。
+func Open(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开 GeoIP 数据文件失败: %w", err)
+	}
+	defer f.Close()
+
+	db, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("读取 GeoIP 数据文件 %s 失败: %w", path, err)
+	}
+	return db, nil
+}
+
+// Parse 从 Reader 解析 GeoIP 数据（格式同 Open）
+func Parse(r io.Reader) (*DB, error) {
+	db := &DB{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields, err := csv.NewReader(strings.NewReader(line)).Read()
+		if err != nil {
+			continue
+		}
+		if rng, ok := parseRange(fields); ok {
+			db.ranges = append(db.ranges, rng)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return db.ranges[i].start.Less(db.ranges[j].start)
+	})
+	return db, nil
+}
+
+// parseRange 解析一行记录
+func parseRange(fields []string) (ipRange, bool) {
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	var rng ipRange
+	switch {
+	case len(fields) >= 3:
+		start, ok1 := parseAddr(fields[0])
+		end, ok2 := parseAddr(fields[1])
+		if !ok1 || !ok2 || start.Is4() != end.Is4() || end.Less(start) {
+			return ipRange{}, false
+		}
+		rng = ipRange{start: start, end: end, country: fields[2]}
+	case len(fields) == 2:
+		prefix, err := iplist.ParsePrefix(fields[0])
+		if err != nil {
+			return ipRange{}, false
+		}
+		rng = ipRange{start: prefix.Addr(), end: lastAddr(prefix), country: fields[1]}
+	default:
+		return ipRange{}, false
+	}
+
+	rng.country = strings.ToUpper(rng.country)
+	if rng.country == "" || rng.country == "-" || rng.country == "ZZ" {
+		return ipRange{}, false
+	}
+	return rng, true
+}
+
+// parseAddr 解析 IP 地址或十进制 IPv4 整数
+func parseAddr(s string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return addr.Unmap().WithZone(""), true
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return netip.AddrFrom4([4]byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}), true
+}
+
+// lastAddr 前缀内的最后一个地址
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	b := prefix.Addr().AsSlice()
+	for i := prefix.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// Len 地址段数量
+func (db *DB) Len() int {
+	if db == nil {
+		return 0
+	}
+	return len(db.ranges)
+}
+
+// Lookup 查询 IP 所属国家/地区代码，未收录或无法解析时返回空字符串
+func (db *DB) Lookup(ip string) string {
+	if db == nil {
+		return ""
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap().WithZone("")
+
+	// 最后一个起始地址 <= addr 的地址段
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return addr.Less(db.ranges[i].start)
+	}) - 1
+	if i < 0 {
+		return ""
+	}
+	rng := db.ranges[i]
+	if rng.start.Is4() != addr.Is4() || rng.end.Less(addr) {
+		return ""
+	}
+	return rng.country
+}
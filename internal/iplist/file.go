@@ -0,0 +1,83 @@
+package iplist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// ParseError 名单文件中无法解析的行
+type ParseError struct {
+	Line int
+	Text string
+}
+
+// ParseFile 读取名单文件
+// 每行一个 IP 或 CIDR；# 与 ; 之后为注释（兼容常见的滥用 IP 订阅格式），空行忽略。
+// 无法解析的行跳过并在 invalid 中返回，文件本身无法读取时返回 error。
+func ParseFile(path string) (set *Set, invalid []ParseError, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("打开名单文件失败: %w", err)
+	}
+	defer f.Close()
+
+	set, invalid, err = Parse(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取名单文件 %s 失败: %w", path, err)
+	}
+	return set, invalid, nil
+}
+
+// Parse 从 Reader 解析名单（格式同 ParseFile）
+func Parse(r io.Reader) (*Set, []ParseError, error) {
+	set := New()
+	var invalid []ParseError
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if i := strings.IndexAny(line, "#;"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		prefix, err := ParsePrefix(fields[0])
+		if err != nil {
+			invalid = append(invalid, ParseError{Line: lineNo, Text: fields[0]})
+			continue
+		}
+		set.Add(prefix)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return set, invalid, nil
+}
+
+// ParsePrefix 解析单个 IP（视为 /32、/128）或 CIDR
+func ParsePrefix(s string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		addr = addr.Unmap().WithZone("")
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("既不是有效的 IP 也不是有效的 CIDR: %s", s)
+	}
+	prefix, ok := normalize(prefix)
+	if !ok {
+		return netip.Prefix{}, fmt.Errorf("无效的 CIDR: %s", s)
+	}
+	return prefix, nil
+}
@@ -0,0 +1,91 @@
+package iplist
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePrefix(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "192.0.2.1", want: "192.0.2.1/32"},
+		{input: "192.0.2.77/24", want: "192.0.2.0/24"}, // 清除主机位
+		{input: "2001:db8::1", want: "2001:db8::1/128"},
+		{input: "::ffff:192.0.2.1", want: "192.0.2.1/32"},
+		{input: "::ffff:192.0.2.0/120", want: "192.0.2.0/24"},
+		{input: "fe80::1%eth0", want: "fe80::1/128"},
+		{input: "::ffff:0:0/64", wantErr: true}, // IPv4-mapped 前缀短于 /96
+		{input: "192.0.2.1/33", wantErr: true},
+		{input: "example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePrefix(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParsePrefix(%q) = %s, want error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("ParsePrefix(%q) = %s, %v, want %s", tt.input, got, err, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	input := `# 滥用 IP 订阅
+192.0.2.1
+198.51.100.0/24 ; 注释
+  2001:db8::/32   extra fields ignored
+
+not-an-ip
+10.0.0.0/40 # 无效前缀
+`
+	set, invalid, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if set.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", set.Len())
+	}
+	for _, addr := range []string{"192.0.2.1", "198.51.100.200", "2001:db8::5"} {
+		if !set.Contains(netip.MustParseAddr(addr)) {
+			t.Errorf("Contains(%s) = false", addr)
+		}
+	}
+
+	want := []ParseError{{Line: 6, Text: "not-an-ip"}, {Line: 7, Text: "10.0.0.0/40"}}
+	if !reflect.DeepEqual(invalid, want) {
+		t.Errorf("invalid = %+v, want %+v", invalid, want)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blacklist.txt")
+	if err := os.WriteFile(path, []byte("203.0.113.0/24\nbad\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	set, invalid, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	if !set.Contains(netip.MustParseAddr("203.0.113.9")) {
+		t.Error("文件中的前缀未加载")
+	}
+	if len(invalid) != 1 || invalid[0].Line != 2 {
+		t.Errorf("invalid = %+v", invalid)
+	}
+
+	if _, _, err := ParseFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("文件不存在时应返回错误")
+	}
+}
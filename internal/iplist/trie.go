@@ -0,0 +1,195 @@
+// Package iplist 提供 IP/CIDR 名单：前缀树匹配、名单文件解析与 inotify 文件监听
+package iplist
+
+import (
+	"net/netip"
+)
+
+// node 二叉前缀树节点
+type node struct {
+	child    [2]*node
+	terminal bool // 从根到此节点的路径是一个已加入的前缀
+}
+
+// Set 基于二叉前缀树的 IP/CIDR 集合，IPv4 与 IPv6 各一棵树
+// 匹配开销只与地址长度有关，与条目数量无关
+type Set struct {
+	v4  *node
+	v6  *node
+	len int
+}
+
+// New 创建空集合
+func New() *Set {
+	return &Set{v4: &node{}, v6: &node{}}
+}
+
+// Len 加入的不同前缀数量
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.len
+}
+
+// Add 加入前缀（IPv4-mapped 前缀按 IPv4 处理）
+func (s *Set) Add(prefix netip.Prefix) {
+	prefix, ok := normalize(prefix)
+	if !ok {
+		return
+	}
+
+	n := s.root(prefix.Addr())
+	bytes := prefix.Addr().As16()
+	offset := bitOffset(prefix.Addr())
+	for i := 0; i < prefix.Bits(); i++ {
+		b := bit(bytes, offset+i)
+		if n.child[b] == nil {
+			n.child[b] = &node{}
+		}
+		n = n.child[b]
+	}
+
+	if !n.terminal {
+		n.terminal = true
+		s.len++
+	}
+}
+
+// AddSet 合并另一个集合的全部前缀
+func (s *Set) AddSet(other *Set) {
+	for _, prefix := range other.Prefixes() {
+		s.Add(prefix)
+	}
+}
+
+// Contains 检查地址是否落在任一前缀内
+func (s *Set) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return s.ContainsPrefix(netip.PrefixFrom(addr, addr.BitLen()))
+}
+
+// ContainsPrefix 检查前缀是否整体落在某个已加入的前缀内
+func (s *Set) ContainsPrefix(prefix netip.Prefix) bool {
+	if s == nil {
+		return false
+	}
+	prefix, ok := normalize(prefix)
+	if !ok {
+		return false
+	}
+
+	n := s.root(prefix.Addr())
+	bytes := prefix.Addr().As16()
+	offset := bitOffset(prefix.Addr())
+	for i := 0; i < prefix.Bits(); i++ {
+		if n.terminal {
+			return true
+		}
+		n = n.child[bit(bytes, offset+i)]
+		if n == nil {
+			return false
+		}
+	}
+	return n.terminal
+}
+
+// Overlaps 检查前缀与集合是否有交集（包含某个已加入前缀，或被其包含）
+func (s *Set) Overlaps(prefix netip.Prefix) bool {
+	if s == nil {
+		return false
+	}
+	prefix, ok := normalize(prefix)
+	if !ok {
+		return false
+	}
+
+	n := s.root(prefix.Addr())
+	bytes := prefix.Addr().As16()
+	offset := bitOffset(prefix.Addr())
+	for i := 0; i < prefix.Bits(); i++ {
+		if n.terminal {
+			return true
+		}
+		n = n.child[bit(bytes, offset+i)]
+		if n == nil {
+			return false
+		}
+	}
+
+	// 节点只存在于通往某个前缀的路径上，到达即说明其下有已加入的前缀
+	return true
+}
+
+// Prefixes 返回集合中的前缀（已被更短前缀覆盖的条目省略），IPv4 在前
+func (s *Set) Prefixes() []netip.Prefix {
+	if s == nil {
+		return nil
+	}
+
+	var prefixes []netip.Prefix
+	var path [16]byte
+	collect(s.v4, true, path, 0, &prefixes)
+	collect(s.v6, false, path, 0, &prefixes)
+	return prefixes
+}
+
+// collect 深度优先收集前缀
+func collect(n *node, is4 bool, path [16]byte, depth int, out *[]netip.Prefix) {
+	if n == nil {
+		return
+	}
+	if n.terminal {
+		if is4 {
+			*out = append(*out, netip.PrefixFrom(netip.AddrFrom4([4]byte(path[:4])), depth))
+		} else {
+			*out = append(*out, netip.PrefixFrom(netip.AddrFrom16(path), depth))
+		}
+		return
+	}
+
+	for b := 0; b < 2; b++ {
+		child := path
+		if b == 1 {
+			child[depth/8] |= 0x80 >> (depth % 8)
+		}
+		collect(n.child[b], is4, child, depth+1, out)
+	}
+}
+
+// root 按地址族选择树根
+func (s *Set) root(addr netip.Addr) *node {
+	if addr.Is4() {
+		return s.v4
+	}
+	return s.v6
+}
+
+// normalize 还原 IPv4-mapped 前缀并清除主机位
+func normalize(prefix netip.Prefix) (netip.Prefix, bool) {
+	if !prefix.IsValid() {
+		return netip.Prefix{}, false
+	}
+
+	addr, bits := prefix.Addr().WithZone(""), prefix.Bits()
+	if addr.Is4In6() {
+		if bits < 96 {
+			return netip.Prefix{}, false
+		}
+		addr, bits = addr.Unmap(), bits-96
+	}
+	return netip.PrefixFrom(addr, bits).Masked(), true
+}
+
+// bitOffset IPv4 地址在 As16 表示中从第 96 位开始
+func bitOffset(addr netip.Addr) int {
+	if addr.Is4() {
+		return 96
+	}
+	return 0
+}
+
+// bit 取第 i 位（0 为最高位）
+func bit(bytes [16]byte, i int) int {
+	return int(bytes[i/8]>>(7-i%8)) & 1
+}
@@ -0,0 +1,103 @@
+package iplist
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func mustSet(t *testing.T, entries ...string) *Set {
+	t.Helper()
+	set := New()
+	for _, entry := range entries {
+		prefix, err := ParsePrefix(entry)
+		if err != nil {
+			t.Fatalf("ParsePrefix(%q): %v", entry, err)
+		}
+		set.Add(prefix)
+	}
+	return set
+}
+
+func TestSetContains(t *testing.T) {
+	set := mustSet(t, "10.0.0.0/8", "192.0.2.7", "2001:db8::/32", "::ffff:198.51.100.0/120")
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"11.0.0.1", false},
+		{"192.0.2.7", true},
+		{"192.0.2.8", false},
+		{"::ffff:10.0.0.1", true}, // IPv4-mapped 按 IPv4 匹配
+		{"198.51.100.9", true},    // IPv4-mapped 前缀按 IPv4 加入
+		{"2001:db8:1::1", true},
+		{"2001:db9::1", false},
+		{"::a00:1", false}, // IPv4 与 IPv6 互不影响
+	}
+	for _, tt := range tests {
+		if got := set.Contains(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("Contains(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestSetContainsPrefixAndOverlaps(t *testing.T) {
+	set := mustSet(t, "10.0.0.0/16", "10.1.2.0/24")
+
+	tests := []struct {
+		prefix   string
+		contains bool
+		overlaps bool
+	}{
+		{"10.0.5.0/24", true, true},  // 整体落在 10.0.0.0/16 内
+		{"10.0.0.0/16", true, true},  // 与条目相同
+		{"10.0.0.0/15", false, true}, // 包含条目
+		{"10.1.0.0/16", false, true}, // 包含 10.1.2.0/24
+		{"10.1.2.128/25", true, true},
+		{"10.2.0.0/16", false, false},
+		{"0.0.0.0/0", false, true},
+	}
+	for _, tt := range tests {
+		prefix := netip.MustParsePrefix(tt.prefix)
+		if got := set.ContainsPrefix(prefix); got != tt.contains {
+			t.Errorf("ContainsPrefix(%s) = %v, want %v", tt.prefix, got, tt.contains)
+		}
+		if got := set.Overlaps(prefix); got != tt.overlaps {
+			t.Errorf("Overlaps(%s) = %v, want %v", tt.prefix, got, tt.overlaps)
+		}
+	}
+}
+
+func TestSetPrefixes(t *testing.T) {
+	// 被更短前缀覆盖的条目省略，重复条目只计一次，IPv4 在前
+	set := mustSet(t, "2001:db8::/32", "10.1.0.0/16", "10.0.0.0/8", "192.0.2.1", "192.0.2.1", "2001:db8:1::/48")
+
+	got := make([]string, 0)
+	for _, prefix := range set.Prefixes() {
+		got = append(got, prefix.String())
+	}
+	want := []string{"10.0.0.0/8", "192.0.2.1/32", "2001:db8::/32"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Prefixes() = %v, want %v", got, want)
+	}
+	if set.Len() != 5 {
+		t.Errorf("Len() = %d, want 5", set.Len())
+	}
+}
+
+func TestNilSet(t *testing.T) {
+	var set *Set
+	if set.Len() != 0 || set.ContainsPrefix(netip.MustParsePrefix("10.0.0.0/8")) ||
+		set.Overlaps(netip.MustParsePrefix("10.0.0.0/8")) || set.Prefixes() != nil {
+		t.Error("nil Set 应视为空集合")
+	}
+
+	merged := mustSet(t, "10.0.0.0/8")
+	merged.AddSet(nil)
+	merged.AddSet(mustSet(t, "192.0.2.0/24"))
+	if merged.Len() != 2 || !merged.Contains(netip.MustParseAddr("192.0.2.1")) {
+		t.Errorf("AddSet 合并结果错误: %v", merged.Prefixes())
+	}
+}
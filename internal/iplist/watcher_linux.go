@@ -0,0 +1,202 @@
+//go:build linux
+
+package iplist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// debounceDelay 合并短时间内的多次写入（编辑器保存、订阅脚本分块写入）
+const debounceDelay = 500 * time.Millisecond
+
+// watchMask 监听父目录：直接写入、原子替换（rename）与删除后重建都能捕获
+const watchMask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_CREATE | syscall.IN_DELETE
+
+// Watcher 基于 inotify 监听名单文件变更，合并后回调 onChange
+type Watcher struct {
+	file     *os.File
+	fd       int
+	onChange func(paths []string)
+
+	mu      sync.Mutex
+	files   map[string]bool // 监听的文件
+	dirs    map[string]int  // 目录 -> watch descriptor
+	wds     map[int]string  // watch descriptor -> 目录
+	pending map[string]bool // 等待回调的变更文件
+	timer   *time.Timer
+	closed  bool
+
+	done chan struct{}
+}
+
+// NewWatcher 创建文件监听器，需调用 SetFiles 指定监听的文件
+func NewWatcher(onChange func(paths []string)) (*Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("初始化 inotify 失败: %w", err)
+	}
+
+	w := &Watcher{
+		// 非阻塞 fd 交给运行时 poller，Close 可以中断阻塞中的 Read
+		file:     os.NewFile(uintptr(fd), "inotify"),
+		fd:       fd,
+		onChange: onChange,
+		files:    make(map[string]bool),
+		dirs:     make(map[string]int),
+		wds:      make(map[int]string),
+		pending:  make(map[string]bool),
+		done:     make(chan struct{}),
+	}
+
+	go w.readLoop()
+	return w, nil
+}
+
+// SetFiles 替换监听的文件集合（热重载时调用）
+func (w *Watcher) SetFiles(paths []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	files := make(map[string]bool, len(paths))
+	dirs := make(map[string]bool)
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		files[path] = true
+		dirs[filepath.Dir(path)] = true
+	}
+
+	// 移除不再需要的目录监听
+	for dir, wd := range w.dirs {
+		if dirs[dir] {
+			continue
+		}
+		syscall.InotifyRmWatch(w.fd, uint32(wd))
+		delete(w.dirs, dir)
+		delete(w.wds, wd)
+	}
+
+	// 添加新目录监听
+	var firstErr error
+	for dir := range dirs {
+		if _, ok := w.dirs[dir]; ok {
+			continue
+		}
+		wd, err := syscall.InotifyAddWatch(w.fd, dir, watchMask)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("监听目录 %s 失败: %w", dir, err)
+			}
+			continue
+		}
+		w.dirs[dir] = wd
+		w.wds[wd] = dir
+	}
+
+	w.files = files
+	return firstErr
+}
+
+// Close 停止监听
+func (w *Watcher) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+
+	err := w.file.Close()
+	<-w.done
+	return err
+}
+
+// readLoop 读取 inotify 事件，直到 Close
+func (w *Watcher) readLoop() {
+	defer close(w.done)
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(event.Len)
+			if nameEnd > n {
+				break
+			}
+			name := trimNul(buf[nameStart:nameEnd])
+			w.handleEvent(int(event.Wd), event.Mask, name)
+			offset = nameEnd
+		}
+	}
+}
+
+// handleEvent 处理单个事件：命中监听文件时记录并推迟回调
+func (w *Watcher) handleEvent(wd int, mask uint32, name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	dir, ok := w.wds[wd]
+	if !ok {
+		return
+	}
+
+	// 目录被删除或卸载，监听已失效
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(w.wds, wd)
+		delete(w.dirs, dir)
+		return
+	}
+
+	path := filepath.Join(dir, name)
+	if name == "" || !w.files[path] || w.closed {
+		return
+	}
+
+	w.pending[path] = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(debounceDelay, w.flush)
+}
+
+// flush 回调已合并的变更
+func (w *Watcher) flush() {
+	w.mu.Lock()
+	if w.closed || len(w.pending) == 0 {
+		w.mu.Unlock()
+		return
+	}
+	paths := make([]string, 0, len(w.pending))
+	for path := range w.pending {
+		paths = append(paths, path)
+	}
+	w.pending = make(map[string]bool)
+	w.mu.Unlock()
+
+	w.onChange(paths)
+}
+
+// trimNul 去除 inotify 文件名末尾的 NUL 填充
+func trimNul(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
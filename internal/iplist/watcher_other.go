@@ -0,0 +1,23 @@
+//go:build !linux
+
+package iplist
+
+import "fmt"
+
+// Watcher 非 Linux 平台占位实现（inotify 仅 Linux 可用）
+type Watcher struct{}
+
+// NewWatcher 非 Linux 平台不支持文件监听，名单文件只在启动与热重载时读取
+func NewWatcher(onChange func(paths []string)) (*Watcher, error) {
+	return nil, fmt.Errorf("名单文件监听仅支持 Linux")
+}
+
+// SetFiles 替换监听的文件集合
+func (w *Watcher) SetFiles(paths []string) error {
+	return nil
+}
+
+// Close 停止监听
+func (w *Watcher) Close() error {
+	return nil
+}
@@ -3,91 +3,73 @@ package monitor
 import (
 	"fmt"
 	"net"
-	"os/exec"
+	"net/netip"
 	"strconv"
-	"strings"
-	"time"
-)
 
-// Collector 连接采集器
-type Collector struct {
-	// 可扩展配置
-}
+	"github.com/nodeaccessmanager/nam/internal/config"
+	"github.com/nodeaccessmanager/nam/pkg/utils"
+)
 
-// NewCollector 创建采集器实例
-func NewCollector() *Collector {
-	return &Collector{}
+// ConnectionSource 连接数据源
+// Coordinator 只依赖该接口，具体实现有 netlink (inet_diag)、/proc/net/tcp 和 ss 命令
+type ConnectionSource interface {
+	// Name 数据源名称（用于日志）
+	Name() string
+	// Probe 检查数据源在当前系统上是否可用
+	Probe() error
+	// CollectConnections 采集指定端口的 ESTABLISHED 连接
+	CollectConnections(port int) ([]Connection, error)
+	// CollectAllPorts 一次采集全部连接并按端口分发，每个请求的端口都有对应条目
+	CollectAllPorts(ports []int) (map[int][]Connection, error)
 }
 
-// CollectConnections 采集指定端口的连接信息
-func (c *Collector) CollectConnections(port int) ([]Connection, error) {
-	// 执行 ss 命令: ss -tn state established sport = :<PORT>
-	cmd := exec.Command("ss", "-tn", "state", "established",
-		"sport", "=", fmt.Sprintf(":%d", port))
+// NewConnectionSource 根据配置创建连接数据源
+// auto 模式按 netlink → proc → ss 的顺序探测第一个可用的实现
+func NewConnectionSource(name string) (ConnectionSource, error) {
+	logger := utils.GetLogger()
+
+	var source ConnectionSource
+	switch name {
+	case config.ConnectionSourceNetlink:
+		source = NewNetlinkCollector()
+	case config.ConnectionSourceProc:
+		source = NewProcCollector()
+	case config.ConnectionSourceSS:
+		source = NewSSCollector()
+	case "", config.ConnectionSourceAuto:
+		return detectConnectionSource()
+	default:
+		return nil, fmt.Errorf("不支持的连接数据源: %s", name)
+	}
 
-	output, err := cmd.Output()
-	if err != nil {
-		// ss 命令执行失败，可能是权限问题或命令不存在
-		return nil, fmt.Errorf("执行 ss 命令失败: %w", err)
+	if err := source.Probe(); err != nil {
+		return nil, fmt.Errorf("连接数据源 %s 不可用: %w", source.Name(), err)
 	}
 
-	return c.parseSSOutput(output)
+	logger.Infof("使用连接数据源: %s", source.Name())
+	return source, nil
 }
 
-// parseSSOutput 解析 ss 命令输出
-func (c *Collector) parseSSOutput(output []byte) ([]Connection, error) {
-	// ss 输出格式:
-	// State   Recv-Q   Send-Q   Local Address:Port   Peer Address:Port   Process
-	// ESTAB   0        0        0.0.0.0:443          203.0.113.1:52341
-
-	lines := strings.Split(string(output), "\n")
-	var connections []Connection
-
-	now := time.Now()
-
-	for i, line := range lines {
-		// 跳过表头和空行
-		if i == 0 || strings.TrimSpace(line) == "" {
-			continue
-		}
-
-		fields := strings.Fields(line)
-		if len(fields) < 5 {
-			continue // 字段不完整，跳过
-		}
-
-		// 解析字段
-		state := fields[0]
-		recvQ, _ := strconv.Atoi(fields[1])
-		sendQ, _ := strconv.Atoi(fields[2])
-		localAddr := fields[3]
-		peerAddr := fields[4]
+// detectConnectionSource 自动探测可用的连接数据源
+func detectConnectionSource() (ConnectionSource, error) {
+	logger := utils.GetLogger()
 
-		// 解析本地地址
-		localIP, localPort, err := parseAddr(localAddr)
-		if err != nil {
-			continue
-		}
+	candidates := []ConnectionSource{
+		NewNetlinkCollector(),
+		NewProcCollector(),
+		NewSSCollector(),
+	}
 
-		// 解析远程地址
-		remoteIP, remotePort, err := parseAddr(peerAddr)
-		if err != nil {
+	for _, source := range candidates {
+		if err := source.Probe(); err != nil {
+			logger.Debugf("连接数据源 %s 不可用: %v", source.Name(), err)
 			continue
 		}
-
-		connections = append(connections, Connection{
-			LocalAddr:  localIP,
-			LocalPort:  localPort,
-			RemoteAddr: remoteIP,
-			RemotePort: remotePort,
-			State:      state,
-			RecvQ:      recvQ,
-			SendQ:      sendQ,
-			DetectedAt: now,
-		})
+		logger.Infof("自动选择连接数据源: %s", source.Name())
+		return source, nil
 	}
 
-	return connections, nil
+	return nil, fmt.Errorf("未找到可用的连接数据源（netlink、/proc/net/tcp、ss 均不可用）")
 }
 
 // parseAddr 解析地址字符串 "IP:Port" 或 "[IPv6]:Port"
@@ -105,23 +87,28 @@ func parseAddr(addr string) (string, int, error) {
 		return "", 0, fmt.Errorf("解析端口失败: %w", err)
 	}
 
+	// IPv4-mapped IPv6 地址（如 ::ffff:203.0.113.1）还原为 IPv4，与 netlink 采集保持一致
+	if addr, err := netip.ParseAddr(host); err == nil {
+		host = addr.Unmap().String()
+	}
+
 	return host, port, nil
 }
 
-// CollectAllPorts 批量采集多个端口的连接
-func (c *Collector) CollectAllPorts(ports []int) (map[int][]Connection, error) {
-	result := make(map[int][]Connection)
-
+// groupByLocalPort 按本地端口分发连接，只保留关注的端口
+func groupByLocalPort(connections []Connection, ports []int) map[int][]Connection {
+	result := make(map[int][]Connection, len(ports))
 	for _, port := range ports {
-		connections, err := c.CollectConnections(port)
-		if err != nil {
-			// 单个端口失败不影响其他端口
-			continue
+		result[port] = []Connection{}
+	}
+
+	for _, conn := range connections {
+		if _, wanted := result[conn.LocalPort]; wanted {
+			result[conn.LocalPort] = append(result[conn.LocalPort], conn)
 		}
-		result[port] = connections
 	}
 
-	return result, nil
+	return result
 }
 
 // GetUniqueIPs 从连接列表中提取唯一 IP
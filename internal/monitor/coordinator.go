@@ -2,59 +2,83 @@ package monitor
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/nodeaccessmanager/nam/internal/clock"
 	"github.com/nodeaccessmanager/nam/internal/config"
 	"github.com/nodeaccessmanager/nam/pkg/utils"
 )
 
 // Coordinator 监控协调器
 type Coordinator struct {
-	config    *config.Config
-	collector *Collector
-	trackers  map[int]*PortTracker // key: port number
-	stopCh    chan struct{}
-	wg        sync.WaitGroup
-	mu        sync.RWMutex
+	config   *config.Config
+	source   ConnectionSource
+	trackers map[int]*PortTracker // key: port number
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+	mu       sync.RWMutex
+	clock    clock.Clock
+
+	// 运行中的监控协程（热重载时按需启停）
+	running   bool
+	portStops map[int]chan struct{} // per_port 模式：每个端口一个停止信号
+	batchStop chan struct{}         // batch 模式：批量协程的停止信号
 
 	// 可选的回调函数
 	onOverlimit func(port int, currentCount int, maxAllowed int)
+	onUpdate    func(port int, tracker *PortTracker) // 每个周期更新追踪器后、超限检查前调用
 }
 
 // NewCoordinator 创建监控协调器
-func NewCoordinator(cfg *config.Config) *Coordinator {
+func NewCoordinator(cfg *config.Config, source ConnectionSource) *Coordinator {
 	return &Coordinator{
 		config:    cfg,
-		collector: NewCollector(),
+		source:    source,
 		trackers:  make(map[int]*PortTracker),
 		stopCh:    make(chan struct{}),
+		portStops: make(map[int]chan struct{}),
+		clock:     clock.Real(),
 	}
 }
 
+// SetClock 设置时间源（需在 Start 之前调用）
+func (c *Coordinator) SetClock(clk clock.Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clk
+}
+
 // SetOverlimitCallback 设置超限回调函数
 func (c *Coordinator) SetOverlimitCallback(callback func(port int, currentCount int, maxAllowed int)) {
 	c.onOverlimit = callback
 }
 
+// SetUpdateCallback 设置追踪器更新回调（如断开黑名单连接），在超限检查之前执行
+func (c *Coordinator) SetUpdateCallback(callback func(port int, tracker *PortTracker)) {
+	c.onUpdate = callback
+}
+
 // Start 启动监控
 func (c *Coordinator) Start() error {
 	logger := utils.GetLogger()
 	logger.Info("启动监控协调器")
 
-	// 初始化每个端口的追踪器
 	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 初始化每个端口的追踪器
 	for _, rule := range c.config.Rules {
-		c.trackers[rule.Port] = NewPortTracker(rule.Port)
+		tracker := NewPortTrackerWithClock(rule.Port, c.clock)
+		tracker.SetPrefixLen(rule.IPv4PrefixLen, rule.IPv6PrefixLen)
+		c.trackers[rule.Port] = tracker
 		logger.Infof("初始化端口 %d 的追踪器（最大 %d IP）", rule.Port, rule.MaxIPs)
 	}
-	c.mu.Unlock()
 
-	// 为每个端口启动监控 goroutine
-	for port := range c.trackers {
-		c.wg.Add(1)
-		go c.monitorPort(port)
-	}
+	c.running = true
+	c.startWorkers()
 
 	logger.Infof("监控协调器已启动，监控 %d 个端口", len(c.trackers))
 	return nil
@@ -65,67 +89,200 @@ func (c *Coordinator) Stop() {
 	logger := utils.GetLogger()
 	logger.Info("停止监控协调器")
 
+	c.mu.Lock()
+	c.running = false
+	c.mu.Unlock()
+
 	close(c.stopCh)
 	c.wg.Wait()
 
 	logger.Info("监控协调器已停止")
 }
 
+// startWorkers 按当前采集模式启动全部监控协程（调用方需持有写锁）
+func (c *Coordinator) startWorkers() {
+	if c.config.IsBatchCollect() {
+		// 批量模式：单个 goroutine 每周期采集一次全部端口
+		c.batchStop = make(chan struct{})
+		c.wg.Add(1)
+		go c.monitorAll(c.batchStop)
+		return
+	}
+
+	// 为每个端口启动监控 goroutine
+	for port, tracker := range c.trackers {
+		c.startPortWorker(port, tracker)
+	}
+}
+
+// stopWorkers 停止全部监控协程（调用方需持有写锁，不等待协程退出）
+func (c *Coordinator) stopWorkers() {
+	if c.batchStop != nil {
+		close(c.batchStop)
+		c.batchStop = nil
+	}
+
+	for port := range c.portStops {
+		c.stopPortWorker(port)
+	}
+}
+
+// startPortWorker 启动单个端口的监控协程（调用方需持有写锁）
+func (c *Coordinator) startPortWorker(port int, tracker *PortTracker) {
+	if _, exists := c.portStops[port]; exists {
+		return
+	}
+
+	stop := make(chan struct{})
+	c.portStops[port] = stop
+
+	c.wg.Add(1)
+	go c.monitorPort(port, tracker, stop)
+}
+
+// stopPortWorker 停止单个端口的监控协程（调用方需持有写锁）
+func (c *Coordinator) stopPortWorker(port int) {
+	if stop, exists := c.portStops[port]; exists {
+		close(stop)
+		delete(c.portStops, port)
+	}
+}
+
+// checkInterval 获取当前检查周期
+func (c *Coordinator) checkInterval() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return time.Duration(c.config.Global.CheckInterval) * time.Second
+}
+
 // monitorPort 监控单个端口
-func (c *Coordinator) monitorPort(port int) {
+func (c *Coordinator) monitorPort(port int, tracker *PortTracker, stop <-chan struct{}) {
 	defer c.wg.Done()
 
 	logger := utils.GetLogger()
-	ticker := time.NewTicker(time.Duration(c.config.Global.CheckInterval) * time.Second)
+	interval := c.checkInterval()
+	ticker := c.clock.NewTicker(interval)
 	defer ticker.Stop()
 
-	tracker := c.getTracker(port)
-	if tracker == nil {
-		logger.Errorf("端口 %d 的追踪器不存在", port)
-		return
-	}
-
-	logger.Infof("开始监控端口 %d（检查周期: %ds）", port, c.config.Global.CheckInterval)
+	logger.Infof("开始监控端口 %d（检查周期: %s）", port, interval)
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-ticker.C():
+			// 0. 热重载修改了检查周期时，从下一个周期起生效
+			if current := c.checkInterval(); current != interval {
+				interval = current
+				ticker.Reset(interval)
+				logger.Infof("端口 %d 检查周期调整为 %s", port, interval)
+			}
+
 			// 1. 采集连接
-			connections, err := c.collector.CollectConnections(port)
+			connections, err := c.source.CollectConnections(port)
 			if err != nil {
 				logger.Errorf("采集端口 %d 连接失败: %v", port, err)
 				continue
 			}
 
-			// 2. 更新追踪器
-			tracker.Update(connections)
+			// 2. 更新追踪器并检查是否超限
+			c.checkPort(port, tracker, connections)
 
-			// 3. 检查是否超限
-			rule := c.config.GetRuleByPort(port)
-			if rule == nil {
+		case <-stop:
+			logger.Infof("端口 %d 已移出监控", port)
+			return
+
+		case <-c.stopCh:
+			logger.Infof("停止监控端口 %d", port)
+			return
+		}
+	}
+}
+
+// monitorAll 批量模式：每个周期只读取一次套接字表，按端口分发到各追踪器
+func (c *Coordinator) monitorAll(stop <-chan struct{}) {
+	defer c.wg.Done()
+
+	logger := utils.GetLogger()
+	interval := c.checkInterval()
+	ticker := c.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	logger.Infof("开始批量监控（检查周期: %s）", interval)
+
+	for {
+		select {
+		case <-ticker.C():
+			// 0. 热重载修改了检查周期时，从下一个周期起生效
+			if current := c.checkInterval(); current != interval {
+				interval = current
+				ticker.Reset(interval)
+				logger.Infof("批量监控检查周期调整为 %s", interval)
+			}
+
+			// 1. 取当前全部追踪器（与本周期的快照对应）
+			c.mu.RLock()
+			trackers := make(map[int]*PortTracker, len(c.trackers))
+			ports := make([]int, 0, len(c.trackers))
+			for port, tracker := range c.trackers {
+				trackers[port] = tracker
+				ports = append(ports, port)
+			}
+			c.mu.RUnlock()
+
+			// 2. 一次采集，按端口分发
+			snapshot, err := c.source.CollectAllPorts(ports)
+			if err != nil {
+				logger.Errorf("批量采集连接失败: %v", err)
 				continue
 			}
 
-			currentCount := tracker.Count()
-			if currentCount > rule.MaxIPs {
-				logger.Warnf("端口 %d 超限: 当前 %d IP > 最大 %d IP",
-					port, currentCount, rule.MaxIPs)
-
-				// 触发超限回调
-				if c.onOverlimit != nil {
-					c.onOverlimit(port, currentCount, rule.MaxIPs)
-				}
-			} else {
-				logger.Debugf("端口 %d 状态正常: %d/%d IP", port, currentCount, rule.MaxIPs)
+			// 3. 基于同一份快照更新追踪器并检查超限
+			for port, tracker := range trackers {
+				c.checkPort(port, tracker, snapshot[port])
 			}
 
+		case <-stop:
+			logger.Info("批量监控协程已停止")
+			return
+
 		case <-c.stopCh:
-			logger.Infof("停止监控端口 %d", port)
+			logger.Info("停止批量监控")
 			return
 		}
 	}
 }
 
+// checkPort 用采集结果更新追踪器，并在超限时触发回调
+func (c *Coordinator) checkPort(port int, tracker *PortTracker, connections []Connection) {
+	logger := utils.GetLogger()
+
+	// 1. 更新追踪器
+	tracker.Update(connections)
+	if c.onUpdate != nil {
+		c.onUpdate(port, tracker)
+	}
+
+	// 2. 检查是否超限（端口可能已在热重载中被移除）
+	c.mu.RLock()
+	rule := c.config.GetRuleByPort(port)
+	c.mu.RUnlock()
+	if rule == nil {
+		return
+	}
+
+	currentCount := tracker.Count()
+	if currentCount > rule.MaxIPs {
+		logger.Warnf("端口 %d 超限: 当前 %d IP > 最大 %d IP",
+			port, currentCount, rule.MaxIPs)
+
+		// 触发超限回调
+		if c.onOverlimit != nil {
+			c.onOverlimit(port, currentCount, rule.MaxIPs)
+		}
+	} else {
+		logger.Debugf("端口 %d 状态正常: %d/%d IP", port, currentCount, rule.MaxIPs)
+	}
+}
+
 // GetTracker 获取指定端口的追踪器
 func (c *Coordinator) GetTracker(port int) *PortTracker {
 	return c.getTracker(port)
@@ -163,33 +320,87 @@ func (c *Coordinator) GetPortStats(port int) (*PortStats, error) {
 }
 
 // Reconfigure 重新配置（热重载支持）
-func (c *Coordinator) Reconfigure(newConfig *config.Config) error {
+// 对比新旧规则：为新增端口创建追踪器并启动监控，停止并移除已删除端口的监控，
+// 保留未删除端口的会话数据。检查周期的变化在各协程的下一个周期生效。
+func (c *Coordinator) Reconfigure(newConfig *config.Config) (*ReconfigureSummary, error) {
 	logger := utils.GetLogger()
 	logger.Info("重新配置监控协调器")
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// 更新配置
+	oldConfig := c.config
+	summary := &ReconfigureSummary{
+		OldInterval: oldConfig.Global.CheckInterval,
+		NewInterval: newConfig.Global.CheckInterval,
+		ModeChanged: oldConfig.IsBatchCollect() != newConfig.IsBatchCollect(),
+	}
+
+	// 1. 对比规则
+	newPorts := make(map[int]bool, len(newConfig.Rules))
+	for i := range newConfig.Rules {
+		rule := &newConfig.Rules[i]
+		newPorts[rule.Port] = true
+
+		oldRule := oldConfig.GetRuleByPort(rule.Port)
+		switch {
+		case oldRule == nil:
+			summary.Added = append(summary.Added, rule.Port)
+		case !reflect.DeepEqual(*oldRule, *rule):
+			summary.Updated = append(summary.Updated, rule.Port)
+		default:
+			summary.Unchanged++
+		}
+	}
+	for _, rule := range oldConfig.Rules {
+		if !newPorts[rule.Port] {
+			summary.Removed = append(summary.Removed, rule.Port)
+		}
+	}
+	sort.Ints(summary.Added)
+	sort.Ints(summary.Updated)
+	sort.Ints(summary.Removed)
+
+	// 2. 更新配置
 	c.config = newConfig
 
-	// 重新初始化追踪器
-	// 注意：这里简化处理，直接清空并重建
-	// 生产环境可以保留现有会话数据
-	oldTrackers := c.trackers
-	c.trackers = make(map[int]*PortTracker)
-
-	for _, rule := range newConfig.Rules {
-		// 如果端口已存在，保留现有追踪器
-		if tracker, exists := oldTrackers[rule.Port]; exists {
-			c.trackers[rule.Port] = tracker
-			logger.Infof("保留端口 %d 的追踪器", rule.Port)
-		} else {
-			c.trackers[rule.Port] = NewPortTracker(rule.Port)
-			logger.Infof("新增端口 %d 的追踪器", rule.Port)
+	// 3. 采集模式变化时重启全部监控协程
+	if c.running && summary.ModeChanged {
+		c.stopWorkers()
+	}
+
+	// 4. 移除已删除端口
+	for _, port := range summary.Removed {
+		c.stopPortWorker(port)
+		delete(c.trackers, port)
+		logger.Infof("移除端口 %d 的追踪器", port)
+	}
+
+	// 5. 规则变化的端口：按新的聚合粒度重新分组会话
+	for _, port := range summary.Updated {
+		if tracker, exists := c.trackers[port]; exists {
+			rule := newConfig.GetRuleByPort(port)
+			tracker.SetPrefixLen(rule.IPv4PrefixLen, rule.IPv6PrefixLen)
 		}
 	}
 
-	logger.Info("监控协调器重新配置完成")
-	return nil
+	// 6. 新增端口
+	for _, port := range summary.Added {
+		rule := newConfig.GetRuleByPort(port)
+		tracker := NewPortTrackerWithClock(port, c.clock)
+		tracker.SetPrefixLen(rule.IPv4PrefixLen, rule.IPv6PrefixLen)
+		c.trackers[port] = tracker
+		logger.Infof("新增端口 %d 的追踪器", port)
+
+		if c.running && !summary.ModeChanged && !newConfig.IsBatchCollect() {
+			c.startPortWorker(port, tracker)
+		}
+	}
+
+	if c.running && summary.ModeChanged {
+		c.startWorkers()
+	}
+
+	logger.Infof("监控协调器重新配置完成: %s", summary)
+	return summary, nil
 }
@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/clock"
+	"github.com/nodeaccessmanager/nam/internal/config"
+)
+
+// fakeSource 记录每次采集时的虚拟时间
+type fakeSource struct {
+	clock     clock.Clock
+	collected chan time.Time
+}
+
+func (s *fakeSource) Name() string { return "fake" }
+
+func (s *fakeSource) Probe() error { return nil }
+
+func (s *fakeSource) CollectConnections(port int) ([]Connection, error) {
+	s.collected <- s.clock.Now()
+	return nil, nil
+}
+
+func (s *fakeSource) CollectAllPorts(ports []int) (map[int][]Connection, error) {
+	s.collected <- s.clock.Now()
+	return groupByLocalPort(nil, ports), nil
+}
+
+// waitPending 等待被测 goroutine 注册 n 个定时器
+func waitPending(t *testing.T, clk *clock.Fake, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for clk.Pending() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待定时器注册超时（当前 %d 个）", clk.Pending())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// expectCollect 推进虚拟时间并断言在 want 时刻发生一次采集
+func expectCollect(t *testing.T, clk *clock.Fake, source *fakeSource, advance time.Duration, want time.Time) {
+	t.Helper()
+	clk.Advance(advance)
+	select {
+	case got := <-source.collected:
+		if !got.Equal(want) {
+			t.Fatalf("采集时间 = %v, want %v", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("推进 %s 后未在 %v 采集", advance, want)
+	}
+}
+
+func TestCoordinatorReconfigureInterval(t *testing.T) {
+	tests := []struct {
+		name        string
+		collectMode string
+		oldInterval int
+		newInterval int
+	}{
+		{name: "per_port 缩短周期", collectMode: config.CollectModePerPort, oldInterval: 5, newInterval: 2},
+		{name: "per_port 延长周期", collectMode: config.CollectModePerPort, oldInterval: 2, newInterval: 7},
+		{name: "batch 缩短周期", collectMode: config.CollectModeBatch, oldInterval: 5, newInterval: 2},
+		{name: "batch 延长周期", collectMode: config.CollectModeBatch, oldInterval: 2, newInterval: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			clk := clock.NewFake(start)
+			source := &fakeSource{clock: clk, collected: make(chan time.Time, 1)}
+
+			newConfig := func(interval int) *config.Config {
+				cfg := config.DefaultConfig()
+				cfg.Global.CheckInterval = interval
+				cfg.Global.CollectMode = tt.collectMode
+				cfg.Rules = []config.Rule{{Port: 8443, MaxIPs: 10}}
+				return cfg
+			}
+
+			c := NewCoordinator(newConfig(tt.oldInterval), source)
+			c.SetClock(clk)
+			if err := c.Start(); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			defer c.Stop()
+			waitPending(t, clk, 1)
+
+			oldInterval := time.Duration(tt.oldInterval) * time.Second
+			newInterval := time.Duration(tt.newInterval) * time.Second
+
+			// 按旧周期采集
+			now := start.Add(oldInterval)
+			expectCollect(t, clk, source, oldInterval, now)
+
+			summary, err := c.Reconfigure(newConfig(tt.newInterval))
+			if err != nil {
+				t.Fatalf("Reconfigure() error = %v", err)
+			}
+			if summary.OldInterval != tt.oldInterval || summary.NewInterval != tt.newInterval {
+				t.Errorf("summary interval = %d -> %d", summary.OldInterval, summary.NewInterval)
+			}
+
+			// 已排定的下一次仍按旧周期触发，并在此时切换到新周期
+			now = now.Add(oldInterval)
+			expectCollect(t, clk, source, oldInterval, now)
+
+			// 此后按新周期采集
+			for i := 0; i < 2; i++ {
+				now = now.Add(newInterval)
+				expectCollect(t, clk, source, newInterval, now)
+			}
+		})
+	}
+}
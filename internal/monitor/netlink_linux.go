@@ -0,0 +1,400 @@
+//go:build linux
+
+package monitor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// inet_diag 协议常量（见 linux/inet_diag.h、linux/sock_diag.h）
+const (
+	sockDiagByFamily = 20 // SOCK_DIAG_BY_FAMILY
+
+	inetDiagReqBytecode = 1 // INET_DIAG_REQ_BYTECODE
+	inetDiagBcSGE       = 2 // INET_DIAG_BC_S_GE
+	inetDiagBcSLE       = 3 // INET_DIAG_BC_S_LE
+
+	tcpEstablished = 1 // TCP_ESTABLISHED
+
+	inetDiagInfo = 2 // INET_DIAG_INFO：附带 struct tcp_info
+
+	// struct tcp_info 中的字节计数（Linux 4.1+，更早的内核属性长度不足时视为 0）
+	tcpInfoBytesAckedOff    = 120 // tcpi_bytes_acked
+	tcpInfoBytesReceivedOff = 128 // tcpi_bytes_received
+	tcpInfoMinLen           = 136
+
+	inetDiagReqV2Len = 56 // sizeof(struct inet_diag_req_v2)
+	inetDiagMsgLen   = 72 // sizeof(struct inet_diag_msg)
+	nlAttrHdrLen     = 4  // sizeof(struct nlattr)
+
+	netlinkRecvBufSize = 32 * 1024
+)
+
+// NetlinkCollector 基于 NETLINK_SOCK_DIAG (inet_diag) 的连接采集器
+// 直接向内核查询 ESTABLISHED 状态的 TCP 套接字，无需 fork ss 进程
+type NetlinkCollector struct {
+	seq atomic.Uint32 // netlink 请求序号（多个端口协程并发使用）
+}
+
+// NewNetlinkCollector 创建 netlink 采集器实例
+func NewNetlinkCollector() *NetlinkCollector {
+	return &NetlinkCollector{}
+}
+
+// Name 数据源名称
+func (nc *NetlinkCollector) Name() string {
+	return "netlink"
+}
+
+// Probe 检查当前内核是否支持 inet_diag 查询
+func (nc *NetlinkCollector) Probe() error {
+	fd, err := nc.openSocket()
+	if err != nil {
+		return err
+	}
+	syscall.Close(fd)
+	return nil
+}
+
+// CollectConnections 采集指定端口的连接信息（IPv4 + IPv6，内核侧按源端口过滤）
+func (nc *NetlinkCollector) CollectConnections(port int) ([]Connection, error) {
+	if port < 1 || port > 65535 {
+		return nil, fmt.Errorf("端口号无效: %d", port)
+	}
+
+	var connections []Connection
+	for _, family := range []uint8{syscall.AF_INET, syscall.AF_INET6} {
+		conns, err := nc.dump(family, port)
+		if err != nil {
+			return nil, err
+		}
+		connections = append(connections, conns...)
+	}
+
+	return connections, nil
+}
+
+// CollectAll 一次性采集全部 ESTABLISHED 的 TCP 连接（IPv4 + IPv6）
+func (nc *NetlinkCollector) CollectAll() ([]Connection, error) {
+	var connections []Connection
+	for _, family := range []uint8{syscall.AF_INET, syscall.AF_INET6} {
+		conns, err := nc.dump(family, 0)
+		if err != nil {
+			return nil, err
+		}
+		connections = append(connections, conns...)
+	}
+
+	return connections, nil
+}
+
+// CollectAllPorts 一次 dump 全部连接，再按端口分发
+func (nc *NetlinkCollector) CollectAllPorts(ports []int) (map[int][]Connection, error) {
+	connections, err := nc.CollectAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return groupByLocalPort(connections, ports), nil
+}
+
+// dump 对单个地址族执行一次 inet_diag 查询（port 为 0 时不过滤端口）
+func (nc *NetlinkCollector) dump(family uint8, port int) ([]Connection, error) {
+	fd, err := nc.openSocket()
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	portID, err := netlinkPortID(fd)
+	if err != nil {
+		return nil, err
+	}
+
+	seq := nc.seq.Add(1)
+	req := buildDiagRequest(seq, family, port)
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("发送 inet_diag 请求失败: %w", err)
+	}
+
+	now := time.Now()
+	var connections []Connection
+	buf := make([]byte, netlinkRecvBufSize)
+
+	for {
+		var n int
+		buf, n, err = recvDatagram(fd, buf)
+		if err != nil {
+			return nil, fmt.Errorf("读取 inet_diag 响应失败: %w", err)
+		}
+
+		conns, done, err := parseDumpMessages(buf[:n], seq, portID, now)
+		if err != nil {
+			return nil, err
+		}
+		connections = append(connections, conns...)
+		if done {
+			return connections, nil
+		}
+	}
+}
+
+// parseDumpMessages 解析一个 netlink 数据报中属于本次请求（序号与端口号一致）的 dump 消息
+// 返回其中的连接，以及是否已收到 NLMSG_DONE
+func parseDumpMessages(data []byte, seq, portID uint32, now time.Time) ([]Connection, bool, error) {
+	msgs, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("解析 netlink 消息失败: %w", err)
+	}
+
+	var connections []Connection
+	for _, msg := range msgs {
+		// 不属于本次请求的消息直接丢弃
+		if msg.Header.Seq != seq || msg.Header.Pid != portID {
+			continue
+		}
+
+		switch msg.Header.Type {
+		case syscall.NLMSG_DONE:
+			return connections, true, nil
+		case syscall.NLMSG_ERROR:
+			if err := parseNetlinkError(msg.Data); err != nil {
+				return nil, false, err
+			}
+		case sockDiagByFamily:
+			conn, ok := parseDiagMsg(msg.Data, now)
+			if ok {
+				connections = append(connections, conn)
+			}
+		}
+	}
+	return connections, false, nil
+}
+
+// recvDatagram 读取一个完整的数据报，返回（可能已扩容的）缓冲区与数据长度
+// 先以 MSG_PEEK|MSG_TRUNC 取得数据报的实际长度，缓冲区不足时扩容后再读取，避免截断的消息被当作短 dump
+func recvDatagram(fd int, buf []byte) ([]byte, int, error) {
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, syscall.MSG_PEEK|syscall.MSG_TRUNC)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return buf, 0, err
+		}
+		if n > len(buf) {
+			buf = make([]byte, n)
+		}
+
+		n, _, err = syscall.Recvfrom(fd, buf, syscall.MSG_TRUNC)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return buf, 0, err
+		}
+		if n > len(buf) {
+			return buf, 0, fmt.Errorf("netlink 消息被截断（%d > %d 字节）", n, len(buf))
+		}
+		return buf, n, nil
+	}
+}
+
+// netlinkPortID 套接字绑定的 netlink 端口号（内核响应的 nlmsg_pid）
+func netlinkPortID(fd int) (uint32, error) {
+	sa, err := syscall.Getsockname(fd)
+	if err != nil {
+		return 0, fmt.Errorf("获取 netlink 套接字地址失败: %w", err)
+	}
+	nl, ok := sa.(*syscall.SockaddrNetlink)
+	if !ok {
+		return 0, fmt.Errorf("获取 netlink 套接字地址失败: 地址类型 %T", sa)
+	}
+	return nl.Pid, nil
+}
+
+// openSocket 打开 NETLINK_SOCK_DIAG 套接字
+func (nc *NetlinkCollector) openSocket() (int, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_INET_DIAG)
+	if err != nil {
+		return -1, fmt.Errorf("创建 netlink 套接字失败: %w", err)
+	}
+
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		syscall.Close(fd)
+		return -1, fmt.Errorf("绑定 netlink 套接字失败: %w", err)
+	}
+
+	return fd, nil
+}
+
+// buildDiagRequest 构造 inet_diag_req_v2 请求
+// port > 0 时附带字节码过滤器 (sport >= port && sport <= port)，由内核完成端口过滤
+func buildDiagRequest(seq uint32, family uint8, port int) []byte {
+	var bytecode []byte
+	if port > 0 {
+		bytecode = sportEqualBytecode(uint16(port))
+	}
+
+	attrLen := 0
+	if len(bytecode) > 0 {
+		attrLen = nlAttrHdrLen + len(bytecode)
+	}
+	total := syscall.NLMSG_HDRLEN + inetDiagReqV2Len + attrLen
+
+	b := make([]byte, total)
+	ne := binary.NativeEndian
+
+	// struct nlmsghdr
+	ne.PutUint32(b[0:4], uint32(total))
+	ne.PutUint16(b[4:6], sockDiagByFamily)
+	ne.PutUint16(b[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	ne.PutUint32(b[8:12], seq)
+	ne.PutUint32(b[12:16], uint32(os.Getpid()))
+
+	// struct inet_diag_req_v2（inet_diag_sockid 保持全零，匹配全部套接字）
+	req := b[syscall.NLMSG_HDRLEN:]
+	req[0] = family
+	req[1] = syscall.IPPROTO_TCP
+	req[2] = 1 << (inetDiagInfo - 1) // idiag_ext：请求 tcp_info
+	ne.PutUint32(req[4:8], 1<<tcpEstablished)
+
+	// struct nlattr + 字节码
+	if attrLen > 0 {
+		attr := req[inetDiagReqV2Len:]
+		ne.PutUint16(attr[0:2], uint16(attrLen))
+		ne.PutUint16(attr[2:4], inetDiagReqBytecode)
+		copy(attr[nlAttrHdrLen:], bytecode)
+	}
+
+	return b
+}
+
+// sportEqualBytecode 生成 "源端口等于 port" 的 inet_diag 字节码
+// 每条指令为 struct inet_diag_bc_op { u8 code; u8 yes; u16 no; }，
+// 端口比较指令后紧跟一条仅用 no 字段携带端口号的伪指令。
+// 条件不成立时跳转到 len+4，即越过末尾，表示拒绝该套接字。
+func sportEqualBytecode(port uint16) []byte {
+	const opLen = 4
+	const condLen = 2 * opLen
+	const total = 2 * condLen
+
+	b := make([]byte, total)
+	ne := binary.NativeEndian
+
+	// sport >= port
+	b[0] = inetDiagBcSGE
+	b[1] = condLen
+	ne.PutUint16(b[2:4], total+4)
+	ne.PutUint16(b[6:8], port)
+
+	// sport <= port
+	b[8] = inetDiagBcSLE
+	b[9] = condLen
+	ne.PutUint16(b[10:12], condLen+4)
+	ne.PutUint16(b[14:16], port)
+
+	return b
+}
+
+// parseDiagMsg 解析 struct inet_diag_msg
+func parseDiagMsg(data []byte, now time.Time) (Connection, bool) {
+	if len(data) < inetDiagMsgLen {
+		return Connection{}, false
+	}
+
+	family := data[0]
+	state := data[1]
+	if state != tcpEstablished {
+		return Connection{}, false
+	}
+
+	// inet_diag_sockid: sport(be16) dport(be16) src[16] dst[16] if(u32) cookie[2]u32
+	id := data[4:52]
+	sport := binary.BigEndian.Uint16(id[0:2])
+	dport := binary.BigEndian.Uint16(id[2:4])
+
+	localIP, ok := diagAddr(family, id[4:20])
+	if !ok {
+		return Connection{}, false
+	}
+	remoteIP, ok := diagAddr(family, id[20:36])
+	if !ok {
+		return Connection{}, false
+	}
+
+	ne := binary.NativeEndian
+	rqueue := ne.Uint32(data[56:60])
+	wqueue := ne.Uint32(data[60:64])
+
+	conn := Connection{
+		LocalAddr:  localIP,
+		LocalPort:  int(sport),
+		RemoteAddr: remoteIP,
+		RemotePort: int(dport),
+		State:      "ESTAB",
+		RecvQ:      int(rqueue),
+		SendQ:      int(wqueue),
+		DetectedAt: now,
+	}
+
+	if info := diagAttr(data[inetDiagMsgLen:], inetDiagInfo); len(info) >= tcpInfoMinLen {
+		conn.BytesSent = ne.Uint64(info[tcpInfoBytesAckedOff:])
+		conn.BytesReceived = ne.Uint64(info[tcpInfoBytesReceivedOff:])
+	}
+
+	return conn, true
+}
+
+// diagAttr 在 inet_diag_msg 之后的 netlink 属性中查找指定类型的属性载荷
+func diagAttr(attrs []byte, attrType uint16) []byte {
+	ne := binary.NativeEndian
+	for len(attrs) >= nlAttrHdrLen {
+		length := int(ne.Uint16(attrs[0:2]))
+		if length < nlAttrHdrLen || length > len(attrs) {
+			return nil
+		}
+		if ne.Uint16(attrs[2:4]) == attrType {
+			return attrs[nlAttrHdrLen:length]
+		}
+
+		// 属性按 4 字节对齐
+		aligned := (length + 3) &^ 3
+		if aligned >= len(attrs) {
+			return nil
+		}
+		attrs = attrs[aligned:]
+	}
+	return nil
+}
+
+// diagAddr 将 inet_diag 中的地址字段转换为字符串
+// IPv4-mapped IPv6 地址（双栈监听）统一还原为 IPv4，便于后续封禁
+func diagAddr(family uint8, raw []byte) (string, bool) {
+	switch family {
+	case syscall.AF_INET:
+		return netip.AddrFrom4([4]byte(raw[:4])).String(), true
+	case syscall.AF_INET6:
+		return netip.AddrFrom16([16]byte(raw[:16])).Unmap().String(), true
+	default:
+		return "", false
+	}
+}
+
+// parseNetlinkError 解析 NLMSG_ERROR 消息
+func parseNetlinkError(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("inet_diag 返回错误（消息不完整）")
+	}
+	errno := int32(binary.NativeEndian.Uint32(data[0:4]))
+	if errno == 0 {
+		return nil
+	}
+	return fmt.Errorf("inet_diag 返回错误: %w", syscall.Errno(-errno))
+}
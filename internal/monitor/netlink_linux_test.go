@@ -0,0 +1,396 @@
+//go:build linux
+
+package monitor
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// runBytecode 按内核 inet_diag_bc_run 的语义执行端口字节码：恰好走到末尾为接受，越过末尾为拒绝
+func runBytecode(t *testing.T, bc []byte, sport uint16) bool {
+	t.Helper()
+	ne := binary.NativeEndian
+	remaining := len(bc)
+	pc := 0
+	for remaining > 0 {
+		code, yes, no := bc[pc], int(bc[pc+1]), int(ne.Uint16(bc[pc+2:pc+4]))
+		operand := ne.Uint16(bc[pc+6 : pc+8])
+
+		var match bool
+		switch code {
+		case inetDiagBcSGE:
+			match = sport >= operand
+		case inetDiagBcSLE:
+			match = sport <= operand
+		default:
+			t.Fatalf("未知指令 %d", code)
+		}
+
+		step := no
+		if match {
+			step = yes
+		}
+		remaining -= step
+		pc += step
+	}
+	return remaining == 0
+}
+
+func TestSportEqualBytecode(t *testing.T) {
+	bc := sportEqualBytecode(443)
+	if len(bc) != 16 {
+		t.Fatalf("字节码长度 = %d, want 16", len(bc))
+	}
+
+	for _, sport := range []uint16{1, 442, 443, 444, 8443, 65535} {
+		if got, want := runBytecode(t, bc, sport), sport == 443; got != want {
+			t.Errorf("sport %d: accept = %v, want %v", sport, got, want)
+		}
+	}
+}
+
+func TestBuildDiagRequest(t *testing.T) {
+	ne := binary.NativeEndian
+
+	req := buildDiagRequest(7, syscall.AF_INET6, 443)
+	if got := int(ne.Uint32(req[0:4])); got != len(req) || got != syscall.NLMSG_HDRLEN+inetDiagReqV2Len+nlAttrHdrLen+16 {
+		t.Fatalf("nlmsg_len = %d, 请求长度 %d", got, len(req))
+	}
+	if ne.Uint16(req[4:6]) != sockDiagByFamily || ne.Uint32(req[8:12]) != 7 {
+		t.Errorf("nlmsghdr 类型或序号错误")
+	}
+
+	body := req[syscall.NLMSG_HDRLEN:]
+	if body[0] != syscall.AF_INET6 || body[1] != syscall.IPPROTO_TCP {
+		t.Errorf("family/protocol = %d/%d", body[0], body[1])
+	}
+	if body[2] != 1<<(inetDiagInfo-1) {
+		t.Errorf("idiag_ext = %#x, 应请求 INET_DIAG_INFO", body[2])
+	}
+	if ne.Uint32(body[4:8]) != 1<<tcpEstablished {
+		t.Errorf("idiag_states = %#x", ne.Uint32(body[4:8]))
+	}
+
+	attr := body[inetDiagReqV2Len:]
+	if ne.Uint16(attr[0:2]) != nlAttrHdrLen+16 || ne.Uint16(attr[2:4]) != inetDiagReqBytecode {
+		t.Errorf("字节码属性头错误: len %d type %d", ne.Uint16(attr[0:2]), ne.Uint16(attr[2:4]))
+	}
+
+	// 不过滤端口时没有字节码属性
+	if all := buildDiagRequest(1, syscall.AF_INET, 0); len(all) != syscall.NLMSG_HDRLEN+inetDiagReqV2Len {
+		t.Errorf("无过滤请求长度 = %d", len(all))
+	}
+}
+
+// diagMsg 构造一条 inet_diag_msg（其后拼接 attrs 中的 netlink 属性）
+func diagMsg(family, state uint8, local, remote string, sport, dport uint16, attrs ...[]byte) []byte {
+	b := make([]byte, inetDiagMsgLen)
+	b[0], b[1] = family, state
+	binary.BigEndian.PutUint16(b[4:6], sport)
+	binary.BigEndian.PutUint16(b[6:8], dport)
+
+	put := func(dst []byte, s string) {
+		addr := netip.MustParseAddr(s)
+		if family == syscall.AF_INET {
+			a := addr.As4()
+			copy(dst, a[:])
+		} else {
+			a := addr.As16()
+			copy(dst, a[:])
+		}
+	}
+	put(b[8:24], local)
+	put(b[24:40], remote)
+
+	ne := binary.NativeEndian
+	ne.PutUint32(b[56:60], 11) // idiag_rqueue
+	ne.PutUint32(b[60:64], 22) // idiag_wqueue
+
+	for _, attr := range attrs {
+		b = append(b, attr...)
+	}
+	return b
+}
+
+// nlAttr 构造一个按 4 字节对齐的 netlink 属性
+func nlAttr(attrType uint16, payload []byte) []byte {
+	length := nlAttrHdrLen + len(payload)
+	b := make([]byte, (length+3)&^3)
+	binary.NativeEndian.PutUint16(b[0:2], uint16(length))
+	binary.NativeEndian.PutUint16(b[2:4], attrType)
+	copy(b[nlAttrHdrLen:], payload)
+	return b
+}
+
+// tcpInfo 构造长度为 size 的 struct tcp_info，字节计数写在 120/128 偏移处（长度足够时）
+func tcpInfo(size int, acked, received uint64) []byte {
+	b := make([]byte, size)
+	if size >= tcpInfoMinLen {
+		binary.NativeEndian.PutUint64(b[tcpInfoBytesAckedOff:], acked)
+		binary.NativeEndian.PutUint64(b[tcpInfoBytesReceivedOff:], received)
+	}
+	return b
+}
+
+func TestParseDiagMsg(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name       string
+		data       []byte
+		ok         bool
+		local      string
+		remote     string
+		sent, recv uint64
+	}{
+		{
+			name:   "IPv4 带完整 tcp_info",
+			data:   diagMsg(syscall.AF_INET, tcpEstablished, "10.0.0.1", "203.0.113.7", 443, 51000, nlAttr(inetDiagInfo, tcpInfo(232, 1000, 2000))),
+			ok:     true,
+			local:  "10.0.0.1",
+			remote: "203.0.113.7",
+			sent:   1000,
+			recv:   2000,
+		},
+		{
+			name:   "IPv6",
+			data:   diagMsg(syscall.AF_INET6, tcpEstablished, "2001:db8::1", "2001:db8::beef", 443, 51000),
+			ok:     true,
+			local:  "2001:db8::1",
+			remote: "2001:db8::beef",
+		},
+		{
+			name:   "IPv4-mapped 还原为 IPv4",
+			data:   diagMsg(syscall.AF_INET6, tcpEstablished, "::ffff:10.0.0.1", "::ffff:198.51.100.9", 443, 51000),
+			ok:     true,
+			local:  "10.0.0.1",
+			remote: "198.51.100.9",
+		},
+		{
+			name:   "tcp_info 截断（旧内核）时字节数为 0",
+			data:   diagMsg(syscall.AF_INET, tcpEstablished, "10.0.0.1", "203.0.113.7", 443, 51000, nlAttr(inetDiagInfo, tcpInfo(tcpInfoMinLen-1, 0, 0))),
+			ok:     true,
+			local:  "10.0.0.1",
+			remote: "203.0.113.7",
+		},
+		{
+			name: "tcp_info 前有其他属性（按 4 字节对齐跳过）",
+			data: diagMsg(syscall.AF_INET, tcpEstablished, "10.0.0.1", "203.0.113.7", 443, 51000,
+				nlAttr(1, []byte{1, 2, 3, 4, 5}), nlAttr(inetDiagInfo, tcpInfo(tcpInfoMinLen, 7, 9))),
+			ok:     true,
+			local:  "10.0.0.1",
+			remote: "203.0.113.7",
+			sent:   7,
+			recv:   9,
+		},
+		{
+			name: "非 ESTABLISHED",
+			data: diagMsg(syscall.AF_INET, 10, "10.0.0.1", "203.0.113.7", 443, 51000),
+		},
+		{
+			name: "消息不完整",
+			data: diagMsg(syscall.AF_INET, tcpEstablished, "10.0.0.1", "203.0.113.7", 443, 51000)[:inetDiagMsgLen-1],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, ok := parseDiagMsg(tt.data, now)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if conn.LocalAddr != tt.local || conn.RemoteAddr != tt.remote {
+				t.Errorf("地址 = %s -> %s, want %s -> %s", conn.RemoteAddr, conn.LocalAddr, tt.remote, tt.local)
+			}
+			if conn.LocalPort != 443 || conn.RemotePort != 51000 || conn.State != "ESTAB" {
+				t.Errorf("端口/状态 = %d %d %s", conn.LocalPort, conn.RemotePort, conn.State)
+			}
+			if conn.RecvQ != 11 || conn.SendQ != 22 || !conn.DetectedAt.Equal(now) {
+				t.Errorf("队列/时间 = %d %d %v", conn.RecvQ, conn.SendQ, conn.DetectedAt)
+			}
+			if conn.BytesSent != tt.sent || conn.BytesReceived != tt.recv {
+				t.Errorf("字节数 = %d/%d, want %d/%d", conn.BytesSent, conn.BytesReceived, tt.sent, tt.recv)
+			}
+		})
+	}
+}
+
+func TestDiagAttr(t *testing.T) {
+	payload := []byte{0xaa, 0xbb}
+	attrs := append(nlAttr(1, []byte{1}), nlAttr(inetDiagInfo, payload)...)
+
+	if got := diagAttr(attrs, inetDiagInfo); string(got) != string(payload) {
+		t.Errorf("diagAttr = %x, want %x", got, payload)
+	}
+	if got := diagAttr(attrs, 5); got != nil {
+		t.Errorf("不存在的属性返回 %x", got)
+	}
+
+	// 长度字段超出缓冲区或小于属性头时停止解析
+	bad := nlAttr(inetDiagInfo, payload)
+	binary.NativeEndian.PutUint16(bad[0:2], 64)
+	if got := diagAttr(bad, inetDiagInfo); got != nil {
+		t.Errorf("越界属性返回 %x", got)
+	}
+	binary.NativeEndian.PutUint16(bad[0:2], 2)
+	if got := diagAttr(bad, inetDiagInfo); got != nil {
+		t.Errorf("过短属性返回 %x", got)
+	}
+}
+
+// nlMsg 构造一条 netlink 消息（负载按 4 字节对齐填充）
+func nlMsg(msgType uint16, seq, pid uint32, payload []byte) []byte {
+	b := make([]byte, syscall.NLMSG_HDRLEN, syscall.NLMSG_HDRLEN+len(payload)+syscall.NLMSG_ALIGNTO)
+	ne := binary.NativeEndian
+	ne.PutUint32(b[0:4], uint32(syscall.NLMSG_HDRLEN+len(payload)))
+	ne.PutUint16(b[4:6], msgType)
+	ne.PutUint32(b[8:12], seq)
+	ne.PutUint32(b[12:16], pid)
+	b = append(b, payload...)
+	for len(b)%syscall.NLMSG_ALIGNTO != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// nlErrno 构造 NLMSG_ERROR 负载（errno 取负值，0 表示 ACK）
+func nlErrno(errno syscall.Errno) []byte {
+	b := make([]byte, 4+syscall.NLMSG_HDRLEN)
+	binary.NativeEndian.PutUint32(b[0:4], uint32(-int32(errno)))
+	return b
+}
+
+func TestParseDumpMessages(t *testing.T) {
+	const seq, portID = 7, 4242
+	now := time.Unix(1700000000, 0)
+	conn := func(remote string) []byte {
+		return diagMsg(syscall.AF_INET, tcpEstablished, "10.0.0.1", remote, 443, 51000)
+	}
+	concat := func(msgs ...[]byte) []byte {
+		var b []byte
+		for _, msg := range msgs {
+			b = append(b, msg...)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		remotes []string
+		done    bool
+		wantErr bool
+	}{
+		{
+			name:    "本次请求的连接",
+			data:    concat(nlMsg(sockDiagByFamily, seq, portID, conn("192.0.2.1")), nlMsg(sockDiagByFamily, seq, portID, conn("192.0.2.2"))),
+			remotes: []string{"192.0.2.1", "192.0.2.2"},
+		},
+		{
+			name:    "NLMSG_DONE 结束 dump",
+			data:    concat(nlMsg(sockDiagByFamily, seq, portID, conn("192.0.2.1")), nlMsg(syscall.NLMSG_DONE, seq, portID, make([]byte, 4))),
+			remotes: []string{"192.0.2.1"},
+			done:    true,
+		},
+		{
+			name: "序号不符的消息（上一次请求的残留）被丢弃",
+			data: concat(
+				nlMsg(sockDiagByFamily, seq-1, portID, conn("198.51.100.1")),
+				nlMsg(syscall.NLMSG_DONE, seq-1, portID, make([]byte, 4)),
+				nlMsg(sockDiagByFamily, seq, portID, conn("192.0.2.1")),
+			),
+			remotes: []string{"192.0.2.1"},
+		},
+		{
+			name:    "端口号不符的消息被丢弃",
+			data:    concat(nlMsg(sockDiagByFamily, seq, portID+1, conn("198.51.100.1")), nlMsg(syscall.NLMSG_ERROR, seq, portID+1, nlErrno(syscall.EPERM))),
+			remotes: nil,
+		},
+		{
+			name:    "NLMSG_ERROR 返回错误",
+			data:    nlMsg(syscall.NLMSG_ERROR, seq, portID, nlErrno(syscall.EPERM)),
+			wantErr: true,
+		},
+		{
+			name:    "errno 为 0 的 ACK 不是错误",
+			data:    nlMsg(syscall.NLMSG_ERROR, seq, portID, nlErrno(0)),
+			remotes: nil,
+		},
+		{
+			name:    "消息长度超出数据报（被截断）",
+			data:    nlMsg(sockDiagByFamily, seq, portID, conn("192.0.2.1"))[:syscall.NLMSG_HDRLEN+8],
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conns, done, err := parseDumpMessages(tt.data, seq, portID, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if done != tt.done {
+				t.Errorf("done = %v, want %v", done, tt.done)
+			}
+			var remotes []string
+			for _, c := range conns {
+				remotes = append(remotes, c.RemoteAddr)
+			}
+			if len(remotes) != len(tt.remotes) {
+				t.Fatalf("remotes = %v, want %v", remotes, tt.remotes)
+			}
+			for i := range remotes {
+				if remotes[i] != tt.remotes[i] {
+					t.Errorf("remotes = %v, want %v", remotes, tt.remotes)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestRecvDatagram(t *testing.T) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		t.Skipf("socketpair 不可用: %v", err)
+	}
+	defer syscall.Close(fds[0])
+	defer syscall.Close(fds[1])
+
+	large := make([]byte, 3000)
+	for i := range large {
+		large[i] = byte(i)
+	}
+	small := []byte("done")
+	for _, msg := range [][]byte{large, small} {
+		if err := syscall.Sendto(fds[0], msg, 0, nil); err != nil {
+			t.Fatalf("Sendto() error = %v", err)
+		}
+	}
+
+	// 缓冲区小于数据报时扩容后完整读取，不截断
+	buf := make([]byte, 1024)
+	buf, n, err := recvDatagram(fds[1], buf)
+	if err != nil {
+		t.Fatalf("recvDatagram() error = %v", err)
+	}
+	if n != len(large) || string(buf[:n]) != string(large) {
+		t.Fatalf("recvDatagram() = %d 字节, want %d 字节的完整数据报", n, len(large))
+	}
+
+	// 扩容后的缓冲区继续使用，下一个数据报不受影响
+	buf, n, err = recvDatagram(fds[1], buf)
+	if err != nil {
+		t.Fatalf("recvDatagram() error = %v", err)
+	}
+	if string(buf[:n]) != string(small) || len(buf) < len(large) {
+		t.Errorf("recvDatagram() = %q (len(buf) %d), want %q", buf[:n], len(buf), small)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build !linux
+
+package monitor
+
+import "fmt"
+
+// NetlinkCollector 非 Linux 平台占位实现（inet_diag 仅 Linux 可用）
+type NetlinkCollector struct{}
+
+// NewNetlinkCollector 创建 netlink 采集器实例
+func NewNetlinkCollector() *NetlinkCollector {
+	return &NetlinkCollector{}
+}
+
+// Name 数据源名称
+func (nc *NetlinkCollector) Name() string {
+	return "netlink"
+}
+
+// Probe 非 Linux 平台始终不可用
+func (nc *NetlinkCollector) Probe() error {
+	return fmt.Errorf("当前平台不支持 NETLINK_SOCK_DIAG")
+}
+
+// CollectConnections 非 Linux 平台始终返回错误
+func (nc *NetlinkCollector) CollectConnections(port int) ([]Connection, error) {
+	return nil, fmt.Errorf("当前平台不支持 NETLINK_SOCK_DIAG")
+}
+
+// CollectAll 非 Linux 平台始终返回错误
+func (nc *NetlinkCollector) CollectAll() ([]Connection, error) {
+	return nil, fmt.Errorf("当前平台不支持 NETLINK_SOCK_DIAG")
+}
+
+// CollectAllPorts 非 Linux 平台始终返回错误
+func (nc *NetlinkCollector) CollectAllPorts(ports []int) (map[int][]Connection, error) {
+	return nil, fmt.Errorf("当前平台不支持 NETLINK_SOCK_DIAG")
+}
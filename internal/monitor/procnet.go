@@ -0,0 +1,193 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// /proc/net/tcp 中 ESTABLISHED 状态的编码
+const procStateEstablished = "01"
+
+// ProcCollector 基于 /proc/net/tcp 与 /proc/net/tcp6 的连接采集器
+// 纯 Go 实现，不依赖 ss 或 netlink，适用于精简容器 / busybox 环境
+type ProcCollector struct {
+	tcpPath  string
+	tcp6Path string
+}
+
+// NewProcCollector 创建 /proc 采集器实例
+func NewProcCollector() *ProcCollector {
+	return &ProcCollector{
+		tcpPath:  "/proc/net/tcp",
+		tcp6Path: "/proc/net/tcp6",
+	}
+}
+
+// Name 数据源名称
+func (c *ProcCollector) Name() string {
+	return "proc"
+}
+
+// Probe 检查 /proc/net/tcp 是否可读
+func (c *ProcCollector) Probe() error {
+	f, err := os.Open(c.tcpPath)
+	if err != nil {
+		return fmt.Errorf("无法读取 %s: %w", c.tcpPath, err)
+	}
+	f.Close()
+	return nil
+}
+
+// CollectConnections 采集指定端口的连接信息
+func (c *ProcCollector) CollectConnections(port int) ([]Connection, error) {
+	result, err := c.CollectAllPorts([]int{port})
+	if err != nil {
+		return nil, err
+	}
+	return result[port], nil
+}
+
+// CollectAllPorts 读取一次 tcp/tcp6 表，再按端口分发
+func (c *ProcCollector) CollectAllPorts(ports []int) (map[int][]Connection, error) {
+	connections, err := c.readTable(c.tcpPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 内核未启用 IPv6 时 tcp6 不存在，忽略即可
+	conns6, err := c.readTable(c.tcp6Path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	connections = append(connections, conns6...)
+
+	return groupByLocalPort(connections, ports), nil
+}
+
+// readTable 读取并解析单个 /proc/net/tcp* 文件
+func (c *ProcCollector) readTable(path string) ([]Connection, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	defer f.Close()
+
+	return parseProcNetTCP(f, time.Now())
+}
+
+// parseProcNetTCP 解析 /proc/net/tcp 格式的内容
+//
+// 格式示例:
+//
+//	sl  local_address rem_address   st tx_queue rx_queue ...
+//	0: 0100007F:01BB 0A00000B:CC85 01 00000000:00000000 ...
+//
+// 地址为按 32 位字存放的网络序字节（以本机字节序打印的十六进制），
+// 端口为主机序十六进制，st 为 TCP 状态（01 = ESTABLISHED）。
+func parseProcNetTCP(r io.Reader, now time.Time) ([]Connection, error) {
+	var connections []Connection
+
+	scanner := bufio.NewScanner(r)
+	first := true
+	for scanner.Scan() {
+		// 跳过表头
+		if first {
+			first = false
+			continue
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue // 字段不完整，跳过
+		}
+
+		if fields[3] != procStateEstablished {
+			continue
+		}
+
+		localIP, localPort, err := parseProcAddr(fields[1])
+		if err != nil {
+			continue
+		}
+
+		remoteIP, remotePort, err := parseProcAddr(fields[2])
+		if err != nil {
+			continue
+		}
+
+		// tx_queue:rx_queue
+		var sendQ, recvQ int
+		if tx, rx, ok := strings.Cut(fields[4], ":"); ok {
+			if v, err := strconv.ParseUint(tx, 16, 32); err == nil {
+				sendQ = int(v)
+			}
+			if v, err := strconv.ParseUint(rx, 16, 32); err == nil {
+				recvQ = int(v)
+			}
+		}
+
+		connections = append(connections, Connection{
+			LocalAddr:  localIP,
+			LocalPort:  localPort,
+			RemoteAddr: remoteIP,
+			RemotePort: remotePort,
+			State:      "ESTAB",
+			RecvQ:      recvQ,
+			SendQ:      sendQ,
+			DetectedAt: now,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("解析 /proc/net/tcp 失败: %w", err)
+	}
+
+	return connections, nil
+}
+
+// parseProcAddr 解析 "HEXADDR:HEXPORT" 格式的地址
+func parseProcAddr(s string) (string, int, error) {
+	hexAddr, hexPort, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("地址格式无效: %s", s)
+	}
+
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("解析端口失败: %w", err)
+	}
+
+	raw, err := hex.DecodeString(hexAddr)
+	if err != nil {
+		return "", 0, fmt.Errorf("解析地址失败: %w", err)
+	}
+
+	// 每 4 字节为一个以本机字节序打印的 32 位字，还原为网络序字节
+	if len(raw) != 4 && len(raw) != 16 {
+		return "", 0, fmt.Errorf("地址长度无效: %s", hexAddr)
+	}
+	for i := 0; i < len(raw); i += 4 {
+		word := binary.BigEndian.Uint32(raw[i : i+4])
+		binary.NativeEndian.PutUint32(raw[i:i+4], word)
+	}
+
+	var addr netip.Addr
+	if len(raw) == 4 {
+		addr = netip.AddrFrom4([4]byte(raw))
+	} else {
+		addr = netip.AddrFrom16([16]byte(raw)).Unmap()
+	}
+
+	return addr.String(), int(port), nil
+}
@@ -0,0 +1,119 @@
+package monitor
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+// /proc/net/tcp 按本机字节序打印地址，以下夹具为小端机器上的输出
+func skipBigEndian(t *testing.T) {
+	t.Helper()
+	if binary.NativeEndian.Uint16([]byte{1, 0}) != 1 {
+		t.Skip("夹具按小端字节序编写")
+	}
+}
+
+const procNetTCP = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:01BB 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 12345 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:01BB 0B00000A:CC85 01 0000001A:00000002 00:00000000 00000000     0        0 12346 1 0000000000000000 20 4 30 10 -1
+   2: 0100007F:01BB 0B00000A:CC86 06 00000000:00000000 03:00001000 00000000     0        0 0 3 0000000000000000
+   3: 0100007F:01BB
+`
+
+const procNetTCP6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: B80D0120000000000000000001000000:01BB B80D01200000000000000000EFBE0000:CC85 01 00000000:00000000 00:00000000 00000000     0        0 22345 1 0000000000000000 20 4 30 10 -1
+   1: 0000000000000000FFFF00000100000A:01BB 0000000000000000FFFF0000077100CB:D431 01 00000000:00000000 00:00000000 00000000     0        0 22346 1 0000000000000000 20 4 30 10 -1
+   2: 00000000000000000000000000000000:01BB 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 22347 1 0000000000000000 100 0 0 10 0
+`
+
+func TestParseProcNetTCP(t *testing.T) {
+	skipBigEndian(t)
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Connection
+	}{
+		{
+			name:  "IPv4（跳过 LISTEN、TIME_WAIT 与不完整的行）",
+			input: procNetTCP,
+			want: []Connection{{
+				LocalAddr: "127.0.0.1", LocalPort: 443,
+				RemoteAddr: "10.0.0.11", RemotePort: 52357,
+				State: "ESTAB", SendQ: 0x1a, RecvQ: 2, DetectedAt: now,
+			}},
+		},
+		{
+			name:  "IPv6 与 IPv4-mapped",
+			input: procNetTCP6,
+			want: []Connection{
+				{
+					LocalAddr: "2001:db8::1", LocalPort: 443,
+					RemoteAddr: "2001:db8::beef", RemotePort: 52357,
+					State: "ESTAB", DetectedAt: now,
+				},
+				{
+					LocalAddr: "10.0.0.1", LocalPort: 443,
+					RemoteAddr: "203.0.113.7", RemotePort: 54321,
+					State: "ESTAB", DetectedAt: now,
+				},
+			},
+		},
+		{
+			name:  "只有表头",
+			input: "  sl  local_address rem_address   st\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProcNetTCP(strings.NewReader(tt.input), now)
+			if err != nil {
+				t.Fatalf("parseProcNetTCP: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("得到 %d 条连接 %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseProcAddr(t *testing.T) {
+	skipBigEndian(t)
+
+	tests := []struct {
+		input   string
+		ip      string
+		port    int
+		wantErr bool
+	}{
+		{input: "0100007F:01BB", ip: "127.0.0.1", port: 443},
+		{input: "B80D0120000000000000000001000000:0050", ip: "2001:db8::1", port: 80},
+		{input: "0000000000000000FFFF0000077100CB:1F90", ip: "203.0.113.7", port: 8080},
+		{input: "0100007F", wantErr: true},          // 缺少端口
+		{input: "0100007F:1FFFF", wantErr: true},    // 端口超出 16 位
+		{input: "0100007G:01BB", wantErr: true},     // 非十六进制
+		{input: "01000000007F:01BB", wantErr: true}, // 长度既不是 4 也不是 16 字节
+	}
+
+	for _, tt := range tests {
+		ip, port, err := parseProcAddr(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseProcAddr(%q) = %s:%d, want error", tt.input, ip, port)
+			}
+			continue
+		}
+		if err != nil || ip != tt.ip || port != tt.port {
+			t.Errorf("parseProcAddr(%q) = %s:%d, %v, want %s:%d", tt.input, ip, port, err, tt.ip, tt.port)
+		}
+	}
+}
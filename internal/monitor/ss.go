@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SSCollector 基于 ss 命令的连接采集器
+type SSCollector struct{}
+
+// NewSSCollector 创建 ss 采集器实例
+func NewSSCollector() *SSCollector {
+	return &SSCollector{}
+}
+
+// Name 数据源名称
+func (c *SSCollector) Name() string {
+	return "ss"
+}
+
+// Probe 检查 ss 命令是否可用
+func (c *SSCollector) Probe() error {
+	if err := exec.Command("ss", "-V").Run(); err != nil {
+		return fmt.Errorf("ss 命令不可用: %w", err)
+	}
+	return nil
+}
+
+// CollectConnections 采集指定端口的连接信息
+func (c *SSCollector) CollectConnections(port int) ([]Connection, error) {
+	// 执行 ss 命令: ss -tn state established sport = :<PORT>
+	cmd := exec.Command("ss", "-tn", "state", "established",
+		"sport", "=", fmt.Sprintf(":%d", port))
+
+	output, err := cmd.Output()
+	if err != nil {
+		// ss 命令执行失败，可能是权限问题或命令不存在
+		return nil, fmt.Errorf("执行 ss 命令失败: %w", err)
+	}
+
+	return parseSSOutput(output)
+}
+
+// CollectAllPorts 执行一次 ss 获取全部连接，再按端口分发
+func (c *SSCollector) CollectAllPorts(ports []int) (map[int][]Connection, error) {
+	// 执行 ss 命令: ss -tn state established
+	cmd := exec.Command("ss", "-tn", "state", "established")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("执行 ss 命令失败: %w", err)
+	}
+
+	connections, err := parseSSOutput(output)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupByLocalPort(connections, ports), nil
+}
+
+// parseSSOutput 解析 ss 命令输出
+func parseSSOutput(output []byte) ([]Connection, error) {
+	// ss 输出格式:
+	// State   Recv-Q   Send-Q   Local Address:Port   Peer Address:Port   Process
+	// ESTAB   0        0        0.0.0.0:443          203.0.113.1:52341
+	//
+	// 指定 state 过滤条件时 ss 不输出 State 列:
+	// Recv-Q   Send-Q   Local Address:Port   Peer Address:Port   Process
+	// 0        0        0.0.0.0:443          203.0.113.1:52341
+
+	lines := strings.Split(string(output), "\n")
+	var connections []Connection
+
+	now := time.Now()
+
+	for i, line := range lines {
+		// 跳过表头和空行
+		if i == 0 || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+
+		// 首列不是数字时为 State 列，否则视为已省略
+		state := "ESTAB"
+		if len(fields) > 0 {
+			if _, err := strconv.Atoi(fields[0]); err != nil {
+				state = fields[0]
+				fields = fields[1:]
+			}
+		}
+
+		if len(fields) < 4 {
+			continue // 字段不完整，跳过
+		}
+
+		// 解析字段
+		recvQ, _ := strconv.Atoi(fields[0])
+		sendQ, _ := strconv.Atoi(fields[1])
+		localAddr := fields[2]
+		peerAddr := fields[3]
+
+		// 解析本地地址
+		localIP, localPort, err := parseAddr(localAddr)
+		if err != nil {
+			continue
+		}
+
+		// 解析远程地址
+		remoteIP, remotePort, err := parseAddr(peerAddr)
+		if err != nil {
+			continue
+		}
+
+		connections = append(connections, Connection{
+			LocalAddr:  localIP,
+			LocalPort:  localPort,
+			RemoteAddr: remoteIP,
+			RemotePort: remotePort,
+			State:      state,
+			RecvQ:      recvQ,
+			SendQ:      sendQ,
+			DetectedAt: now,
+		})
+	}
+
+	return connections, nil
+}
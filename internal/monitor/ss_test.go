@@ -0,0 +1,70 @@
+package monitor
+
+import (
+	"testing"
+)
+
+func TestParseSSOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Connection
+	}{
+		{
+			name: "带 State 列",
+			output: `State   Recv-Q   Send-Q   Local Address:Port   Peer Address:Port   Process
+ESTAB   0        36       10.0.0.1:443         203.0.113.1:52341
+ESTAB   5        0        [2001:db8::1]:443    [2001:db8::beef]:40000
+`,
+			want: []Connection{
+				{LocalAddr: "10.0.0.1", LocalPort: 443, RemoteAddr: "203.0.113.1", RemotePort: 52341, State: "ESTAB", SendQ: 36},
+				{LocalAddr: "2001:db8::1", LocalPort: 443, RemoteAddr: "2001:db8::beef", RemotePort: 40000, State: "ESTAB", RecvQ: 5},
+			},
+		},
+		{
+			name: "指定 state 过滤时省略 State 列",
+			output: `Recv-Q   Send-Q   Local Address:Port       Peer Address:Port   Process
+0        0        [::ffff:10.0.0.1]:443    [::ffff:198.51.100.9]:50000
+0        0        10.0.0.1:443             203.0.113.2:50001   users:(("sing-box",pid=1,fd=9))
+`,
+			want: []Connection{
+				{LocalAddr: "10.0.0.1", LocalPort: 443, RemoteAddr: "198.51.100.9", RemotePort: 50000, State: "ESTAB"},
+				{LocalAddr: "10.0.0.1", LocalPort: 443, RemoteAddr: "203.0.113.2", RemotePort: 50001, State: "ESTAB"},
+			},
+		},
+		{
+			name: "非 ESTABLISHED 行保留原状态，不完整与无法解析的行跳过",
+			output: `State      Recv-Q Send-Q Local Address:Port Peer Address:Port
+TIME-WAIT  0      0      10.0.0.1:443       203.0.113.3:50002
+ESTAB      0      0      10.0.0.1:443
+
+ESTAB      0      0      10.0.0.1:https     203.0.113.4:50003
+`,
+			want: []Connection{
+				{LocalAddr: "10.0.0.1", LocalPort: 443, RemoteAddr: "203.0.113.3", RemotePort: 50002, State: "TIME-WAIT"},
+			},
+		},
+		{
+			name:   "只有表头",
+			output: "Recv-Q Send-Q Local Address:Port Peer Address:Port Process\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSSOutput([]byte(tt.output))
+			if err != nil {
+				t.Fatalf("parseSSOutput: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("得到 %d 条连接 %+v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				got[i].DetectedAt = tt.want[i].DetectedAt // 采集时间为当前时间，不参与比较
+				if got[i] != tt.want[i] {
+					t.Errorf("[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
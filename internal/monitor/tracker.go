@@ -1,23 +1,101 @@
 package monitor
 
 import (
+	"net/netip"
+	"sort"
 	"sync"
-	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/clock"
 )
 
 // PortTracker 端口会话追踪器
 type PortTracker struct {
 	Port     int                 `json:"port"`
-	Sessions map[string]*Session `json:"sessions"` // key: IP address
+	Sessions map[string]*Session `json:"sessions"` // key: IP 或聚合前缀
 	mu       sync.RWMutex
+	clock    clock.Clock
+
+	// 地址聚合前缀长度（0 表示按单个 IP）
+	ipv4PrefixLen int
+	ipv6PrefixLen int
 }
 
 // NewPortTracker 创建端口追踪器
 func NewPortTracker(port int) *PortTracker {
+	return NewPortTrackerWithClock(port, clock.Real())
+}
+
+// NewPortTrackerWithClock 使用指定时间源创建端口追踪器
+func NewPortTrackerWithClock(port int, clk clock.Clock) *PortTracker {
 	return &PortTracker{
 		Port:     port,
 		Sessions: make(map[string]*Session),
+		clock:    clk,
+	}
+}
+
+// SetPrefixLen 设置地址聚合前缀长度，已有会话按新粒度重新分组（保留最早的首次连接时间）
+func (pt *PortTracker) SetPrefixLen(ipv4PrefixLen, ipv6PrefixLen int) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	if pt.ipv4PrefixLen == ipv4PrefixLen && pt.ipv6PrefixLen == ipv6PrefixLen {
+		return
+	}
+	pt.ipv4PrefixLen = ipv4PrefixLen
+	pt.ipv6PrefixLen = ipv6PrefixLen
+
+	regrouped := make(map[string]*Session, len(pt.Sessions))
+	for _, old := range pt.Sessions {
+		for _, addr := range old.Addresses {
+			key := pt.sessionKey(addr)
+			session, exists := regrouped[key]
+			if !exists {
+				session = &Session{
+					IP:          key,
+					Port:        pt.Port,
+					FirstSeenAt: old.FirstSeenAt,
+					LastSeenAt:  old.LastSeenAt,
+				}
+				regrouped[key] = session
+			}
+			if old.FirstSeenAt.Before(session.FirstSeenAt) {
+				session.FirstSeenAt = old.FirstSeenAt
+			}
+			session.Addresses = append(session.Addresses, addr)
+		}
 	}
+
+	// 连接数在下一次 Update 时重新统计
+	for _, session := range regrouped {
+		sort.Strings(session.Addresses)
+	}
+	pt.Sessions = regrouped
+}
+
+// sessionKey 计算地址所属的会话 key（调用方需持有锁）
+func (pt *PortTracker) sessionKey(ip string) string {
+	return SessionKey(ip, pt.ipv4PrefixLen, pt.ipv6PrefixLen)
+}
+
+// SessionKey 按前缀长度将地址聚合为会话 key
+// 前缀长度为 0 或等于地址长度时返回地址本身，否则返回掩码后的前缀（如 2001:db8::/64）
+func SessionKey(ip string, ipv4PrefixLen, ipv6PrefixLen int) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap()
+
+	bits := ipv6PrefixLen
+	if addr.Is4() {
+		bits = ipv4PrefixLen
+	}
+	if bits <= 0 || bits >= addr.BitLen() {
+		return addr.String()
+	}
+
+	return netip.PrefixFrom(addr, bits).Masked().String()
 }
 
 // Update 更新会话状态
@@ -25,48 +103,61 @@ func (pt *PortTracker) Update(connections []Connection) {
 	pt.mu.Lock()
 	defer pt.mu.Unlock()
 
-	now := time.Now()
-	currentIPs := make(map[string]bool)
+	now := pt.clock.Now()
 
-	// 1. 更新现有会话 + 记录新会话
+	// 1. 按会话 key 分组连接
+	type group struct {
+		addrs map[string]bool
+		count int
+		bytes uint64
+	}
+	groups := make(map[string]*group)
 	for _, conn := range connections {
-		ip := conn.RemoteAddr
-		currentIPs[ip] = true
+		key := pt.sessionKey(conn.RemoteAddr)
+		g, exists := groups[key]
+		if !exists {
+			g = &group{addrs: make(map[string]bool)}
+			groups[key] = g
+		}
+		g.addrs[conn.RemoteAddr] = true
+		g.count++
+		g.bytes += conn.BytesSent + conn.BytesReceived
+	}
+
+	// 2. 更新现有会话 + 记录新会话
+	for key, g := range groups {
+		addrs := make([]string, 0, len(g.addrs))
+		for addr := range g.addrs {
+			addrs = append(addrs, addr)
+		}
+		sort.Strings(addrs)
 
-		if session, exists := pt.Sessions[ip]; exists {
+		if session, exists := pt.Sessions[key]; exists {
 			// 已存在的会话，只更新 LastSeenAt
 			session.LastSeenAt = now
-			session.ConnectionNum = countIPConnections(connections, ip)
+			session.ConnectionNum = g.count
+			session.Addresses = addrs
+			session.TotalBytes = g.bytes
 		} else {
 			// 新会话，记录首次连接时间
-			pt.Sessions[ip] = &Session{
-				IP:            ip,
+			pt.Sessions[key] = &Session{
+				IP:            key,
+				Addresses:     addrs,
 				Port:          pt.Port,
 				FirstSeenAt:   now,
 				LastSeenAt:    now,
-				ConnectionNum: countIPConnections(connections, ip),
-				TotalBytes:    0,
+				ConnectionNum: g.count,
+				TotalBytes:    g.bytes,
 			}
 		}
 	}
 
-	// 2. 清理已断开的会话
-	for ip := range pt.Sessions {
-		if !currentIPs[ip] {
-			delete(pt.Sessions, ip)
-		}
-	}
-}
-
-// countIPConnections 统计指定 IP 的连接数
-func countIPConnections(connections []Connection, ip string) int {
-	count := 0
-	for _, conn := range connections {
-		if conn.RemoteAddr == ip {
-			count++
+	// 3. 清理已断开的会话
+	for key := range pt.Sessions {
+		if _, exists := groups[key]; !exists {
+			delete(pt.Sessions, key)
 		}
 	}
-	return count
 }
 
 // GetActiveSessions 获取活跃会话列表
@@ -78,24 +169,26 @@ func (pt *PortTracker) GetActiveSessions() []*Session {
 	for _, session := range pt.Sessions {
 		// 返回副本，避免并发修改
 		sessionCopy := *session
+		sessionCopy.Addresses = append([]string(nil), session.Addresses...)
 		sessions = append(sessions, &sessionCopy)
 	}
 
 	return sessions
 }
 
-// GetSessionByIP 根据 IP 获取会话
+// GetSessionByIP 根据 IP（或会话 key）获取会话
 func (pt *PortTracker) GetSessionByIP(ip string) (*Session, bool) {
 	pt.mu.RLock()
 	defer pt.mu.RUnlock()
 
-	session, exists := pt.Sessions[ip]
+	session, exists := pt.Sessions[pt.sessionKey(ip)]
 	if !exists {
 		return nil, false
 	}
 
 	// 返回副本
 	sessionCopy := *session
+	sessionCopy.Addresses = append([]string(nil), session.Addresses...)
 	return &sessionCopy, true
 }
 
@@ -121,7 +214,7 @@ func (pt *PortTracker) GetStats() PortStats {
 		ActiveSessions:   len(pt.Sessions),
 		TotalConnections: totalConnections,
 		UniqueIPs:        len(pt.Sessions),
-		LastUpdated:      time.Now(),
+		LastUpdated:      pt.clock.Now(),
 	}
 }
 
@@ -132,13 +225,14 @@ func (pt *PortTracker) Clear() {
 	pt.Sessions = make(map[string]*Session)
 }
 
-// RemoveSession 移除指定会话
+// RemoveSession 移除指定会话（IP 或会话 key）
 func (pt *PortTracker) RemoveSession(ip string) bool {
 	pt.mu.Lock()
 	defer pt.mu.Unlock()
 
-	if _, exists := pt.Sessions[ip]; exists {
-		delete(pt.Sessions, ip)
+	key := pt.sessionKey(ip)
+	if _, exists := pt.Sessions[key]; exists {
+		delete(pt.Sessions, key)
 		return true
 	}
 	return false
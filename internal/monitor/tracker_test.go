@@ -0,0 +1,192 @@
+package monitor
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/clock"
+)
+
+func TestSessionKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		ip         string
+		ipv4, ipv6 int
+		want       string
+	}{
+		{name: "未聚合的 IPv4", ip: "192.0.2.77", want: "192.0.2.77"},
+		{name: "未聚合的 IPv6", ip: "2001:db8::1", want: "2001:db8::1"},
+		{name: "IPv4 /24", ip: "192.0.2.77", ipv4: 24, ipv6: 64, want: "192.0.2.0/24"},
+		{name: "IPv6 /64", ip: "2001:db8:0:1:aaaa::1", ipv4: 24, ipv6: 64, want: "2001:db8:0:1::/64"},
+		{name: "IPv6 前缀不影响 IPv4", ip: "192.0.2.77", ipv6: 64, want: "192.0.2.77"},
+		{name: "IPv4 映射地址按 IPv4 聚合", ip: "::ffff:192.0.2.77", ipv4: 24, ipv6: 64, want: "192.0.2.0/24"},
+		{name: "IPv4 映射地址去掉映射", ip: "::ffff:192.0.2.77", want: "192.0.2.77"},
+		{name: "IPv4 前缀等于地址长度", ip: "192.0.2.77", ipv4: 32, want: "192.0.2.77"},
+		{name: "IPv6 前缀超过地址长度", ip: "2001:db8::1", ipv6: 200, want: "2001:db8::1"},
+		{name: "无法解析时原样返回", ip: "not-an-ip", ipv4: 24, want: "not-an-ip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SessionKey(tt.ip, tt.ipv4, tt.ipv6); got != tt.want {
+				t.Errorf("SessionKey(%q, %d, %d) = %q, want %q", tt.ip, tt.ipv4, tt.ipv6, got, tt.want)
+			}
+		})
+	}
+}
+
+// sessionsByKey 会话 key -> 会话
+func sessionsByKey(tracker *PortTracker) map[string]*Session {
+	sessions := make(map[string]*Session)
+	for _, session := range tracker.GetActiveSessions() {
+		sessions[session.IP] = session
+	}
+	return sessions
+}
+
+func TestPortTrackerAggregation(t *testing.T) {
+	conns := []Connection{
+		{RemoteAddr: "2001:db8:0:1::a", BytesSent: 100, BytesReceived: 1},
+		{RemoteAddr: "2001:db8:0:1::a", BytesSent: 200, BytesReceived: 2},
+		{RemoteAddr: "2001:db8:0:1::b", BytesSent: 300, BytesReceived: 3},
+		{RemoteAddr: "2001:db8:0:2::a", BytesSent: 400},
+		{RemoteAddr: "192.0.2.1", BytesReceived: 10},
+		{RemoteAddr: "192.0.2.200", BytesReceived: 20},
+		{RemoteAddr: "198.51.100.1", BytesReceived: 30},
+	}
+
+	type want struct {
+		addresses []string
+		conns     int
+		bytes     uint64
+	}
+
+	tests := []struct {
+		name       string
+		ipv4, ipv6 int
+		want       map[string]want
+	}{
+		{
+			name: "按单个地址",
+			want: map[string]want{
+				"2001:db8:0:1::a": {addresses: []string{"2001:db8:0:1::a"}, conns: 2, bytes: 303},
+				"2001:db8:0:1::b": {addresses: []string{"2001:db8:0:1::b"}, conns: 1, bytes: 303},
+				"2001:db8:0:2::a": {addresses: []string{"2001:db8:0:2::a"}, conns: 1, bytes: 400},
+				"192.0.2.1":       {addresses: []string{"192.0.2.1"}, conns: 1, bytes: 10},
+				"192.0.2.200":     {addresses: []string{"192.0.2.200"}, conns: 1, bytes: 20},
+				"198.51.100.1":    {addresses: []string{"198.51.100.1"}, conns: 1, bytes: 30},
+			},
+		},
+		{
+			name: "IPv4 /24 与 IPv6 /64",
+			ipv4: 24,
+			ipv6: 64,
+			want: map[string]want{
+				"2001:db8:0:1::/64": {addresses: []string{"2001:db8:0:1::a", "2001:db8:0:1::b"}, conns: 3, bytes: 606},
+				"2001:db8:0:2::/64": {addresses: []string{"2001:db8:0:2::a"}, conns: 1, bytes: 400},
+				"192.0.2.0/24":      {addresses: []string{"192.0.2.1", "192.0.2.200"}, conns: 2, bytes: 30},
+				"198.51.100.0/24":   {addresses: []string{"198.51.100.1"}, conns: 1, bytes: 30},
+			},
+		},
+		{
+			name: "只聚合 IPv6",
+			ipv6: 48,
+			want: map[string]want{
+				"2001:db8::/48": {addresses: []string{"2001:db8:0:1::a", "2001:db8:0:1::b", "2001:db8:0:2::a"}, conns: 4, bytes: 1006},
+				"192.0.2.1":     {addresses: []string{"192.0.2.1"}, conns: 1, bytes: 10},
+				"192.0.2.200":   {addresses: []string{"192.0.2.200"}, conns: 1, bytes: 20},
+				"198.51.100.1":  {addresses: []string{"198.51.100.1"}, conns: 1, bytes: 30},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewPortTrackerWithClock(443, clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+			tracker.SetPrefixLen(tt.ipv4, tt.ipv6)
+			tracker.Update(conns)
+
+			// max_ips 按会话（前缀）计数
+			if got := tracker.Count(); got != len(tt.want) {
+				t.Errorf("Count() = %d, want %d", got, len(tt.want))
+			}
+
+			sessions := sessionsByKey(tracker)
+			for key, w := range tt.want {
+				session, ok := sessions[key]
+				if !ok {
+					t.Errorf("缺少会话 %s（现有 %v）", key, sessions)
+					continue
+				}
+				if !reflect.DeepEqual(session.Addresses, w.addresses) {
+					t.Errorf("%s Addresses = %v, want %v", key, session.Addresses, w.addresses)
+				}
+				if session.ConnectionNum != w.conns || session.TotalBytes != w.bytes {
+					t.Errorf("%s ConnectionNum/TotalBytes = %d/%d, want %d/%d", key, session.ConnectionNum, session.TotalBytes, w.conns, w.bytes)
+				}
+			}
+
+			// 按任一成员地址都能找到所属会话
+			if _, ok := tracker.GetSessionByIP("2001:db8:0:1::b"); !ok {
+				t.Error("GetSessionByIP() 未找到成员地址所属的会话")
+			}
+		})
+	}
+}
+
+func TestPortTrackerSetPrefixLen(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	tracker := NewPortTrackerWithClock(443, clk)
+
+	// 三个地址依次到达，FirstSeenAt 各差 1 秒
+	var conns []Connection
+	for _, addr := range []string{"2001:db8::b", "2001:db8::a", "192.0.2.9"} {
+		conns = append(conns, Connection{RemoteAddr: addr})
+		tracker.Update(conns)
+		clk.Advance(time.Second)
+	}
+
+	// 细粒度 -> /64：合并后保留最早的首次连接时间
+	tracker.SetPrefixLen(24, 64)
+	sessions := sessionsByKey(tracker)
+	if len(sessions) != 2 {
+		t.Fatalf("regrouped sessions = %v, want 2", sessions)
+	}
+	merged := sessions["2001:db8::/64"]
+	if merged == nil {
+		t.Fatalf("缺少 2001:db8::/64 会话: %v", sessions)
+	}
+	if !merged.FirstSeenAt.Equal(start) {
+		t.Errorf("FirstSeenAt = %v, want %v", merged.FirstSeenAt, start)
+	}
+	if want := []string{"2001:db8::a", "2001:db8::b"}; !reflect.DeepEqual(merged.Addresses, want) {
+		t.Errorf("Addresses = %v, want %v", merged.Addresses, want)
+	}
+	if sessions["192.0.2.0/24"] == nil {
+		t.Errorf("缺少 192.0.2.0/24 会话: %v", sessions)
+	}
+
+	// 下一次 Update 按新粒度统计连接数，且不重置首次连接时间
+	tracker.Update(conns)
+	merged, _ = tracker.GetSessionByIP("2001:db8::/64")
+	if merged.ConnectionNum != 2 || !merged.FirstSeenAt.Equal(start) {
+		t.Errorf("after Update: ConnectionNum = %d, FirstSeenAt = %v", merged.ConnectionNum, merged.FirstSeenAt)
+	}
+
+	// /64 -> 单个地址：拆分后各地址继承前缀会话的首次连接时间
+	tracker.SetPrefixLen(0, 0)
+	var keys []string
+	for key, session := range sessionsByKey(tracker) {
+		keys = append(keys, key)
+		if key != "192.0.2.9" && !session.FirstSeenAt.Equal(start) {
+			t.Errorf("%s FirstSeenAt = %v, want %v", key, session.FirstSeenAt, start)
+		}
+	}
+	sort.Strings(keys)
+	if want := []string{"192.0.2.9", "2001:db8::a", "2001:db8::b"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("split sessions = %v, want %v", keys, want)
+	}
+}
@@ -1,27 +1,34 @@
 package monitor
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Connection TCP 连接信息
 type Connection struct {
-	LocalAddr  string    `json:"local_addr"`  // 本地地址
-	LocalPort  int       `json:"local_port"`  // 本地端口
-	RemoteAddr string    `json:"remote_addr"` // 远程地址
-	RemotePort int       `json:"remote_port"` // 远程端口
-	State      string    `json:"state"`       // 连接状态
-	RecvQ      int       `json:"recv_q"`      // 接收队列
-	SendQ      int       `json:"send_q"`      // 发送队列
-	DetectedAt time.Time `json:"detected_at"` // 检测时间
+	LocalAddr     string    `json:"local_addr"`     // 本地地址
+	LocalPort     int       `json:"local_port"`     // 本地端口
+	RemoteAddr    string    `json:"remote_addr"`    // 远程地址
+	RemotePort    int       `json:"remote_port"`    // 远程端口
+	State         string    `json:"state"`          // 连接状态
+	RecvQ         int       `json:"recv_q"`         // 接收队列
+	SendQ         int       `json:"send_q"`         // 发送队列
+	BytesSent     uint64    `json:"bytes_sent"`     // 已确认发送的字节数（仅 netlink 数据源）
+	BytesReceived uint64    `json:"bytes_received"` // 已接收的字节数（仅 netlink 数据源）
+	DetectedAt    time.Time `json:"detected_at"`    // 检测时间
 }
 
 // Session 会话信息
 type Session struct {
-	IP            string    `json:"ip"`              // 远程 IP
+	IP            string    `json:"ip"`              // 远程 IP（启用地址聚合时为前缀，如 2001:db8::/64）
+	Addresses     []string  `json:"addresses"`       // 会话包含的远程地址
 	Port          int       `json:"port"`            // 本地端口
 	FirstSeenAt   time.Time `json:"first_seen_at"`   // 首次连接时间
 	LastSeenAt    time.Time `json:"last_seen_at"`    // 最后一次检测到的时间
 	ConnectionNum int       `json:"connection_num"`  // 当前连接数
-	TotalBytes    uint64    `json:"total_bytes"`     // 总字节数（可选）
+	TotalBytes    uint64    `json:"total_bytes"`     // 当前连接收发的总字节数（仅 netlink 数据源）
 }
 
 // PortStats 端口统计信息
@@ -32,3 +39,42 @@ type PortStats struct {
 	UniqueIPs         int       `json:"unique_ips"`          // 独立 IP 数
 	LastUpdated       time.Time `json:"last_updated"`        // 最后更新时间
 }
+
+// ReconfigureSummary 热重载变更摘要
+type ReconfigureSummary struct {
+	Added       []int `json:"added"`        // 新增监控的端口
+	Removed     []int `json:"removed"`      // 移出监控的端口
+	Updated     []int `json:"updated"`      // 规则有变化的端口
+	Unchanged   int   `json:"unchanged"`    // 规则未变化的端口数
+	OldInterval int   `json:"old_interval"` // 原检查周期（秒）
+	NewInterval int   `json:"new_interval"` // 新检查周期（秒）
+	ModeChanged bool  `json:"mode_changed"` // 采集模式是否变化
+}
+
+// HasChanges 是否有任何变更
+func (s *ReconfigureSummary) HasChanges() bool {
+	return len(s.Added) > 0 || len(s.Removed) > 0 || len(s.Updated) > 0 ||
+		s.OldInterval != s.NewInterval || s.ModeChanged
+}
+
+// String 返回便于记录日志的摘要文本
+func (s *ReconfigureSummary) String() string {
+	if !s.HasChanges() {
+		return fmt.Sprintf("无变更（%d 个端口）", s.Unchanged)
+	}
+
+	parts := []string{
+		fmt.Sprintf("新增 %v", s.Added),
+		fmt.Sprintf("移除 %v", s.Removed),
+		fmt.Sprintf("更新 %v", s.Updated),
+		fmt.Sprintf("未变 %d", s.Unchanged),
+	}
+	if s.OldInterval != s.NewInterval {
+		parts = append(parts, fmt.Sprintf("检查周期 %ds → %ds", s.OldInterval, s.NewInterval))
+	}
+	if s.ModeChanged {
+		parts = append(parts, "采集模式已切换")
+	}
+
+	return strings.Join(parts, "，")
+}
@@ -0,0 +1,60 @@
+// Package notify 将执行事件（超限、封禁、解封等）异步投递到 Webhook
+package notify
+
+import (
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/config"
+)
+
+// EventType 事件类型
+type EventType string
+
+const (
+	EventOverlimit EventType = config.NotifyEventOverlimit
+	EventBan       EventType = config.NotifyEventBan
+	EventUnban     EventType = config.NotifyEventUnban
+	EventBlacklist EventType = config.NotifyEventBlacklist
+	EventKick      EventType = config.NotifyEventKick
+	EventAllow     EventType = config.NotifyEventAllow
+	EventReload    EventType = config.NotifyEventReload
+	EventStart     EventType = config.NotifyEventStart
+	EventStop      EventType = config.NotifyEventStop
+)
+
+// Event 一条通知事件
+type Event struct {
+	Type    EventType         `json:"event"`
+	Time    time.Time         `json:"time"`
+	Host    string            `json:"host"`           // 由 Notifier 填充
+	Port    int               `json:"port,omitempty"` // 0 表示与端口无关（启动、重载等）
+	IP      string            `json:"ip,omitempty"`
+	Message string            `json:"message"`           // 面向人的描述
+	Details map[string]string `json:"details,omitempty"` // 附加字段（原因、策略、时长等）
+}
+
+// title 事件标题（Telegram / Discord 文本）
+func (e Event) title() string {
+	switch e.Type {
+	case EventOverlimit:
+		return "⚠️ 端口超限"
+	case EventBan:
+		return "🚫 封禁"
+	case EventUnban:
+		return "✅ 解封"
+	case EventBlacklist:
+		return "⛔ 黑名单连接"
+	case EventKick:
+		return "✂️ 手动断开"
+	case EventAllow:
+		return "🟢 临时白名单"
+	case EventReload:
+		return "🔄 配置重载"
+	case EventStart:
+		return "🚀 NAM 启动"
+	case EventStop:
+		return "🛑 NAM 停止"
+	default:
+		return string(e.Type)
+	}
+}
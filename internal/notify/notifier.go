@@ -0,0 +1,304 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/clock"
+	"github.com/nodeaccessmanager/nam/internal/config"
+	"github.com/nodeaccessmanager/nam/pkg/utils"
+)
+
+const (
+	defaultRateLimit  = 10  // 每类事件每分钟
+	defaultQueueSize  = 100 // 待发送事件
+	defaultMaxRetries = 3
+
+	rateWindow     = time.Minute
+	sendTimeout    = 10 * time.Second
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 30 * time.Second
+	closeTimeout   = 5 * time.Second
+)
+
+// Stats 通知投递统计
+type Stats struct {
+	Sent        uint64 `json:"sent"`
+	Failed      uint64 `json:"failed"`       // 重试耗尽仍失败
+	Dropped     uint64 `json:"dropped"`      // 队列已满丢弃
+	RateLimited uint64 `json:"rate_limited"` // 超过频率限制丢弃
+}
+
+// Notifier 异步通知器：Notify 只做过滤与入队，从不阻塞调用方；
+// 后台协程负责投递、失败重试（指数退避）
+type Notifier struct {
+	sender     Sender
+	events     map[EventType]bool // nil 表示订阅全部事件
+	rateLimit  int
+	maxRetries int
+	host       string
+
+	queue  chan Event
+	stop   chan struct{}
+	done   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu         sync.Mutex
+	clock      clock.Clock
+	retryDelay time.Duration
+	windows    map[EventType]*rateState
+	stats      Stats
+	closed     bool
+}
+
+// rateState 单类事件的固定窗口计数
+type rateState struct {
+	start time.Time
+	count int
+}
+
+// NewNotifier 按配置创建通知器，未启用时返回 nil（nil 通知器的方法均为空操作）
+func NewNotifier(cfg config.NotificationConfig) *Notifier {
+	if !cfg.Enabled || cfg.WebhookURL == "" {
+		return nil
+	}
+	client := &http.Client{Timeout: sendTimeout}
+	return NewNotifierWithSender(cfg, NewWebhookSender(cfg.Type, cfg.WebhookURL, cfg.TelegramChatID, client))
+}
+
+// NewNotifierWithSender 使用指定的投递方式创建通知器（测试中可传入指向 httptest 服务器的 WebhookSender）
+func NewNotifierWithSender(cfg config.NotificationConfig, sender Sender) *Notifier {
+	host, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	n := &Notifier{
+		sender:     sender,
+		rateLimit:  valueOr(cfg.RateLimit, defaultRateLimit),
+		maxRetries: valueOr(cfg.MaxRetries, defaultMaxRetries),
+		host:       host,
+		queue:      make(chan Event, valueOr(cfg.QueueSize, defaultQueueSize)),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+		clock:      clock.Real(),
+		retryDelay: retryBaseDelay,
+		windows:    make(map[EventType]*rateState),
+	}
+
+	if len(cfg.Events) > 0 {
+		n.events = make(map[EventType]bool, len(cfg.Events))
+		for _, event := range cfg.Events {
+			n.events[EventType(event)] = true
+		}
+	}
+
+	go n.run()
+	return n
+}
+
+// SetClock 设置时间源（频率限制窗口与重试退避使用）
+func (n *Notifier) SetClock(clk clock.Clock) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.clock = clk
+}
+
+// SetRetryDelay 设置首次重试的等待时间，之后每次翻倍
+func (n *Notifier) SetRetryDelay(delay time.Duration) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.retryDelay = delay
+}
+
+// Notify 提交一条事件（未订阅、超过频率限制或队列已满时丢弃，不阻塞）
+func (n *Notifier) Notify(event Event) {
+	if n == nil {
+		return
+	}
+	if n.events != nil && !n.events[event.Type] {
+		return
+	}
+
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return
+	}
+	now := n.clock.Now()
+	if event.Time.IsZero() {
+		event.Time = now
+	}
+	event.Host = n.host
+
+	if !n.allow(event.Type, now) {
+		n.stats.RateLimited++
+		n.mu.Unlock()
+		utils.GetLogger().Debugf("通知 %s 超过频率限制，已丢弃", event.Type)
+		return
+	}
+	n.mu.Unlock()
+
+	select {
+	case n.queue <- event:
+	default:
+		n.mu.Lock()
+		n.stats.Dropped++
+		n.mu.Unlock()
+		utils.GetLogger().Warnf("通知队列已满，丢弃 %s 事件", event.Type)
+	}
+}
+
+// allow 固定窗口频率限制（调用方需持有锁）
+func (n *Notifier) allow(eventType EventType, now time.Time) bool {
+	state, ok := n.windows[eventType]
+	if !ok || now.Sub(state.start) >= rateWindow {
+		n.windows[eventType] = &rateState{start: now, count: 1}
+		return true
+	}
+	if state.count >= n.rateLimit {
+		return false
+	}
+	state.count++
+	return true
+}
+
+// Stats 返回投递统计
+func (n *Notifier) Stats() Stats {
+	if n == nil {
+		return Stats{}
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.stats
+}
+
+// Close 停止接收新事件，尽量发出队列中剩余的事件（不再重试，最多等待 5 秒）
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return
+	}
+	n.closed = true
+	n.mu.Unlock()
+
+	close(n.stop)
+	select {
+	case <-n.done:
+	case <-time.After(closeTimeout):
+		utils.GetLogger().Warn("等待通知发送超时，剩余事件已丢弃")
+		n.cancel()
+		<-n.done
+	}
+	n.cancel()
+}
+
+// run 后台投递协程
+func (n *Notifier) run() {
+	defer close(n.done)
+
+	for {
+		select {
+		case event := <-n.queue:
+			n.deliver(event, true)
+		case <-n.stop:
+			for {
+				select {
+				case event := <-n.queue:
+					n.deliver(event, false)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver 发送一条事件，失败时按指数退避重试
+func (n *Notifier) deliver(event Event, retry bool) {
+	logger := utils.GetLogger()
+
+	n.mu.Lock()
+	delay := n.retryDelay
+	n.mu.Unlock()
+
+	attempts := 1
+	if retry {
+		attempts += n.maxRetries
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		ctx, cancel := context.WithTimeout(n.ctx, sendTimeout)
+		err = n.sender.Send(ctx, event)
+		cancel()
+
+		if err == nil {
+			n.mu.Lock()
+			n.stats.Sent++
+			n.mu.Unlock()
+			return
+		}
+
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) && !httpErr.Retryable() {
+			break
+		}
+		if attempt == attempts || !n.wait(delay) {
+			break
+		}
+
+		logger.Debugf("发送 %s 通知失败（第 %d 次），%s 后重试: %v", event.Type, attempt, delay, err)
+		delay *= 2
+		if delay > retryMaxDelay {
+			delay = retryMaxDelay
+		}
+	}
+
+	n.mu.Lock()
+	n.stats.Failed++
+	n.mu.Unlock()
+	logger.Warnf("发送 %s 通知失败（%s）: %v", event.Type, n.sender.Name(), err)
+}
+
+// wait 等待重试间隔，关闭时提前返回 false
+func (n *Notifier) wait(delay time.Duration) bool {
+	n.mu.Lock()
+	clk := n.clock
+	n.mu.Unlock()
+
+	fired := make(chan struct{})
+	timer := clk.AfterFunc(delay, func() { close(fired) })
+	defer timer.Stop()
+
+	select {
+	case <-fired:
+		return true
+	case <-n.stop:
+		return false
+	}
+}
+
+// valueOr 配置值为 0 时使用默认值
+func valueOr(value, def int) int {
+	if value > 0 {
+		return value
+	}
+	return def
+}
@@ -0,0 +1,247 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/clock"
+	"github.com/nodeaccessmanager/nam/internal/config"
+)
+
+// webhookServer 按顺序返回 statuses 中的状态码（用尽后返回 200），收到的事件依次写入 received
+type webhookServer struct {
+	*httptest.Server
+	received chan Event
+	requests atomic.Int32
+}
+
+func newWebhookServer(t *testing.T, statuses []int, release <-chan struct{}) *webhookServer {
+	t.Helper()
+	s := &webhookServer{received: make(chan Event, 100)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("解码请求体失败: %v", err)
+		}
+		s.received <- event
+
+		if release != nil {
+			<-release
+		}
+		if i := int(s.requests.Add(1)) - 1; i < len(statuses) {
+			w.WriteHeader(statuses[i])
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// newTestNotifier 创建投递到测试服务器的通知器（使用假时钟）
+func newTestNotifier(t *testing.T, cfg config.NotificationConfig, server *webhookServer) (*Notifier, *clock.Fake) {
+	t.Helper()
+	cfg.Enabled = true
+	cfg.WebhookURL = server.URL
+
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	n := NewNotifierWithSender(cfg, NewWebhookSender(config.NotifyTypeGeneric, server.URL, "", server.Client()))
+	n.SetClock(clk)
+	t.Cleanup(n.Close)
+	return n, clk
+}
+
+// receive 等待服务器收到下一条事件
+func (s *webhookServer) receive(t *testing.T) Event {
+	t.Helper()
+	select {
+	case event := <-s.received:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("等待 Webhook 请求超时")
+		return Event{}
+	}
+}
+
+// waitFor 轮询直到 cond 成立
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待%s超时", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNotifierRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		maxRetries int
+		delays     []time.Duration // 各次重试前的退避时间
+		want       Stats
+	}{
+		{
+			name:       "5xx 后重试成功",
+			statuses:   []int{503, 502},
+			maxRetries: 3,
+			delays:     []time.Duration{time.Second, 2 * time.Second},
+			want:       Stats{Sent: 1},
+		},
+		{
+			name:       "429 视为可重试",
+			statuses:   []int{429},
+			maxRetries: 3,
+			delays:     []time.Duration{time.Second},
+			want:       Stats{Sent: 1},
+		},
+		{
+			name:       "重试耗尽",
+			statuses:   []int{500, 500, 500},
+			maxRetries: 2,
+			delays:     []time.Duration{time.Second, 2 * time.Second},
+			want:       Stats{Failed: 1},
+		},
+		{
+			name:       "4xx 不重试",
+			statuses:   []int{400},
+			maxRetries: 3,
+			want:       Stats{Failed: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWebhookServer(t, tt.statuses, nil)
+			n, clk := newTestNotifier(t, config.NotificationConfig{MaxRetries: tt.maxRetries}, server)
+			n.SetRetryDelay(time.Second)
+
+			n.Notify(Event{Type: EventBan, IP: "192.0.2.1", Message: "ban"})
+			server.receive(t)
+
+			for i, delay := range tt.delays {
+				waitFor(t, "退避定时器", func() bool { return clk.Pending() == 1 })
+
+				// 退避时间未到不重试
+				clk.Advance(delay - time.Millisecond)
+				if clk.Pending() != 1 {
+					t.Fatalf("第 %d 次重试早于 %s 触发", i+1, delay)
+				}
+				clk.Advance(time.Millisecond)
+
+				if event := server.receive(t); event.IP != "192.0.2.1" {
+					t.Errorf("重试的事件 = %+v", event)
+				}
+			}
+
+			waitFor(t, "投递结果", func() bool {
+				stats := n.Stats()
+				return stats.Sent+stats.Failed > 0
+			})
+			if got := n.Stats(); got != tt.want {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.want)
+			}
+			if got := int(server.requests.Load()); got != len(tt.delays)+1 {
+				t.Errorf("请求次数 = %d, want %d", got, len(tt.delays)+1)
+			}
+		})
+	}
+}
+
+func TestNotifierRateLimit(t *testing.T) {
+	server := newWebhookServer(t, nil, nil)
+	n, clk := newTestNotifier(t, config.NotificationConfig{
+		RateLimit: 2,
+		Events:    []string{config.NotifyEventBan, config.NotifyEventUnban},
+	}, server)
+
+	// 每类事件独立计数，未订阅的事件直接忽略
+	for _, eventType := range []EventType{EventBan, EventBan, EventBan, EventUnban, EventOverlimit} {
+		n.Notify(Event{Type: eventType})
+	}
+	waitFor(t, "投递", func() bool { return n.Stats().Sent == 3 })
+	if got, want := n.Stats(), (Stats{Sent: 3, RateLimited: 1}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+
+	counts := make(map[EventType]int)
+	for i := 0; i < 3; i++ {
+		event := server.receive(t)
+		counts[event.Type]++
+		if event.Time.IsZero() || event.Host == "" {
+			t.Errorf("事件未填充时间与主机名: %+v", event)
+		}
+	}
+	if counts[EventBan] != 2 || counts[EventUnban] != 1 {
+		t.Errorf("received = %v", counts)
+	}
+
+	// 窗口内仍受限，窗口结束后重新计数
+	clk.Advance(rateWindow - time.Second)
+	n.Notify(Event{Type: EventBan})
+	clk.Advance(time.Second)
+	n.Notify(Event{Type: EventBan})
+	waitFor(t, "投递", func() bool { return n.Stats().Sent == 4 })
+	if got, want := n.Stats(), (Stats{Sent: 4, RateLimited: 2}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNotifierQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	server := newWebhookServer(t, nil, release)
+	n, _ := newTestNotifier(t, config.NotificationConfig{QueueSize: 1}, server)
+	var once sync.Once
+	unblock := func() { once.Do(func() { close(release) }) }
+	t.Cleanup(unblock) // 失败提前返回时放行被阻塞的请求
+
+	// 第一条事件占住投递协程，第二条进入队列
+	n.Notify(Event{Type: EventBan, IP: "192.0.2.1"})
+	server.receive(t)
+	n.Notify(Event{Type: EventBan, IP: "192.0.2.2"})
+
+	// 队列已满时立即丢弃，不阻塞调用方
+	done := make(chan struct{})
+	go func() {
+		n.Notify(Event{Type: EventBan, IP: "192.0.2.3"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("队列已满时 Notify 阻塞")
+	}
+	if got := n.Stats().Dropped; got != 1 {
+		t.Errorf("Dropped = %d, want 1", got)
+	}
+
+	unblock()
+	if event := server.receive(t); event.IP != "192.0.2.2" {
+		t.Errorf("第二个投递的事件 = %s, want 192.0.2.2", event.IP)
+	}
+	waitFor(t, "投递", func() bool { return n.Stats().Sent == 2 })
+	if got, want := n.Stats(), (Stats{Sent: 2, Dropped: 1}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNilNotifier(t *testing.T) {
+	n := NewNotifier(config.NotificationConfig{Enabled: false, WebhookURL: "https://hooks.example.com/nam"})
+	if n != nil {
+		t.Fatalf("未启用时 NewNotifier() = %v, want nil", n)
+	}
+
+	// nil 通知器的方法均为空操作
+	n.Notify(Event{Type: EventBan})
+	n.SetClock(clock.Real())
+	n.SetRetryDelay(time.Second)
+	if got := n.Stats(); got != (Stats{}) {
+		t.Errorf("Stats() = %+v", got)
+	}
+	n.Close()
+}
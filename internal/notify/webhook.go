@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/nodeaccessmanager/nam/internal/config"
+)
+
+// Sender 通知投递方式
+type Sender interface {
+	// Name 投递方式名称
+	Name() string
+	// Send 发送一条事件（返回 *HTTPError 时按状态码判断是否重试）
+	Send(ctx context.Context, event Event) error
+}
+
+// HTTPError Webhook 返回非 2xx 状态码
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+// Error 实现 error 接口
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("webhook 返回 HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// Retryable 429 与 5xx 可重试，其余 4xx 视为配置错误
+func (e *HTTPError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// WebhookSender 以 JSON POST 投递事件，支持通用、Telegram 与 Discord 三种格式
+type WebhookSender struct {
+	kind   string
+	url    string
+	chatID string
+	client *http.Client
+}
+
+// NewWebhookSender 创建 Webhook 投递器（kind 为空时按 URL 识别 Telegram / Discord）
+func NewWebhookSender(kind, url, chatID string, client *http.Client) *WebhookSender {
+	if kind == "" {
+		kind = detectType(url)
+	}
+	return &WebhookSender{kind: kind, url: url, chatID: chatID, client: client}
+}
+
+// Name 投递方式名称
+func (w *WebhookSender) Name() string {
+	return w.kind
+}
+
+// Send 发送一条事件
+func (w *WebhookSender) Send(ctx context.Context, event Event) error {
+	body, err := w.payload(event)
+	if err != nil {
+		return fmt.Errorf("编码通知失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "nam-notifier")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &HTTPError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(msg))}
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// payload 按类型编码请求体
+func (w *WebhookSender) payload(event Event) ([]byte, error) {
+	switch w.kind {
+	case config.NotifyTypeTelegram:
+		return json.Marshal(map[string]any{
+			"chat_id":                  w.chatID,
+			"text":                     formatText(event),
+			"disable_web_page_preview": true,
+		})
+	case config.NotifyTypeDiscord:
+		return json.Marshal(map[string]any{
+			"username": "NAM",
+			"content":  formatText(event),
+		})
+	default:
+		return json.Marshal(event)
+	}
+}
+
+// formatText 人类可读的通知文本
+func formatText(event Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s [%s]\n%s", event.title(), event.Host, event.Message)
+
+	keys := make([]string, 0, len(event.Details))
+	for key := range event.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\n%s: %s", key, event.Details[key])
+	}
+
+	fmt.Fprintf(&b, "\n%s", event.Time.Format("2006-01-02 15:04:05"))
+	return b.String()
+}
+
+// detectType 按 URL 识别 Webhook 类型
+func detectType(url string) string {
+	switch {
+	case strings.Contains(url, "api.telegram.org"):
+		return config.NotifyTypeTelegram
+	case strings.Contains(url, "discord.com/api/webhooks"), strings.Contains(url, "discordapp.com/api/webhooks"):
+		return config.NotifyTypeDiscord
+	default:
+		return config.NotifyTypeGeneric
+	}
+}
@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/config"
+)
+
+// testEvent 固定内容的封禁事件
+func testEvent() Event {
+	return Event{
+		Type:    EventBan,
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Host:    "node-1",
+		Port:    443,
+		IP:      "192.0.2.1",
+		Message: "192.0.2.1 已被封禁",
+		Details: map[string]string{"strategy": "FIFO", "duration": "1h"},
+	}
+}
+
+func TestWebhookPayload(t *testing.T) {
+	text := "🚫 封禁 [node-1]\n192.0.2.1 已被封禁\nduration: 1h\nstrategy: FIFO\n2024-01-02 03:04:05"
+
+	tests := []struct {
+		kind string
+		want map[string]any
+	}{
+		{
+			kind: config.NotifyTypeGeneric,
+			want: map[string]any{
+				"event":   "ban",
+				"time":    "2024-01-02T03:04:05Z",
+				"host":    "node-1",
+				"port":    float64(443),
+				"ip":      "192.0.2.1",
+				"message": "192.0.2.1 已被封禁",
+				"details": map[string]any{"strategy": "FIFO", "duration": "1h"},
+			},
+		},
+		{
+			kind: config.NotifyTypeTelegram,
+			want: map[string]any{
+				"chat_id":                  "-1001234",
+				"text":                     text,
+				"disable_web_page_preview": true,
+			},
+		},
+		{
+			kind: config.NotifyTypeDiscord,
+			want: map[string]any{
+				"username": "NAM",
+				"content":  text,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			var got map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					t.Errorf("Method = %s, want POST", r.Method)
+				}
+				if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+					t.Errorf("Content-Type = %q", ct)
+				}
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Errorf("请求体不是 JSON: %v", err)
+				}
+			}))
+			defer server.Close()
+
+			sender := NewWebhookSender(tt.kind, server.URL, "-1001234", server.Client())
+			if err := sender.Send(context.Background(), testEvent()); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("payload = %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookHTTPError(t *testing.T) {
+	tests := []struct {
+		status    int
+		retryable bool
+	}{
+		{status: http.StatusBadRequest, retryable: false},
+		{status: http.StatusNotFound, retryable: false},
+		{status: http.StatusTooManyRequests, retryable: true},
+		{status: http.StatusInternalServerError, retryable: true},
+		{status: http.StatusBadGateway, retryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "nope", tt.status)
+			}))
+			defer server.Close()
+
+			err := NewWebhookSender(config.NotifyTypeGeneric, server.URL, "", server.Client()).Send(context.Background(), testEvent())
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("Send() error = %v, want *HTTPError", err)
+			}
+			if httpErr.StatusCode != tt.status || httpErr.Body != "nope" {
+				t.Errorf("HTTPError = %+v", httpErr)
+			}
+			if httpErr.Retryable() != tt.retryable {
+				t.Errorf("Retryable() = %v, want %v", httpErr.Retryable(), tt.retryable)
+			}
+		})
+	}
+}
+
+func TestDetectType(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://api.telegram.org/bot123:abc/sendMessage", want: config.NotifyTypeTelegram},
+		{url: "https://discord.com/api/webhooks/1/abc", want: config.NotifyTypeDiscord},
+		{url: "https://discordapp.com/api/webhooks/1/abc", want: config.NotifyTypeDiscord},
+		{url: "https://hooks.example.com/nam", want: config.NotifyTypeGeneric},
+	}
+
+	for _, tt := range tests {
+		if got := NewWebhookSender("", tt.url, "", nil).Name(); got != tt.want {
+			t.Errorf("NewWebhookSender(%q).Name() = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
@@ -59,6 +59,8 @@ func createTables(db *sql.DB) error {
 	tables := []string{
 		CreateSessionsTable,
 		CreateBanHistoryTable,
+		CreateActiveBansTable,
+		CreateTempAllowsTable,
 		CreateStatisticsTable,
 	}
 
@@ -81,8 +83,8 @@ func (d *Database) Close() error {
 // RecordSession 记录会话
 func (d *Database) RecordSession(session *monitor.Session) error {
 	query := `
-INSERT INTO sessions (port, ip, first_seen_at, last_seen_at, connection_num)
-VALUES (?, ?, ?, ?, ?)
+INSERT INTO sessions (port, ip, first_seen_at, last_seen_at, connection_num, total_bytes)
+VALUES (?, ?, ?, ?, ?, ?)
 `
 	_, err := d.db.Exec(query,
 		session.Port,
@@ -90,6 +92,7 @@ VALUES (?, ?, ?, ?, ?)
 		session.FirstSeenAt,
 		session.LastSeenAt,
 		session.ConnectionNum,
+		session.TotalBytes,
 	)
 
 	return err
@@ -157,6 +160,248 @@ LIMIT ?
 	return records, nil
 }
 
+// BanHistoryEntry 带行号的封禁历史（行号递增，用于增量读取）
+type BanHistoryEntry struct {
+	ID int64
+	enforcer.BanRecord
+}
+
+// GetBanHistorySince 获取行号大于 afterID 的最近封禁历史（全部端口，按时间升序，最多 limit 条）
+func (d *Database) GetBanHistorySince(afterID int64, limit int) ([]BanHistoryEntry, error) {
+	query := `
+SELECT id, ip, port, banned_at, expire_at, duration, strategy, reason
+FROM ban_history
+WHERE id > ?
+ORDER BY id DESC
+LIMIT ?
+`
+	rows, err := d.db.Query(query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []BanHistoryEntry
+	for rows.Next() {
+		var entry BanHistoryEntry
+		var reason sql.NullString
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.IP,
+			&entry.Port,
+			&entry.BannedAt,
+			&entry.ExpireAt,
+			&entry.Duration,
+			&entry.Strategy,
+			&reason,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if reason.Valid {
+			entry.Reason = reason.String
+		}
+
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 倒序取最新的 limit 条，再恢复为时间顺序
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// GetRecentSessions 获取端口最近记录的会话（每个 IP 只取最后一条，最多 limit 条）
+func (d *Database) GetRecentSessions(port int, limit int) ([]*monitor.Session, error) {
+	query := `
+SELECT ip, first_seen_at, last_seen_at, connection_num, total_bytes
+FROM sessions
+WHERE port = ?
+ORDER BY last_seen_at DESC
+`
+	rows, err := d.db.Query(query, port)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	var sessions []*monitor.Session
+	for rows.Next() && len(sessions) < limit {
+		session := &monitor.Session{Port: port}
+
+		err := rows.Scan(
+			&session.IP,
+			&session.FirstSeenAt,
+			&session.LastSeenAt,
+			&session.ConnectionNum,
+			&session.TotalBytes,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[session.IP] {
+			continue
+		}
+		seen[session.IP] = true
+		session.Addresses = []string{session.IP}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// SaveActiveBan 保存活跃封禁（同一 IP:端口 覆盖旧记录）
+func (d *Database) SaveActiveBan(record *enforcer.BanRecord) error {
+	query := `
+INSERT OR REPLACE INTO active_bans (ip, port, banned_at, expire_at, duration, strategy, reason)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+	// 永久封禁的过期时间存为 NULL
+	var expireAt interface{}
+	if !record.ExpireAt.IsZero() {
+		expireAt = record.ExpireAt
+	}
+
+	_, err := d.db.Exec(query,
+		record.IP,
+		record.Port,
+		record.BannedAt,
+		expireAt,
+		record.Duration,
+		record.Strategy,
+		record.Reason,
+	)
+
+	return err
+}
+
+// DeleteActiveBan 删除活跃封禁
+func (d *Database) DeleteActiveBan(ip string, port int) error {
+	_, err := d.db.Exec(`DELETE FROM active_bans WHERE ip = ? AND port = ?`, ip, port)
+	return err
+}
+
+// LoadActiveBans 读取全部活跃封禁
+func (d *Database) LoadActiveBans() ([]enforcer.BanRecord, error) {
+	query := `
+SELECT ip, port, banned_at, expire_at, duration, strategy, reason
+FROM active_bans
+ORDER BY banned_at
+`
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []enforcer.BanRecord
+	for rows.Next() {
+		var record enforcer.BanRecord
+		var expireAt sql.NullTime
+		var reason sql.NullString
+
+		err := rows.Scan(
+			&record.IP,
+			&record.Port,
+			&record.BannedAt,
+			&expireAt,
+			&record.Duration,
+			&record.Strategy,
+			&reason,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if expireAt.Valid {
+			record.ExpireAt = expireAt.Time
+		}
+		if reason.Valid {
+			record.Reason = reason.String
+		}
+
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// ClearActiveBans 清空活跃封禁（配合清理防火墙规则使用）
+func (d *Database) ClearActiveBans() error {
+	_, err := d.db.Exec(`DELETE FROM active_bans`)
+	return err
+}
+
+// SaveTempAllow 保存临时白名单（同一 IP:端口 覆盖旧记录）
+func (d *Database) SaveTempAllow(record *enforcer.AllowRecord) error {
+	query := `
+INSERT OR REPLACE INTO temp_allows (ip, port, added_at, expire_at, reason)
+VALUES (?, ?, ?, ?, ?)
+`
+	_, err := d.db.Exec(query,
+		record.IP,
+		record.Port,
+		record.AddedAt,
+		record.ExpireAt,
+		record.Reason,
+	)
+
+	return err
+}
+
+// DeleteTempAllow 删除临时白名单
+func (d *Database) DeleteTempAllow(ip string, port int) error {
+	_, err := d.db.Exec(`DELETE FROM temp_allows WHERE ip = ? AND port = ?`, ip, port)
+	return err
+}
+
+// LoadTempAllows 读取全部临时白名单
+func (d *Database) LoadTempAllows() ([]enforcer.AllowRecord, error) {
+	query := `
+SELECT ip, port, added_at, expire_at, reason
+FROM temp_allows
+ORDER BY expire_at
+`
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []enforcer.AllowRecord
+	for rows.Next() {
+		var record enforcer.AllowRecord
+		var reason sql.NullString
+
+		err := rows.Scan(
+			&record.IP,
+			&record.Port,
+			&record.AddedAt,
+			&record.ExpireAt,
+			&reason,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if reason.Valid {
+			record.Reason = reason.String
+		}
+
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
 // RecordStatistics 记录统计数据
 func (d *Database) RecordStatistics(port int, stats *PortStatistics) error {
 	query := `
@@ -193,10 +438,10 @@ ORDER BY hour DESC
 	var stats []PortStatistics
 	for rows.Next() {
 		var stat PortStatistics
-		var hourStr string
 
+		// hour 列声明为 DATETIME，驱动直接解析为 time.Time
 		err := rows.Scan(
-			&hourStr,
+			&stat.Hour,
 			&stat.UniqueIPs,
 			&stat.TotalBans,
 			&stat.AvgSessions,
@@ -205,13 +450,36 @@ ORDER BY hour DESC
 		if err != nil {
 			return nil, err
 		}
-
-		// 解析时间
-		stat.Hour, _ = time.Parse("2006-01-02 15:04:05", hourStr)
 		stats = append(stats, stat)
 	}
 
-	return stats, nil
+	return stats, rows.Err()
+}
+
+// GetBanTimes 获取端口自 since 起的封禁时间（不含黑名单命中记录，按时间升序）
+func (d *Database) GetBanTimes(port int, since time.Time) ([]time.Time, error) {
+	query := `
+SELECT banned_at
+FROM ban_history
+WHERE port = ? AND banned_at >= ? AND strategy != ?
+ORDER BY banned_at ASC
+`
+	rows, err := d.db.Query(query, port, since, enforcer.StrategyBlacklist)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+
+	return times, rows.Err()
 }
 
 // PortStatistics 端口统计数据
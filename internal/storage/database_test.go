@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/enforcer"
+)
+
+func TestBlacklistHitsExcludedFromBanTimes(t *testing.T) {
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "nam.db"))
+	if err != nil {
+		t.Fatalf("NewDatabase() error = %v", err)
+	}
+	defer db.Close()
+
+	const port = 443
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []enforcer.BanRecord{
+		{IP: "192.0.2.1", Port: port, BannedAt: start, ExpireAt: start.Add(time.Hour), Duration: 3600, Reason: "Overlimit", Strategy: "FIFO"},
+		{IP: "198.51.100.7", Port: port, BannedAt: start.Add(time.Minute), Reason: enforcer.ReasonBlacklist, Strategy: enforcer.StrategyBlacklist},
+		{IP: "192.0.2.2", Port: port, BannedAt: start.Add(2 * time.Minute), Duration: 0, Reason: "manual", Strategy: enforcer.StrategyManual},
+		{IP: "192.0.2.3", Port: 8443, BannedAt: start.Add(3 * time.Minute), Reason: "Overlimit", Strategy: "LIFO"},
+	}
+	for i := range records {
+		if err := db.RecordBan(&records[i]); err != nil {
+			t.Fatalf("RecordBan() error = %v", err)
+		}
+	}
+
+	// 超限封禁统计不含黑名单命中
+	times, err := db.GetBanTimes(port, start)
+	if err != nil {
+		t.Fatalf("GetBanTimes() error = %v", err)
+	}
+	want := []time.Time{start, start.Add(2 * time.Minute)}
+	if len(times) != len(want) {
+		t.Fatalf("GetBanTimes() = %v, want %v", times, want)
+	}
+	for i := range want {
+		if !times[i].Equal(want[i]) {
+			t.Errorf("GetBanTimes()[%d] = %v, want %v", i, times[i], want[i])
+		}
+	}
+
+	// 历史中仍保留黑名单命中及其原因
+	history, err := db.GetBanHistorySince(0, 10)
+	if err != nil {
+		t.Fatalf("GetBanHistorySince() error = %v", err)
+	}
+	if len(history) != len(records) {
+		t.Fatalf("GetBanHistorySince() = %d entries, want %d", len(history), len(records))
+	}
+	if hit := history[1]; hit.IP != "198.51.100.7" || hit.Reason != enforcer.ReasonBlacklist || hit.Strategy != enforcer.StrategyBlacklist {
+		t.Errorf("blacklist entry = %+v", hit.BanRecord)
+	}
+}
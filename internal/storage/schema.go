@@ -36,6 +36,32 @@ CREATE TABLE IF NOT EXISTS ban_history (
 
 CREATE INDEX IF NOT EXISTS idx_ban_port_ip ON ban_history(port, ip);
 CREATE INDEX IF NOT EXISTS idx_ban_time ON ban_history(banned_at);
+`
+
+	// CreateActiveBansTable 活跃封禁表（重启后用于恢复定时解封与防火墙对账）
+	CreateActiveBansTable = `
+CREATE TABLE IF NOT EXISTS active_bans (
+    ip TEXT NOT NULL,
+    port INTEGER NOT NULL,
+    banned_at DATETIME NOT NULL,
+    expire_at DATETIME,
+    duration INTEGER NOT NULL,
+    strategy TEXT NOT NULL,
+    reason TEXT,
+    PRIMARY KEY (ip, port)
+);
+`
+
+	// CreateTempAllowsTable 临时白名单表（重启后恢复未到期条目）
+	CreateTempAllowsTable = `
+CREATE TABLE IF NOT EXISTS temp_allows (
+    ip TEXT NOT NULL,
+    port INTEGER NOT NULL,
+    added_at DATETIME NOT NULL,
+    expire_at DATETIME NOT NULL,
+    reason TEXT,
+    PRIMARY KEY (ip, port)
+);
 `
 
 	// CreateStatisticsTable 统计表
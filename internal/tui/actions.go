@@ -0,0 +1,280 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nodeaccessmanager/nam/internal/config"
+	"github.com/nodeaccessmanager/nam/internal/monitor"
+)
+
+// defaultAllowDuration 临时放行的默认时长
+const defaultAllowDuration = "1h"
+
+// defaultBanDuration 端口规则未配置封禁时长（ban_duration 为 0）时封禁对话框的预填时长
+const defaultBanDuration = "1h"
+
+// dialogKind 对话框类型
+type dialogKind int
+
+const (
+	dialogKick  dialogKind = iota // 断开连接（确认）
+	dialogBan                     // 封禁（输入时长与原因）
+	dialogUnban                   // 解封（确认）
+	dialogAllow                   // 临时放行（输入时长与原因）
+)
+
+// dialog 操作对话框：断开与解封只需确认，封禁与临时放行需要输入时长和原因
+type dialog struct {
+	kind   dialogKind
+	ip     string
+	port   int
+	fields []*inputField
+	focus  int
+	err    string // 输入校验错误
+
+	// confirmPermanent 已提示时长 0 为永久封禁，未修改输入时再次 Enter 即执行
+	confirmPermanent bool
+}
+
+// inputField 单行输入框
+type inputField struct {
+	label string
+	value []rune
+}
+
+// actionMsg 操作结果
+type actionMsg struct {
+	text string
+	err  error
+}
+
+// title 对话框标题
+func (d *dialog) title() string {
+	target := fmt.Sprintf("%s（端口 %d）", d.ip, d.port)
+	switch d.kind {
+	case dialogKick:
+		return "断开连接 " + target
+	case dialogBan:
+		return "封禁 " + target
+	case dialogUnban:
+		return "解除封禁 " + target
+	default:
+		return "临时放行 " + target
+	}
+}
+
+// value 输入框内容
+func (d *dialog) value(i int) string {
+	return strings.TrimSpace(string(d.fields[i].value))
+}
+
+// sortedPorts 端口统计页的端口（升序）
+func (m Model) sortedPorts() []int {
+	ports := make([]int, 0, len(m.portStats))
+	for port := range m.portStats {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// detailSessions 端口详情中可选择的会话（按首次连接时间排序）
+func (m Model) detailSessions() []*monitor.Session {
+	sessions := append([]*monitor.Session(nil), m.sessions[m.detailPort]...)
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].FirstSeenAt.Before(sessions[j].FirstSeenAt)
+	})
+	return sessions
+}
+
+// clampCursors 数据刷新后修正选中行（详情中的端口被热重载移除时返回端口列表）
+func (m *Model) clampCursors() {
+	if _, ok := m.portStats[m.detailPort]; !ok {
+		m.detailPort = 0
+	}
+	m.portCursor = clamp(m.portCursor, len(m.portStats))
+	m.sessionCursor = clamp(m.sessionCursor, len(m.detailSessions()))
+	m.banCursor = clamp(m.banCursor, len(m.banRecords))
+}
+
+// clamp 将游标限制在 [0, n) 内
+func clamp(cursor, n int) int {
+	if cursor >= n {
+		cursor = n - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	return cursor
+}
+
+// moveCursor 在当前标签页上下移动选中行
+func (m *Model) moveCursor(delta int) {
+	switch m.activeTab {
+	case tabPorts:
+		if m.detailPort == 0 {
+			m.portCursor = clamp(m.portCursor+delta, len(m.portStats))
+		} else {
+			m.sessionCursor = clamp(m.sessionCursor+delta, len(m.detailSessions()))
+		}
+	case tabBans:
+		m.banCursor = clamp(m.banCursor+delta, len(m.banRecords))
+	}
+}
+
+// openDialog 按快捷键为选中的会话（端口详情中）或封禁打开对话框
+func (m *Model) openDialog(key string) {
+	if m.activeTab == tabPorts && m.detailPort == 0 {
+		return
+	}
+	if _, ok := m.source.(Actions); !ok {
+		m.notice, m.noticeErr = "检查模式下不可操作（守护进程未运行）", true
+		return
+	}
+
+	var ip string
+	var port int
+	switch m.activeTab {
+	case tabPorts:
+		sessions := m.detailSessions()
+		if len(sessions) == 0 || key == "u" {
+			return
+		}
+		ip, port = sessions[m.sessionCursor].IP, sessions[m.sessionCursor].Port
+	case tabBans:
+		if len(m.banRecords) == 0 || key == "k" || key == "b" {
+			return
+		}
+		ip, port = m.banRecords[m.banCursor].IP, m.banRecords[m.banCursor].Port
+	default:
+		return
+	}
+
+	d := &dialog{ip: ip, port: port}
+	switch key {
+	case "k":
+		d.kind = dialogKick
+	case "u":
+		d.kind = dialogUnban
+	case "b":
+		d.kind = dialogBan
+		d.fields = []*inputField{
+			{label: "时长", value: []rune(m.banDurationFor(port))},
+			{label: "原因", value: []rune("manual")},
+		}
+	case "w":
+		d.kind = dialogAllow
+		d.fields = []*inputField{
+			{label: "时长", value: []rune(defaultAllowDuration)},
+			{label: "原因", value: []rune("manual")},
+		}
+	}
+	m.dialog = d
+}
+
+// banDurationFor 封禁对话框预填的时长：端口规则的有效封禁时长，未配置（为 0）时使用 defaultBanDuration
+// 永久封禁需要手动输入 0 并再次确认
+func (m Model) banDurationFor(port int) string {
+	seconds := 0
+	if m.config != nil {
+		seconds = m.config.Global.BanDuration
+		if rule := m.config.GetRuleByPort(port); rule != nil {
+			seconds = rule.GetEffectiveBanDuration(m.config.Global.BanDuration)
+		}
+	}
+	if seconds <= 0 {
+		return defaultBanDuration
+	}
+	return strconv.Itoa(seconds)
+}
+
+// handleDialogKey 对话框打开时处理按键
+func (m Model) handleDialogKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d := m.dialog
+
+	// 确认对话框
+	if len(d.fields) == 0 {
+		switch msg.String() {
+		case "y", "Y", "enter":
+			m.dialog = nil
+			return m, m.runAction(d, 0, "")
+		case "n", "N", "esc":
+			m.dialog = nil
+		}
+		return m, nil
+	}
+
+	// 输入对话框
+	field := d.fields[d.focus]
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.dialog = nil
+	case tea.KeyTab, tea.KeyDown:
+		d.focus = (d.focus + 1) % len(d.fields)
+	case tea.KeyShiftTab, tea.KeyUp:
+		d.focus = (d.focus - 1 + len(d.fields)) % len(d.fields)
+	case tea.KeyBackspace:
+		if len(field.value) > 0 {
+			field.value = field.value[:len(field.value)-1]
+		}
+		d.confirmPermanent = false
+	case tea.KeySpace:
+		field.value = append(field.value, ' ')
+		d.confirmPermanent = false
+	case tea.KeyRunes:
+		field.value = append(field.value, msg.Runes...)
+		d.confirmPermanent = false
+	case tea.KeyEnter:
+		if d.focus < len(d.fields)-1 {
+			d.focus++
+			return m, nil
+		}
+		duration, err := config.ParseDuration(d.value(0))
+		if err == nil && d.kind == dialogAllow && duration == 0 {
+			err = fmt.Errorf("临时放行时长必须大于 0")
+		}
+		if err != nil {
+			d.err = err.Error()
+			d.focus = 0
+			return m, nil
+		}
+		if d.kind == dialogBan && duration == 0 && !d.confirmPermanent {
+			d.confirmPermanent = true
+			d.err = "时长 0 表示永久封禁，再次按 Enter 确认"
+			return m, nil
+		}
+		m.dialog = nil
+		return m, m.runAction(d, duration, d.value(1))
+	}
+	return m, nil
+}
+
+// runAction 在后台通过守护进程执行操作
+func (m Model) runAction(d *dialog, duration int, reason string) tea.Cmd {
+	actions := m.source.(Actions)
+	return func() tea.Msg {
+		target := fmt.Sprintf("%s:%d", d.ip, d.port)
+		var err error
+		var text string
+		switch d.kind {
+		case dialogKick:
+			err = actions.Kick(d.ip, d.port)
+			text = "已断开 " + target
+		case dialogBan:
+			err = actions.Ban(d.ip, d.port, duration, reason)
+			text = "已封禁 " + target
+		case dialogUnban:
+			err = actions.Unban(d.ip, d.port)
+			text = "已解封 " + target
+		case dialogAllow:
+			err = actions.Allow(d.ip, d.port, duration, reason)
+			text = fmt.Sprintf("已临时放行 %s（%s）", target, time.Duration(duration)*time.Second)
+		}
+		return actionMsg{text: text, err: err}
+	}
+}
@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/core"
+)
+
+// sparkLevels 迷你折线图的 8 级高度
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// barHeight 柱状图高度（行）
+const barHeight = 4
+
+// chartWindow 趋势图的时间粒度与列数：实时模式每列 1 分钟共 60 分钟，检查模式每列 1 小时共 24 小时
+func (m Model) chartWindow() (bucket time.Duration, columns int) {
+	if m.source.Live() {
+		return time.Minute, 60
+	}
+	return time.Hour, 24
+}
+
+// series 按列汇总的趋势数据，ips 中 -1 表示该时段没有采样
+type series struct {
+	ips  []int
+	bans []int
+}
+
+// bucketHistory 将采样点按时间粒度汇总为截至 now 的 columns 列（在线 IP 取峰值，封禁数求和）
+func bucketHistory(points []core.HistoryPoint, bucket time.Duration, columns int, now time.Time) series {
+	s := series{ips: make([]int, columns), bans: make([]int, columns)}
+	for i := range s.ips {
+		s.ips[i] = -1
+	}
+
+	end := now.Truncate(bucket)
+	for _, p := range points {
+		col := columns - 1 - int(end.Sub(p.Time.Truncate(bucket))/bucket)
+		if col < 0 || col >= columns {
+			continue
+		}
+		s.ips[col] = max(s.ips[col], p.UniqueIPs)
+		s.bans[col] += p.Bans
+	}
+	return s
+}
+
+// sparkline 迷你折线图（按最大值缩放，无采样的列为空格）
+func sparkline(values []int) string {
+	peak := 0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		switch {
+		case v < 0:
+			b.WriteRune(' ')
+		case peak == 0:
+			b.WriteRune(sparkLevels[0])
+		default:
+			b.WriteRune(sparkLevels[v*(len(sparkLevels)-1)/peak])
+		}
+	}
+	return b.String()
+}
+
+// barChart 纵向柱状图，返回自上而下的各行（顶部用 1/8 块字符表示不足一格的部分）
+func barChart(values []int, height int) []string {
+	peak := 0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+
+	rows := make([]string, height)
+	for r := range rows {
+		var b strings.Builder
+		base := (height - 1 - r) * len(sparkLevels) // 该行底部对应的高度（1/8 格）
+		for _, v := range values {
+			level := 0
+			if peak > 0 {
+				// 非零值至少显示 1/8 格
+				level = (v*height*len(sparkLevels) + peak - 1) / peak
+			}
+			switch {
+			case level >= base+len(sparkLevels):
+				b.WriteRune('█')
+			case level > base:
+				b.WriteRune(sparkLevels[level-base-1])
+			default:
+				b.WriteRune(' ')
+			}
+		}
+		rows[r] = b.String()
+	}
+	return rows
+}
+
+// renderCharts 渲染端口的在线 IP 折线图与封禁柱状图
+func (m Model) renderCharts(port int) string {
+	bucket, columns := m.chartWindow()
+	s := bucketHistory(m.history[port], bucket, columns, time.Now())
+
+	peak, current, sampled := 0, 0, false
+	for _, v := range s.ips {
+		if v >= 0 {
+			peak, current, sampled = max(peak, v), v, true
+		}
+	}
+	total, banPeak := 0, 0
+	for _, v := range s.bans {
+		total += v
+		banPeak = max(banPeak, v)
+	}
+
+	span := fmt.Sprintf("最近 %d 分钟，每列 1 分钟", columns)
+	if bucket == time.Hour {
+		span = fmt.Sprintf("最近 %d 小时，每列 1 小时", columns)
+	}
+	if !sampled && total == 0 {
+		return panelStyle.Render(titleStyle.Render("趋势") + "  " + mutedStyle.Render(span) + "\n\n" + mutedStyle.Render("暂无趋势数据"))
+	}
+
+	maxIPs := 0
+	if stat, ok := m.portStats[port]; ok {
+		maxIPs = stat.MaxIPs
+	}
+
+	lines := []string{
+		fmt.Sprintf("在线 IP  当前 %d  峰值 %d  上限 %d", current, peak, maxIPs),
+		statusOKStyle.Render(sparkline(s.ips)),
+		"",
+		fmt.Sprintf("封禁     合计 %d  单列最多 %d", total, banPeak),
+	}
+	for _, row := range barChart(s.bans, barHeight) {
+		lines = append(lines, statusDangerStyle.Render(row))
+	}
+	lines = append(lines, mutedStyle.Render(axisLabel(bucket, columns)))
+
+	return panelStyle.Render(titleStyle.Render("趋势") + "  " + mutedStyle.Render(span) + "\n\n" + strings.Join(lines, "\n"))
+}
+
+// axisLabel 时间轴标签：左端为窗口起点，右端为当前
+func axisLabel(bucket time.Duration, columns int) string {
+	left := fmt.Sprintf("-%dm", columns)
+	if bucket == time.Hour {
+		left = fmt.Sprintf("-%dh", columns)
+	}
+	right := "现在"
+	padding := columns - len(left) - 4 // "现在" 占 4 列
+	if padding < 1 {
+		padding = 1
+	}
+	return left + strings.Repeat(" ", padding) + right
+}
@@ -0,0 +1,195 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/nodeaccessmanager/nam/internal/config"
+	"github.com/nodeaccessmanager/nam/internal/core"
+	"github.com/nodeaccessmanager/nam/internal/enforcer"
+	"github.com/nodeaccessmanager/nam/internal/monitor"
+	"github.com/nodeaccessmanager/nam/internal/notify"
+	"github.com/nodeaccessmanager/nam/internal/storage"
+)
+
+const (
+	inspectSessionLimit = 100 // 每个端口展示的最近会话数
+	inspectEventLimit   = 200 // 每次读取的封禁历史条数
+	inspectHistoryHours = 24  // 趋势图覆盖的小时数
+)
+
+// inspectSource 守护进程未运行时直接读取数据库：
+// 端口来自配置，会话为守护进程退出时记录的快照，事件为封禁历史
+type inspectSource struct {
+	cfg        *config.Config
+	configPath string
+	db         *storage.Database
+}
+
+// NewInspectSource 打开配置中的数据库作为数据源（数据库不存在时返回错误，不会新建）
+func NewInspectSource(cfg *config.Config, configPath string) (DataSource, error) {
+	path := cfg.Global.DatabasePath
+	if path == "" {
+		path = "/var/lib/nam/nam.db"
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("读取数据库失败: %w", err)
+	}
+
+	db, err := storage.NewDatabase(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &inspectSource{
+		cfg:        cfg,
+		configPath: configPath,
+		db:         db,
+	}, nil
+}
+
+// Live 离线数据
+func (s *inspectSource) Live() bool {
+	return false
+}
+
+// Status 由配置构建端口列表（无实时连接数）
+func (s *inspectSource) Status() (*core.Status, error) {
+	bans, err := s.Bans()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &core.Status{
+		ConfigPath: s.configPath,
+		TotalBans:  len(bans),
+	}
+	for _, rule := range s.cfg.Rules {
+		status.Ports = append(status.Ports, core.PortStatus{
+			Port:     rule.Port,
+			Protocol: rule.Protocol,
+			Tag:      rule.Tag,
+			MaxIPs:   rule.MaxIPs,
+		})
+	}
+	return status, nil
+}
+
+// Sessions 端口最近记录的会话
+func (s *inspectSource) Sessions(port int) ([]*monitor.Session, error) {
+	sessions, err := s.db.GetRecentSessions(port, inspectSessionLimit)
+	if err != nil {
+		return nil, fmt.Errorf("读取会话记录失败: %w", err)
+	}
+	return sessions, nil
+}
+
+// Bans 数据库中尚未到期的封禁（守护进程重启后会按这些记录恢复）
+func (s *inspectSource) Bans() ([]enforcer.BanRecord, error) {
+	records, err := s.db.LoadActiveBans()
+	if err != nil {
+		return nil, fmt.Errorf("读取活跃封禁失败: %w", err)
+	}
+
+	now := time.Now()
+	bans := make([]enforcer.BanRecord, 0, len(records))
+	for _, record := range records {
+		if record.Duration > 0 && !record.ExpireAt.IsZero() && record.ExpireAt.Before(now) {
+			continue
+		}
+		bans = append(bans, record)
+	}
+	return bans, nil
+}
+
+// Allows 数据库中尚未到期的临时白名单
+func (s *inspectSource) Allows() ([]enforcer.AllowRecord, error) {
+	records, err := s.db.LoadTempAllows()
+	if err != nil {
+		return nil, fmt.Errorf("读取临时白名单失败: %w", err)
+	}
+
+	now := time.Now()
+	allows := make([]enforcer.AllowRecord, 0, len(records))
+	for _, record := range records {
+		if record.ExpireAt.After(now) {
+			allows = append(allows, record)
+		}
+	}
+	return allows, nil
+}
+
+// History 按小时汇总的趋势：在线 IP 取自每小时统计，封禁数取自封禁历史
+func (s *inspectSource) History(port int) ([]core.HistoryPoint, error) {
+	stats, err := s.db.GetStatistics(port, inspectHistoryHours)
+	if err != nil {
+		return nil, fmt.Errorf("读取统计数据失败: %w", err)
+	}
+	since := time.Now().Add(-inspectHistoryHours * time.Hour).Truncate(time.Hour)
+	banTimes, err := s.db.GetBanTimes(port, since)
+	if err != nil {
+		return nil, fmt.Errorf("读取封禁历史失败: %w", err)
+	}
+
+	hours := make(map[int64]*core.HistoryPoint)
+	point := func(t time.Time) *core.HistoryPoint {
+		hour := t.Truncate(time.Hour)
+		p, ok := hours[hour.Unix()]
+		if !ok {
+			p = &core.HistoryPoint{Time: hour}
+			hours[hour.Unix()] = p
+		}
+		return p
+	}
+	for _, stat := range stats {
+		if !stat.Hour.Before(since) {
+			point(stat.Hour).UniqueIPs = stat.UniqueIPs
+		}
+	}
+	for _, t := range banTimes {
+		point(t).Bans++
+	}
+
+	points := make([]core.HistoryPoint, 0, len(hours))
+	for _, p := range hours {
+		points = append(points, *p)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Time.Before(points[j].Time)
+	})
+	return points, nil
+}
+
+// Events 以封禁历史的行号作为事件序号
+func (s *inspectSource) Events(after uint64) ([]core.EventEntry, error) {
+	history, err := s.db.GetBanHistorySince(int64(after), inspectEventLimit)
+	if err != nil {
+		return nil, fmt.Errorf("读取封禁历史失败: %w", err)
+	}
+
+	events := make([]core.EventEntry, 0, len(history))
+	for _, entry := range history {
+		event := notify.Event{
+			Type:    notify.EventBan,
+			Time:    entry.BannedAt,
+			Port:    entry.Port,
+			IP:      entry.IP,
+			Message: fmt.Sprintf("封禁 %s（端口 %d，时长 %ds）", entry.IP, entry.Port, entry.Duration),
+			Details: map[string]string{"reason": entry.Reason, "strategy": entry.Strategy},
+		}
+		if entry.Strategy == enforcer.StrategyBlacklist {
+			event.Type = notify.EventBlacklist
+			event.Message = fmt.Sprintf("断开黑名单连接 %s（端口 %d）", entry.IP, entry.Port)
+			event.Details = nil
+		}
+		events = append(events, core.EventEntry{Seq: uint64(entry.ID), Event: event})
+	}
+	return events, nil
+}
+
+// Close 关闭数据库
+func (s *inspectSource) Close() error {
+	return s.db.Close()
+}
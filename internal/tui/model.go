@@ -8,11 +8,27 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/nodeaccessmanager/nam/internal/config"
 	"github.com/nodeaccessmanager/nam/internal/core"
+	"github.com/nodeaccessmanager/nam/internal/enforcer"
+	"github.com/nodeaccessmanager/nam/internal/geoip"
+	"github.com/nodeaccessmanager/nam/internal/monitor"
 )
 
+// 标签页
+const (
+	tabOverview = iota
+	tabPorts
+	tabBans
+	tabEvents
+	tabHelp
+	tabCount
+)
+
+// maxEvents 事件页保留的最近事件条数
+const maxEvents = 200
+
 // Model TUI 模型
 type Model struct {
-	app          *core.App
+	source       DataSource
 	config       *config.Config
 	width        int
 	height       int
@@ -25,6 +41,23 @@ type Model struct {
 	portStats    map[int]PortStat
 	banRecords   []BanRecord
 	systemStats  SystemStats
+	sessions     map[int][]*monitor.Session // port -> 会话
+	events       []core.EventEntry
+	lastEventSeq uint64
+	allows       []enforcer.AllowRecord
+	history      map[int][]core.HistoryPoint // port -> 趋势采样
+	geo          *geoip.DB                   // GeoIP 数据（未配置时为 nil）
+
+	// 端口详情
+	portCursor int // 端口统计页选中的端口
+	detailPort int // 正在查看详情的端口，0 表示端口列表
+
+	// 交互操作
+	sessionCursor int     // 端口详情中选中的会话
+	banCursor     int     // 封禁列表页选中的封禁
+	dialog        *dialog // 打开中的操作对话框
+	notice        string  // 最近一次操作结果
+	noticeErr     bool
 }
 
 // PortStat 端口统计信息
@@ -55,9 +88,9 @@ type SystemStats struct {
 }
 
 // NewModel 创建 TUI 模型
-func NewModel(app *core.App, cfg *config.Config) Model {
+func NewModel(source DataSource, cfg *config.Config) Model {
 	return Model{
-		app:         app,
+		source:      source,
 		config:      cfg,
 		activeTab:   0,
 		autoRefresh: true,
@@ -67,6 +100,11 @@ func NewModel(app *core.App, cfg *config.Config) Model {
 	}
 }
 
+// SetGeoIP 设置 GeoIP 数据，会话详情据此显示来源国家
+func (m *Model) SetGeoIP(db *geoip.DB) {
+	m.geo = db
+}
+
 // Init 初始化
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
@@ -99,9 +137,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.portStats = msg.portStats
 		m.banRecords = msg.banRecords
 		m.systemStats = msg.systemStats
+		m.sessions = msg.sessions
+		m.allows = msg.allows
+		m.history = msg.history
+		m.appendEvents(msg.events)
+		m.clampCursors()
 		m.lastUpdate = time.Now()
+		m.err = nil
 		return m, nil
 
+	case actionMsg:
+		if msg.err != nil {
+			m.notice, m.noticeErr = fmt.Sprintf("操作失败: %v", msg.err), true
+		} else {
+			m.notice, m.noticeErr = msg.text, false
+		}
+		return m, m.fetchData
+
 	case errMsg:
 		m.err = msg.err
 		return m, nil
@@ -125,16 +177,23 @@ func (m Model) View() string {
 	// 内容区
 	var content string
 	switch m.activeTab {
-	case 0:
+	case tabOverview:
 		content = m.renderOverview()
-	case 1:
+	case tabPorts:
 		content = m.renderPortStats()
-	case 2:
+	case tabBans:
 		content = m.renderBanList()
-	case 3:
+	case tabEvents:
+		content = m.renderEvents()
+	case tabHelp:
 		content = m.renderHelp()
 	}
 
+	// 操作对话框
+	if m.dialog != nil {
+		content = m.renderDialog()
+	}
+
 	// 状态栏
 	footer := m.renderFooter()
 
@@ -150,34 +209,58 @@ func (m Model) View() string {
 
 // handleKeyPress 处理按键
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		m.quitting = true
+		return m, tea.Quit
+	}
+	if m.dialog != nil {
+		return m.handleDialogKey(msg)
+	}
+
 	switch msg.String() {
-	case "q", "ctrl+c":
+	case "q":
 		m.quitting = true
 		return m, tea.Quit
 
 	case "tab", "right":
-		m.activeTab = (m.activeTab + 1) % 4
+		m.activeTab = (m.activeTab + 1) % tabCount
 		return m, nil
 
 	case "shift+tab", "left":
-		m.activeTab = (m.activeTab - 1 + 4) % 4
+		m.activeTab = (m.activeTab - 1 + tabCount) % tabCount
 		return m, nil
 
-	case "1":
-		m.activeTab = 0
+	case "1", "2", "3", "4", "5":
+		m.activeTab = int(msg.String()[0] - '1')
 		return m, nil
-	case "2":
-		m.activeTab = 1
+
+	case "r":
+		return m, m.fetchData
+
+	case "enter":
+		if m.activeTab == tabPorts && m.detailPort == 0 {
+			if ports := m.sortedPorts(); len(ports) > 0 {
+				m.detailPort = ports[m.portCursor]
+				m.sessionCursor = 0
+			}
+		}
+		return m, nil
+	case "esc":
+		if m.activeTab == tabPorts {
+			m.detailPort = 0
+		}
 		return m, nil
-	case "3":
-		m.activeTab = 2
+
+	case "up":
+		m.moveCursor(-1)
 		return m, nil
-	case "4":
-		m.activeTab = 3
+	case "down":
+		m.moveCursor(1)
 		return m, nil
 
-	case "r":
-		return m, m.fetchData
+	case "k", "b", "u", "w":
+		m.openDialog(msg.String())
+		return m, nil
 
 	case "space":
 		m.autoRefresh = !m.autoRefresh
@@ -190,7 +273,10 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 // fetchData 获取数据
 func (m Model) fetchData() tea.Msg {
 	// 获取状态
-	status := m.app.GetStatus()
+	status, err := m.source.Status()
+	if err != nil {
+		return errMsg{err: err}
+	}
 
 	// 构建端口统计
 	portStats := make(map[int]PortStat)
@@ -216,7 +302,10 @@ func (m Model) fetchData() tea.Msg {
 
 	// 获取封禁记录
 	banRecords := []BanRecord{}
-	activeBans := m.app.GetActiveBans()
+	activeBans, err := m.source.Bans()
+	if err != nil {
+		return errMsg{err: err}
+	}
 	now := time.Now()
 
 	for _, ban := range activeBans {
@@ -247,19 +336,72 @@ func (m Model) fetchData() tea.Msg {
 		systemStats.TotalSessions += ps.CurrentIPs
 	}
 
+	// 各端口会话与趋势（端口可能刚被热重载移除，跳过即可）
+	sessions := make(map[int][]*monitor.Session)
+	history := make(map[int][]core.HistoryPoint)
+	for _, ps := range status.Ports {
+		if list, err := m.source.Sessions(ps.Port); err == nil {
+			sessions[ps.Port] = list
+		}
+		if points, err := m.source.History(ps.Port); err == nil {
+			history[ps.Port] = points
+		}
+	}
+
+	allows, err := m.source.Allows()
+	if err != nil {
+		return errMsg{err: err}
+	}
+
+	// 增量获取事件
+	events, err := m.source.Events(m.lastEventSeq)
+	if err != nil {
+		return errMsg{err: err}
+	}
+
 	return dataMsg{
 		portStats:   portStats,
 		banRecords:  banRecords,
 		systemStats: systemStats,
+		sessions:    sessions,
+		events:      events,
+		allows:      allows,
+		history:     history,
 	}
 }
 
+// appendEvents 追加新事件；序号回退说明数据源已重启，丢弃旧事件
+func (m *Model) appendEvents(events []core.EventEntry) {
+	if len(events) == 0 {
+		return
+	}
+	if events[len(events)-1].Seq < m.lastEventSeq {
+		m.events = nil
+		m.lastEventSeq = 0
+	}
+
+	// 并发的刷新可能返回已追加过的事件
+	for _, event := range events {
+		if event.Seq > m.lastEventSeq {
+			m.events = append(m.events, event)
+		}
+	}
+	if len(m.events) > maxEvents {
+		m.events = append([]core.EventEntry(nil), m.events[len(m.events)-maxEvents:]...)
+	}
+	m.lastEventSeq = events[len(events)-1].Seq
+}
+
 // 消息类型
 type tickMsg time.Time
 type dataMsg struct {
 	portStats   map[int]PortStat
 	banRecords  []BanRecord
 	systemStats SystemStats
+	sessions    map[int][]*monitor.Session
+	events      []core.EventEntry
+	allows      []enforcer.AllowRecord
+	history     map[int][]core.HistoryPoint
 }
 type errMsg struct {
 	err error
@@ -272,6 +414,23 @@ func tickCmd(d time.Duration) tea.Cmd {
 	})
 }
 
+// formatBytes 格式化字节数（0 表示未统计）
+func formatBytes(n uint64) string {
+	if n == 0 {
+		return "-"
+	}
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 // 格式化时长
 func formatDuration(d time.Duration) string {
 	d = d.Round(time.Second)
@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"github.com/nodeaccessmanager/nam/internal/api"
+	"github.com/nodeaccessmanager/nam/internal/core"
+	"github.com/nodeaccessmanager/nam/internal/enforcer"
+	"github.com/nodeaccessmanager/nam/internal/monitor"
+)
+
+// DataSource TUI 数据来源：运行中的守护进程（控制接口）或离线数据库（检查模式）
+type DataSource interface {
+	// Live 是否连接到运行中的守护进程
+	Live() bool
+
+	Status() (*core.Status, error)
+	Sessions(port int) ([]*monitor.Session, error)
+	Bans() ([]enforcer.BanRecord, error)
+	Allows() ([]enforcer.AllowRecord, error)
+
+	// History 端口的趋势采样点（按时间顺序）
+	History(port int) ([]core.HistoryPoint, error)
+
+	// Events 返回序号大于 after 的事件（按时间顺序）
+	// 返回的最后一条序号小于 after 时表示数据源已重启，调用方应丢弃旧事件
+	Events(after uint64) ([]core.EventEntry, error)
+
+	Close() error
+}
+
+// Actions 交互操作，经守护进程的执行路径完成（记录日志、持久化并产生事件）
+// 只有实时模式的数据源实现该接口
+type Actions interface {
+	Kick(ip string, port int) error
+	Ban(ip string, port int, duration int, reason string) error
+	Unban(ip string, port int) error
+	Allow(ip string, port int, duration int, reason string) error
+}
+
+// liveSource 通过控制接口轮询运行中的守护进程
+type liveSource struct {
+	client *api.Client
+}
+
+// NewLiveSource 创建连接守护进程的数据源
+func NewLiveSource(client *api.Client) DataSource {
+	return &liveSource{client: client}
+}
+
+// Live 实时数据
+func (s *liveSource) Live() bool {
+	return true
+}
+
+// Status 运行状态
+func (s *liveSource) Status() (*core.Status, error) {
+	return s.client.Status()
+}
+
+// Sessions 端口的活跃会话
+func (s *liveSource) Sessions(port int) ([]*monitor.Session, error) {
+	return s.client.Sessions(port)
+}
+
+// Bans 活跃封禁
+func (s *liveSource) Bans() ([]enforcer.BanRecord, error) {
+	return s.client.Bans()
+}
+
+// Allows 临时白名单
+func (s *liveSource) Allows() ([]enforcer.AllowRecord, error) {
+	return s.client.Allows()
+}
+
+// History 守护进程内存中的趋势采样（每 10 秒一个点）
+func (s *liveSource) History(port int) ([]core.HistoryPoint, error) {
+	return s.client.History(port)
+}
+
+// Events 增量事件
+func (s *liveSource) Events(after uint64) ([]core.EventEntry, error) {
+	return s.client.Events(after)
+}
+
+// Close 控制接口客户端无需关闭
+func (s *liveSource) Close() error {
+	return nil
+}
+
+// Kick 断开连接（不封禁）
+func (s *liveSource) Kick(ip string, port int) error {
+	return s.client.Kick(api.KickRequest{IP: ip, Port: port})
+}
+
+// Ban 手动封禁，duration 为 0 表示永久
+func (s *liveSource) Ban(ip string, port int, duration int, reason string) error {
+	return s.client.Ban(api.BanRequest{IP: ip, Port: port, Duration: duration, Reason: reason})
+}
+
+// Unban 提前解封
+func (s *liveSource) Unban(ip string, port int) error {
+	return s.client.Unban(api.UnbanRequest{IP: ip, Port: port})
+}
+
+// Allow 临时放行
+func (s *liveSource) Allow(ip string, port int, duration int, reason string) error {
+	return s.client.Allow(api.AllowRequest{IP: ip, Port: port, Duration: duration, Reason: reason})
+}
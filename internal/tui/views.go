@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/nodeaccessmanager/nam/internal/notify"
 )
 
 // 样式定义
@@ -46,6 +48,10 @@ var (
 	tableCellStyle = lipgloss.NewStyle().
 			Padding(0, 1)
 
+	selectedRowStyle = lipgloss.NewStyle().
+				Padding(0, 1).
+				Reverse(true)
+
 	// 状态样式
 	statusOKStyle = lipgloss.NewStyle().
 			Foreground(successColor).
@@ -83,12 +89,13 @@ var (
 func (m Model) renderHeader() string {
 	title := titleStyle.Render("NAM - Node Access Manager")
 
-	status := m.app.GetStatus()
-	uptime := formatDuration(status.Uptime)
+	uptime := formatDuration(m.systemStats.Uptime)
 
 	statusText := fmt.Sprintf("运行时间: %s", uptime)
-	if !status.IsRunning {
-		statusText = "状态: 未运行"
+	if !m.source.Live() {
+		statusText = "检查模式: 守护进程未运行（只读数据库）"
+	} else if m.err != nil {
+		statusText = "状态: 未连接"
 	}
 
 	statusStyle := lipgloss.NewStyle().
@@ -121,7 +128,7 @@ func (m Model) renderHeader() string {
 
 // renderTabs 渲染标签页
 func (m Model) renderTabs() string {
-	tabs := []string{"概览", "端口统计", "封禁列表", "帮助"}
+	tabs := []string{"概览", "端口统计", "封禁列表", "事件", "帮助"}
 	renderedTabs := make([]string, len(tabs))
 
 	for i, tab := range tabs {
@@ -199,28 +206,28 @@ func (m Model) renderPortSummary() string {
 	return panelStyle.Render(titleStyle.Render("端口摘要") + "\n\n" + content)
 }
 
-// renderPortStats 渲染端口统计详情
+// renderPortStats 渲染端口统计详情（Enter 进入端口详情）
 func (m Model) renderPortStats() string {
 	if len(m.portStats) == 0 {
 		return panelStyle.Render("无监控端口")
 	}
+	if m.detailPort != 0 {
+		return m.renderPortDetail()
+	}
 
 	// 表头
-	header := fmt.Sprintf("%-8s %-10s %-12s %-10s %-10s",
-		"端口", "协议", "当前/最大", "使用率", "状态")
+	header := fmt.Sprintf("%-8s %-10s %-12s %-10s %-22s %-10s",
+		"端口", "协议", "当前/最大", "使用率", "趋势", "状态")
 
 	headerLine := tableHeaderStyle.Render(header)
 
-	// 排序端口
-	ports := make([]int, 0, len(m.portStats))
-	for port := range m.portStats {
-		ports = append(ports, port)
-	}
-	sort.Ints(ports)
+	// 趋势列显示最近 20 个时段的在线 IP
+	bucket, columns := m.chartWindow()
+	now := time.Now()
 
 	// 表格行
 	var rows []string
-	for _, port := range ports {
+	for i, port := range m.sortedPorts() {
 		stat := m.portStats[port]
 
 		usage := 0.0
@@ -229,31 +236,239 @@ func (m Model) renderPortStats() string {
 		}
 
 		statusStr := m.formatStatus(stat.Status)
+		trend := bucketHistory(m.history[port], bucket, columns, now).ips
 
-		row := fmt.Sprintf("%-8d %-10s %-12s %-10s %s",
+		row := fmt.Sprintf("%-8d %-10s %-12s %-10s %-22s",
 			stat.Port,
 			stat.Protocol,
 			fmt.Sprintf("%d/%d", stat.CurrentIPs, stat.MaxIPs),
 			fmt.Sprintf("%.1f%%", usage),
-			statusStr,
+			sparkline(trend[len(trend)-20:]),
 		)
 
-		rows = append(rows, tableCellStyle.Render(row))
+		// 查看详情为只读操作，检查模式下同样可以选择
+		if i == m.portCursor {
+			rows = append(rows, selectedRowStyle.Render(row)+" "+statusStr)
+		} else {
+			rows = append(rows, tableCellStyle.Render(row)+" "+statusStr)
+		}
 	}
 
 	content := strings.Join(rows, "\n")
 
 	return panelStyle.Render(
 		titleStyle.Render("端口统计详情") + "\n\n" +
-		headerLine + "\n" +
-		content,
+			headerLine + "\n" +
+			content + "\n\n" +
+			mutedStyle.Render("↑/↓ 选择  Enter 查看会话与趋势"),
+	)
+}
+
+// renderPortDetail 渲染单个端口的趋势图与会话列表（↑/↓ 选择会话）
+func (m Model) renderPortDetail() string {
+	port := m.detailPort
+	title := fmt.Sprintf("端口 %d", port)
+	if m.config != nil {
+		if rule := m.config.GetRuleByPort(port); rule != nil && rule.Tag != "" {
+			title += " · " + rule.Tag
+		}
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		titleStyle.Render(title),
+		m.renderCharts(port),
+		m.renderSessions(),
 	)
 }
 
+// renderSessions 渲染详情端口的会话列表
+func (m Model) renderSessions() string {
+	title := "活跃会话"
+	if !m.source.Live() {
+		title = "最近记录的会话（守护进程退出时）"
+	}
+
+	header := tableHeaderStyle.Render(fmt.Sprintf("%-40s %-16s %-16s %-8s %-10s %-6s",
+		"IP 地址", "首次连接", "最后活跃", "连接数", "流量", "国家"))
+
+	var rows []string
+	for i, session := range m.detailSessions() {
+		row := fmt.Sprintf("%-40s %-16s %-16s %-8d %-10s %-6s",
+			session.IP,
+			session.FirstSeenAt.Local().Format("01-02 15:04:05"),
+			session.LastSeenAt.Local().Format("01-02 15:04:05"),
+			session.ConnectionNum,
+			formatBytes(session.TotalBytes),
+			m.country(session.IP),
+		)
+		rows = append(rows, m.renderRow(row, i == m.sessionCursor))
+	}
+
+	hint := "Esc 返回端口列表"
+	if m.source.Live() {
+		hint = "↑/↓ 选择  k 断开  b 封禁  w 临时放行  Esc 返回端口列表"
+	}
+
+	if len(rows) == 0 {
+		return panelStyle.Render(titleStyle.Render(title) + "\n\n" + mutedStyle.Render("暂无会话") + "\n\n" + mutedStyle.Render(hint))
+	}
+	return panelStyle.Render(
+		titleStyle.Render(fmt.Sprintf("%s (%d)", title, len(rows))) + "\n\n" +
+			header + "\n" +
+			strings.Join(rows, "\n") + "\n\n" +
+			mutedStyle.Render(hint),
+	)
+}
+
+// country 会话来源国家（未配置 GeoIP 或未收录时为 -）
+func (m Model) country(ip string) string {
+	if c := m.geo.Lookup(ip); c != "" {
+		return c
+	}
+	return "-"
+}
+
+// renderRow 渲染表格行，选中行高亮（仅在实时模式下可选择）
+func (m Model) renderRow(row string, selected bool) string {
+	if selected && m.source.Live() {
+		return selectedRowStyle.Render(row)
+	}
+	return tableCellStyle.Render(row)
+}
+
+// renderAllows 渲染临时白名单
+func (m Model) renderAllows() string {
+	if len(m.allows) == 0 {
+		return ""
+	}
+
+	header := tableHeaderStyle.Render(fmt.Sprintf("%-18s %-8s %-12s %-10s",
+		"IP 地址", "端口", "剩余时间", "原因"))
+
+	now := time.Now()
+	var rows []string
+	for _, allow := range m.allows {
+		remaining := allow.ExpireAt.Sub(now)
+		if remaining < 0 {
+			remaining = 0
+		}
+		row := fmt.Sprintf("%-18s %-8d %-12s %-10s",
+			allow.IP,
+			allow.Port,
+			formatDuration(remaining),
+			allow.Reason,
+		)
+		rows = append(rows, tableCellStyle.Render(row))
+	}
+
+	return panelStyle.Render(
+		titleStyle.Render(fmt.Sprintf("临时白名单 (%d)", len(m.allows))) + "\n\n" +
+			header + "\n" +
+			strings.Join(rows, "\n"),
+	)
+}
+
+// renderDialog 渲染操作对话框
+func (m Model) renderDialog() string {
+	d := m.dialog
+
+	var body []string
+	if len(d.fields) == 0 {
+		body = append(body, "确认执行该操作？", "", mutedStyle.Render("y / Enter 确认  n / Esc 取消"))
+	} else {
+		for i, field := range d.fields {
+			value := string(field.value)
+			if i == d.focus {
+				value += "█"
+			}
+			body = append(body, fmt.Sprintf("%s: %s", field.label, value))
+		}
+		hint := "时长为秒数或 10m、1h 等写法"
+		if d.kind == dialogBan {
+			hint += "，0 表示永久（需再次确认）"
+		}
+		body = append(body, "", mutedStyle.Render(hint), mutedStyle.Render("Tab 切换  Enter 确认  Esc 取消"))
+		if d.err != "" {
+			body = append(body, dangerStyle.Render(d.err))
+		}
+	}
+
+	style := panelStyle
+	if d.kind == dialogKick || d.kind == dialogBan {
+		style = style.BorderForeground(dangerColor)
+	}
+	return style.Render(titleStyle.Render(d.title()) + "\n\n" + strings.Join(body, "\n"))
+}
+
+// renderEvents 渲染最近事件（最新的在前）
+func (m Model) renderEvents() string {
+	title := "最近事件"
+	if !m.source.Live() {
+		title = "封禁历史"
+	}
+
+	if len(m.events) == 0 {
+		return panelStyle.Render(titleStyle.Render(title) + "\n\n" + mutedStyle.Render("暂无事件"))
+	}
+
+	// 按窗口高度截断（未知高度时显示 20 条）
+	limit := 20
+	if m.height > 0 {
+		limit = max(m.height-12, 5)
+	}
+
+	var rows []string
+	for i := len(m.events) - 1; i >= 0 && len(rows) < limit; i-- {
+		event := m.events[i]
+		row := fmt.Sprintf("%-16s %-10s %s",
+			event.Time.Local().Format("01-02 15:04:05"),
+			eventLabel(event.Type),
+			event.Message,
+		)
+		rows = append(rows, tableCellStyle.Render(row))
+	}
+
+	return panelStyle.Render(
+		titleStyle.Render(fmt.Sprintf("%s (%d)", title, len(m.events))) + "\n\n" +
+			strings.Join(rows, "\n"),
+	)
+}
+
+// eventLabel 事件类型名称
+func eventLabel(t notify.EventType) string {
+	switch t {
+	case notify.EventOverlimit:
+		return "超限"
+	case notify.EventBan:
+		return "封禁"
+	case notify.EventUnban:
+		return "解封"
+	case notify.EventBlacklist:
+		return "黑名单"
+	case notify.EventKick:
+		return "断开"
+	case notify.EventAllow:
+		return "放行"
+	case notify.EventReload:
+		return "重载"
+	case notify.EventStart:
+		return "启动"
+	case notify.EventStop:
+		return "停止"
+	default:
+		return string(t)
+	}
+}
+
 // renderBanList 渲染封禁列表
 func (m Model) renderBanList() string {
 	if len(m.banRecords) == 0 {
-		return panelStyle.Render(titleStyle.Render("封禁列表") + "\n\n" + mutedStyle.Render("当前无封禁"))
+		return lipgloss.JoinVertical(
+			lipgloss.Left,
+			panelStyle.Render(titleStyle.Render("封禁列表")+"\n\n"+mutedStyle.Render("当前无封禁")),
+			m.renderAllows(),
+		)
 	}
 
 	// 表头
@@ -264,7 +479,7 @@ func (m Model) renderBanList() string {
 
 	// 表格行
 	var rows []string
-	for _, ban := range m.banRecords {
+	for i, ban := range m.banRecords {
 		bannedTime := ban.BannedAt.Format("01-02 15:04:05")
 		remaining := formatDuration(ban.Remaining)
 
@@ -276,15 +491,22 @@ func (m Model) renderBanList() string {
 			ban.Reason,
 		)
 
-		rows = append(rows, tableCellStyle.Render(row))
+		rows = append(rows, m.renderRow(row, i == m.banCursor))
 	}
 
 	content := strings.Join(rows, "\n")
+	if m.source.Live() {
+		content += "\n\n" + mutedStyle.Render("↑/↓ 选择  u 解封  w 临时放行")
+	}
 
-	return panelStyle.Render(
-		titleStyle.Render(fmt.Sprintf("封禁列表 (%d)", len(m.banRecords))) + "\n\n" +
-		headerLine + "\n" +
-		content,
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		panelStyle.Render(
+			titleStyle.Render(fmt.Sprintf("封禁列表 (%d)", len(m.banRecords)))+"\n\n"+
+				headerLine+"\n"+
+				content,
+		),
+		m.renderAllows(),
 	)
 }
 
@@ -325,19 +547,42 @@ func (m Model) renderHelp() string {
 
   Tab / →         切换到下一个标签页
   Shift+Tab / ←   切换到上一个标签页
-  1/2/3/4         直接跳转到对应标签页
+  1/2/3/4/5       直接跳转到对应标签页
 
   r               手动刷新数据
   Space           暂停/恢复自动刷新
 
+  Enter           端口统计页中查看选中端口的会话与趋势图
+  Esc             从端口详情返回端口列表
+
+操作（仅实时模式，经守护进程执行并记录）：
+
+  ↑ / ↓           选择端口、会话或封禁
+  k               断开选中会话的连接（需确认）
+  b               封禁选中会话（输入时长与原因）
+  u               解除选中的封禁（需确认）
+  w               临时放行（加入临时白名单，到期自动失效）
+
   q / Ctrl+C      退出
 
 标签页说明：
 
   [1] 概览        系统整体状态和摘要信息
-  [2] 端口统计    详细的端口监控数据
+  [2] 端口统计    端口监控数据，详情中为会话（首次/最后活跃、
+                  连接数、流量、国家）与在线 IP、封禁趋势图
   [3] 封禁列表    当前所有封禁记录
-  [4] 帮助        快捷键和使用说明
+  [4] 事件        最近的超限、封禁、解封等事件
+  [5] 帮助        快捷键和使用说明
+
+运行模式：
+
+  实时模式        通过控制接口连接运行中的守护进程
+                  趋势图为最近 60 分钟，每列 1 分钟
+  检查模式        守护进程未运行时只读查看数据库（活跃封禁、
+                  封禁历史与退出时记录的会话）
+                  趋势图为最近 24 小时，每列 1 小时
+
+流量仅在 netlink 连接数据源下统计（当前连接收发字节数）。
 
 状态指示：
 
@@ -383,13 +628,22 @@ func (m Model) renderFooter() string {
 	updateTime := fmt.Sprintf("更新: %s", m.lastUpdate.Format("15:04:05"))
 	parts = append(parts, updateTime)
 
+	// 操作结果
+	if m.notice != "" {
+		if m.noticeErr {
+			parts = append(parts, dangerStyle.Render(m.notice))
+		} else {
+			parts = append(parts, statusOKStyle.Render(m.notice))
+		}
+	}
+
 	// 错误信息
 	if m.err != nil {
 		parts = append(parts, dangerStyle.Render(fmt.Sprintf("错误: %v", m.err)))
 	}
 
 	// 帮助提示
-	parts = append(parts, "按 [4] 查看帮助")
+	parts = append(parts, "按 [5] 查看帮助")
 
 	footer := helpStyle.Render(strings.Join(parts, " │ "))
 
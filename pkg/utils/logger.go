@@ -1,9 +1,11 @@
 package utils
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -12,60 +14,176 @@ import (
 // Logger 全局日志实例
 var Logger *logrus.Logger
 
-// InitLogger 初始化日志系统
+// LogOutput 日志输出目标（配置中的写法见 config.LogOutput*，由 core.LogOptions 转换）
+type LogOutput int
+
+// 日志输出
+const (
+	OutputFile     LogOutput = iota // 滚动日志文件
+	OutputStdout                    // 标准输出（前台运行、容器）
+	OutputSyslog                    // 本地 syslog
+	OutputJournald                  // systemd-journald 原生协议
+)
+
+// DefaultLogFile 默认日志文件路径
+const DefaultLogFile = "/var/log/nam/nam.log"
+
+// LogOptions 日志配置
+type LogOptions struct {
+	Outputs    []LogOutput // 为空时等同于 file（未指定文件时为 stdout）
+	File       string
+	Level      string
+	JSON       bool // true 时输出 JSON，否则为文本
+	MaxSize    int  // MB
+	MaxBackups int  // 保留备份数
+	MaxAge     int  // 天数
+}
+
+var (
+	// logMu 保护 logClosers，保证重新配置时旧输出在切换后才关闭
+	logMu      sync.Mutex
+	logClosers []io.Closer
+
+	// loggerOnce 保证并发首次调用 GetLogger 时只创建一个实例
+	loggerOnce sync.Once
+)
+
+// InitLogger 初始化日志系统（仅写入日志文件，未指定文件时输出到终端）
 func InitLogger(logFile string, level string, maxSize, maxBackups, maxAge int) error {
-	Logger = logrus.New()
+	return ConfigureLogger(LogOptions{
+		File:       logFile,
+		Level:      level,
+		MaxSize:    maxSize,
+		MaxBackups: maxBackups,
+		MaxAge:     maxAge,
+	})
+}
+
+// ConfigureLogger 按配置（重新）设置全局日志：输出、级别与格式
+// 新输出全部创建成功后才替换，失败时保留原有配置；可在运行中重复调用（热重载）
+func ConfigureLogger(opts LogOptions) error {
+	outputs := opts.Outputs
+	if len(outputs) == 0 {
+		outputs = []LogOutput{OutputFile}
+		if opts.File == "" {
+			outputs = []LogOutput{OutputStdout}
+		}
+	}
+
+	var (
+		writers []io.Writer
+		hooks   = make(logrus.LevelHooks)
+		closers []io.Closer
+	)
+	closeAll := func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}
+
+	for _, output := range outputs {
+		switch output {
+		case OutputFile:
+			if opts.File == "" {
+				closeAll()
+				return fmt.Errorf("日志输出 file 需要指定日志文件路径")
+			}
+			// 确保日志目录存在
+			if err := os.MkdirAll(filepath.Dir(opts.File), 0755); err != nil {
+				closeAll()
+				return fmt.Errorf("创建日志目录失败: %w", err)
+			}
+			roller := &lumberjack.Logger{
+				Filename:   opts.File,
+				MaxSize:    opts.MaxSize,
+				MaxBackups: opts.MaxBackups,
+				MaxAge:     opts.MaxAge,
+				Compress:   true, // 压缩旧日志
+			}
+			writers = append(writers, roller)
+			closers = append(closers, roller)
+
+		case OutputStdout:
+			writers = append(writers, os.Stdout)
+
+		case OutputSyslog:
+			hook, err := newSyslogHook()
+			if err != nil {
+				closeAll()
+				return fmt.Errorf("连接 syslog 失败: %w", err)
+			}
+			hooks.Add(hook)
+			closers = append(closers, hook)
+
+		case OutputJournald:
+			hook, err := newJournaldHook()
+			if err != nil {
+				closeAll()
+				return fmt.Errorf("连接 journald 失败: %w", err)
+			}
+			hooks.Add(hook)
+			closers = append(closers, hook)
+
+		default:
+			closeAll()
+			return fmt.Errorf("不支持的日志输出: %d", output)
+		}
+	}
 
 	// 设置日志级别
-	logLevel, err := logrus.ParseLevel(level)
+	logLevel, err := logrus.ParseLevel(opts.Level)
 	if err != nil {
 		logLevel = logrus.InfoLevel
 	}
-	Logger.SetLevel(logLevel)
 
-	// 设置日志格式
-	Logger.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp:   true,
-		TimestampFormat: "2006-01-02 15:04:05",
-	})
+	// 只有 syslog / journald 时丢弃写入器输出，避免重复写到终端
+	var out io.Writer = io.Discard
+	switch len(writers) {
+	case 0:
+	case 1:
+		out = writers[0]
+	default:
+		out = io.MultiWriter(writers...)
+	}
 
-	// 如果指定了日志文件，使用滚动日志
-	if logFile != "" {
-		// 确保日志目录存在
-		logDir := filepath.Dir(logFile)
-		if err := os.MkdirAll(logDir, 0755); err != nil {
-			return err
-		}
+	logger := GetLogger()
+	logger.SetLevel(logLevel)
+	logger.SetFormatter(newFormatter(opts.JSON))
+	logger.SetOutput(out)
+	logger.ReplaceHooks(hooks)
 
-		// 配置滚动日志
-		roller := &lumberjack.Logger{
-			Filename:   logFile,
-			MaxSize:    maxSize,    // MB
-			MaxBackups: maxBackups, // 保留备份数
-			MaxAge:     maxAge,     // 天数
-			Compress:   true,       // 压缩旧日志
-		}
+	logMu.Lock()
+	old := logClosers
+	logClosers = closers
+	logMu.Unlock()
 
-		// 同时输出到文件和终端
-		multiWriter := io.MultiWriter(os.Stdout, roller)
-		Logger.SetOutput(multiWriter)
-	} else {
-		// 仅输出到终端
-		Logger.SetOutput(os.Stdout)
+	for _, c := range old {
+		c.Close()
 	}
-
 	return nil
 }
 
+// newFormatter 创建日志格式器
+func newFormatter(json bool) logrus.Formatter {
+	if json {
+		return &logrus.JSONFormatter{
+			TimestampFormat: "2006-01-02T15:04:05.000Z07:00",
+		}
+	}
+	return &logrus.TextFormatter{
+		FullTimestamp:   true,
+		TimestampFormat: "2006-01-02 15:04:05",
+	}
+}
+
 // GetLogger 获取日志实例
 func GetLogger() *logrus.Logger {
-	if Logger == nil {
-		Logger = logrus.New()
-		Logger.SetLevel(logrus.InfoLevel)
-		Logger.SetFormatter(&logrus.TextFormatter{
-			FullTimestamp:   true,
-			TimestampFormat: "2006-01-02 15:04:05",
-		})
-	}
+	loggerOnce.Do(func() {
+		if Logger == nil {
+			Logger = logrus.New()
+			Logger.SetLevel(logrus.InfoLevel)
+			Logger.SetFormatter(newFormatter(false))
+		}
+	})
 	return Logger
 }
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log/syslog"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logIdentifier syslog / journald 中的程序标识
+const logIdentifier = "nam"
+
+// journaldSocket systemd-journald 原生协议套接字
+const journaldSocket = "/run/systemd/journal/socket"
+
+// syslogHook 将日志按级别写入本地 syslog
+type syslogHook struct {
+	writer *syslog.Writer
+}
+
+// newSyslogHook 连接本地 syslog（facility: daemon）
+func newSyslogHook() (*syslogHook, error) {
+	writer, err := syslog.New(syslog.LOG_DAEMON|syslog.LOG_INFO, logIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogHook{writer: writer}, nil
+}
+
+// Levels 处理全部级别（实际输出由 Logger 级别过滤）
+func (h *syslogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 写入一条日志（syslog 自带时间戳，只发送消息与字段）
+func (h *syslogHook) Fire(entry *logrus.Entry) error {
+	line := entry.Message
+	if len(entry.Data) > 0 {
+		line += " " + formatFields(entry.Data)
+	}
+
+	switch entry.Level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return h.writer.Crit(line)
+	case logrus.ErrorLevel:
+		return h.writer.Err(line)
+	case logrus.WarnLevel:
+		return h.writer.Warning(line)
+	case logrus.InfoLevel:
+		return h.writer.Info(line)
+	default:
+		return h.writer.Debug(line)
+	}
+}
+
+// Close 关闭连接
+func (h *syslogHook) Close() error {
+	return h.writer.Close()
+}
+
+// journaldHook 通过原生协议写入 systemd-journald（保留级别与结构化字段）
+type journaldHook struct {
+	conn *net.UnixConn
+}
+
+// newJournaldHook 连接 journald 套接字
+func newJournaldHook() (*journaldHook, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journaldHook{conn: conn}, nil
+}
+
+// Levels 处理全部级别（实际输出由 Logger 级别过滤）
+func (h *journaldHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 发送一条日志，字段名转换为 journald 要求的大写格式
+func (h *journaldHook) Fire(entry *logrus.Entry) error {
+	var buf bytes.Buffer
+	appendJournalField(&buf, "MESSAGE", entry.Message)
+	appendJournalField(&buf, "PRIORITY", fmt.Sprint(journalPriority(entry.Level)))
+	appendJournalField(&buf, "SYSLOG_IDENTIFIER", logIdentifier)
+
+	for key, value := range entry.Data {
+		if name := journalFieldName(key); name != "" {
+			appendJournalField(&buf, name, fmt.Sprint(value))
+		}
+	}
+
+	_, err := h.conn.Write(buf.Bytes())
+	return err
+}
+
+// Close 关闭连接
+func (h *journaldHook) Close() error {
+	return h.conn.Close()
+}
+
+// appendJournalField 编码一个字段：单行值为 KEY=value，多行值使用长度前缀的二进制格式
+func appendJournalField(buf *bytes.Buffer, key, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteString(key)
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalPriority logrus 级别对应的 syslog 优先级
+func journalPriority(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel:
+		return 0
+	case logrus.FatalLevel:
+		return 2
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// journalFieldName 字段名只能包含大写字母、数字与下划线，且不能以下划线开头
+func journalFieldName(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(key) {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return strings.TrimLeft(b.String(), "_0123456789")
+}
+
+// formatFields 按 key 排序输出 key=value
+func formatFields(data logrus.Fields) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", key, data[key])
+	}
+	return strings.Join(parts, " ")
+}
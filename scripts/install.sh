@@ -19,14 +19,13 @@ fi
 
 # 检查系统依赖
 echo "🔍 检查系统依赖..."
-if ! command -v iptables &> /dev/null; then
-    echo "❌ 未找到 iptables，请先安装"
+if ! command -v nft &> /dev/null && ! command -v iptables &> /dev/null; then
+    echo "❌ 未找到 nftables 或 iptables，请先安装其中之一"
     exit 1
 fi
 
 if ! command -v ss &> /dev/null; then
-    echo "❌ 未找到 ss 命令，请安装 iproute2"
-    exit 1
+    echo "⚠️  未找到 ss 命令，将使用 netlink 或 /proc/net/tcp 采集连接"
 fi
 
 echo "✅ 系统依赖检查通过"
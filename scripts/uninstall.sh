@@ -49,6 +49,38 @@ if [ -f "/etc/systemd/system/nam.service" ]; then
     echo "✅ systemd 服务已删除"
 fi
 
+# 清理防火墙规则（可选，需在删除可执行文件之前执行）
+echo ""
+read -p "🗑️  是否清理 NAM 创建的防火墙规则？(y/N): " cleanup_rules
+if [ "$cleanup_rules" = "y" ] || [ "$cleanup_rules" = "Y" ]; then
+    echo "正在清理防火墙规则..."
+    if [ -x "/usr/local/bin/nam" ]; then
+        /usr/local/bin/nam cleanup --force --remove-chain
+    else
+        # 可执行文件缺失时，直接卸载 NAM-INPUT 链（全部封禁规则都在该链中）
+        for bin in iptables ip6tables; do
+            command -v "$bin" &> /dev/null || continue
+            while "$bin" -D INPUT -j NAM-INPUT 2>/dev/null; do :; done
+            "$bin" -F NAM-INPUT 2>/dev/null || true
+            "$bin" -X NAM-INPUT 2>/dev/null || true
+            # 旧版本直接插入 INPUT 链的规则
+            "$bin"-save | grep -- "^-A INPUT .*--comment \"\?NAM-BAN" | sed 's/^-A /-D /' | while read -r rule; do
+                eval "$bin $rule" 2>/dev/null || true
+            done
+        done
+        # ipset 后端：链删除后才能销毁集合
+        if command -v ipset &> /dev/null; then
+            ipset destroy nam-ban 2>/dev/null || true
+            ipset destroy nam-ban6 2>/dev/null || true
+        fi
+        # nftables 后端：删除 inet nam 表
+        if command -v nft &> /dev/null; then
+            nft delete table inet nam 2>/dev/null || true
+        fi
+    fi
+    echo "✅ 防火墙规则已清理"
+fi
+
 # 删除可执行文件
 echo ""
 echo "🗑️  删除可执行文件..."
@@ -73,20 +105,6 @@ else
     echo "   - /var/log/nam/"
 fi
 
-# 清理 iptables 规则（可选）
-echo ""
-read -p "🗑️  是否清理 NAM 创建的 iptables 规则？(y/N): " cleanup_rules
-if [ "$cleanup_rules" = "y" ] || [ "$cleanup_rules" = "Y" ]; then
-    echo "正在清理 iptables 规则..."
-    # 列出并删除所有带 NAM-BAN 注释的规则
-    iptables-save | grep "NAM-BAN" | while read -r line; do
-        # 转换为删除命令
-        delete_cmd=$(echo "$line" | sed 's/-A /-D /')
-        eval "iptables $delete_cmd" 2>/dev/null || true
-    done
-    echo "✅ iptables 规则已清理"
-fi
-
 echo ""
 echo "========================================="
 echo "✅ NAM 卸载完成！"